@@ -857,7 +857,6 @@ class ApiServerClient {
           });
         }
       });
-      resourceBody.status = opts.status;
     }
     // Create Namespace if not default
     const namespaceId = this.getNamespaceId(opts.resourceType === CONST.APISERVER.RESOURCE_TYPES.INTEROPERATOR_SERVICEBINDINGS ?
@@ -870,6 +869,10 @@ class ApiServerClient {
         .namespaces(namespaceId)[opts.resourceType].post({
           body: resourceBody
         }))
+      // The status of the created resource is ignored, it is set through the status subresource
+      .tap(() => opts.status ? this.updateOSBResourceStatus(_.assign({}, opts, {
+        namespaceId: namespaceId
+      })) : undefined)
       .catch(err => {
         return convertToHttpErrorAndThrow(err);
       });
@@ -908,7 +911,6 @@ class ApiServerClient {
             });
           }
         });
-        patchBody.status = opts.status;
       }
       logger.info(`Updating - Resource ${opts.resourceId} with body - ${JSON.stringify(patchBody)}`);
       // Create Namespace if not default
@@ -916,20 +918,60 @@ class ApiServerClient {
         _.get(opts, 'spec.instance_id') : opts.resourceId
       );
       return Promise.try(() => this.init())
-        .then(() => apiserver
-          .apis[opts.resourceGroup][CONST.APISERVER.API_VERSION]
-          .namespaces(namespaceId)[opts.resourceType](opts.resourceId).patch({
-            body: patchBody,
-            headers: {
-              'content-type': CONST.APISERVER.PATCH_CONTENT_TYPE
-            }
-          }));
+        .then(() => {
+          if (_.isEmpty(patchBody)) {
+            return;
+          }
+          return apiserver
+            .apis[opts.resourceGroup][CONST.APISERVER.API_VERSION]
+            .namespaces(namespaceId)[opts.resourceType](opts.resourceId).patch({
+              body: patchBody,
+              headers: {
+                'content-type': CONST.APISERVER.PATCH_CONTENT_TYPE
+              }
+            });
+        })
+        .then(res => opts.status ? this.updateOSBResourceStatus(_.assign({}, opts, {
+          namespaceId: namespaceId
+        })) : res);
     })
       .catch(err => {
         return convertToHttpErrorAndThrow(err);
       });
   }
 
+  /**
+   * @description Update the status of OSB Resource in Apiserver through its status subresource
+   * @param {string} opts.resourceGroup - Name of resource group ex. osb.servicefabrik.io
+   * @param {string} opts.resourceType - Type of resource ex. sfserviceinstances
+   * @param {string} opts.resourceId - Unique id of resource ex. instance_guid
+   * @param {string} opts.namespaceId - Unique id of namespace
+   * @param {string} opts.status - status of the resource
+   */
+  updateOSBResourceStatus(opts) {
+    assert.ok(opts.resourceGroup, 'Property \'resourceGroup\' is required to update resource status');
+    assert.ok(opts.resourceType, 'Property \'resourceType\' is required to update resource status');
+    assert.ok(opts.resourceId, 'Property \'resourceId\' is required to update resource status');
+    assert.ok(opts.status, 'Property \'status\' is required to update resource status');
+    const patchBody = {
+      status: opts.status
+    };
+    logger.info(`Updating - Status of resource ${opts.resourceId} with body - ${JSON.stringify(patchBody)}`);
+    const namespaceId = opts.namespaceId ? opts.namespaceId : CONST.APISERVER.DEFAULT_NAMESPACE;
+    return Promise.try(() => this.init())
+      .then(() => apiserver
+        .apis[opts.resourceGroup][CONST.APISERVER.API_VERSION]
+        .namespaces(namespaceId)[opts.resourceType](opts.resourceId).status.patch({
+          body: patchBody,
+          headers: {
+            'content-type': CONST.APISERVER.PATCH_CONTENT_TYPE
+          }
+        }))
+      .catch(err => {
+        return convertToHttpErrorAndThrow(err);
+      });
+  }
+
   /**
    * @description Patches OSB Resource in Apiserver with the opts
    * Use this method when you want to append something in status.response or spec
@@ -0,0 +1,118 @@
+/*
+Copyright 2018 The Service Fabrik Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	osbv1alpha1 "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis/osb/v1alpha1"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/render"
+	"github.com/ghodss/yaml"
+)
+
+func main() {
+	var serviceFile, planFile, instanceFile, bindingFile, sourcesFile, action string
+	flag.StringVar(&serviceFile, "service", "", "Path to the SFService yaml.")
+	flag.StringVar(&planFile, "plan", "", "Path to the SFPlan yaml.")
+	flag.StringVar(&instanceFile, "instance", "", "Path to the SFServiceInstance yaml.")
+	flag.StringVar(&bindingFile, "binding", "", "Path to the SFServiceBinding yaml. Required for the bind action.")
+	flag.StringVar(&sourcesFile, "sources", "", "Path to a yaml file with the source objects captured from the cluster. The status is rendered if it is set.")
+	flag.StringVar(&action, "action", osbv1alpha1.ProvisionAction, "The action to render, e.g. provision or bind.")
+	render.AddFlags(flag.CommandLine)
+	flag.Parse()
+
+	if err := run(os.Stdout, serviceFile, planFile, instanceFile, bindingFile, sourcesFile, action); err != nil {
+		fmt.Fprintf(os.Stderr, "render failed. %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(out io.Writer, serviceFile, planFile, instanceFile, bindingFile, sourcesFile, action string) error {
+	if serviceFile == "" || planFile == "" || instanceFile == "" {
+		return fmt.Errorf("--service, --plan and --instance are required")
+	}
+
+	objects := &render.Objects{
+		Service:  &osbv1alpha1.SFService{},
+		Plan:     &osbv1alpha1.SFPlan{},
+		Instance: &osbv1alpha1.SFServiceInstance{},
+	}
+	if err := readObject(serviceFile, objects.Service); err != nil {
+		return err
+	}
+	if err := readObject(planFile, objects.Plan); err != nil {
+		return err
+	}
+	if err := readObject(instanceFile, objects.Instance); err != nil {
+		return err
+	}
+	if bindingFile != "" {
+		objects.Binding = &osbv1alpha1.SFServiceBinding{}
+		if err := readObject(bindingFile, objects.Binding); err != nil {
+			return err
+		}
+	}
+
+	resources, err := render.ExpectedResources(objects, action)
+	if err != nil {
+		return fmt.Errorf("failed to compute expected resources. %v", err)
+	}
+	for _, resource := range resources {
+		content, err := yaml.Marshal(resource.Object)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "---\n%s", content)
+	}
+
+	if sourcesFile == "" {
+		return nil
+	}
+	content, err := ioutil.ReadFile(sourcesFile)
+	if err != nil {
+		return fmt.Errorf("failed to read %s. %v", sourcesFile, err)
+	}
+	objects.Sources, err = render.ParseObjects(string(content))
+	if err != nil {
+		return fmt.Errorf("failed to parse %s. %v", sourcesFile, err)
+	}
+	status, err := render.Status(objects, action)
+	if err != nil {
+		return fmt.Errorf("failed to compute status. %v", err)
+	}
+	content, err = yaml.Marshal(status)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "---\n# status\n%s", content)
+	return nil
+}
+
+func readObject(filename string, obj interface{}) error {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("failed to read %s. %v", filename, err)
+	}
+	if err := yaml.Unmarshal(content, obj); err != nil {
+		return fmt.Errorf("failed to parse %s. %v", filename, err)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2018 The Service Fabrik Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConditionStatus is the status of a condition, True, False or Unknown
+type ConditionStatus string
+
+// List of condition statuses
+const (
+	ConditionTrue    ConditionStatus = "True"
+	ConditionFalse   ConditionStatus = "False"
+	ConditionUnknown ConditionStatus = "Unknown"
+)
+
+// List of condition types
+const (
+	// ClusterReady is set on a SFCluster, it is False while the cluster is
+	// unreachable
+	ClusterReady = "Ready"
+	// ClusterAvailable is set on an instance or binding, it is False while
+	// the cluster it is deployed to is unreachable and its reconcile is
+	// paused
+	ClusterAvailable = "ClusterAvailable"
+
+	// ConditionReady is True once the last operation of an instance or
+	// binding succeeded
+	ConditionReady = "Ready"
+	// ConditionReconciled is False while the reconcile of an instance or
+	// binding fails
+	ConditionReconciled = "Reconciled"
+	// ConditionResourcesApplied is False while the sub resources of an
+	// instance or binding are being applied wave by wave
+	ConditionResourcesApplied = "ResourcesApplied"
+	// ConditionDeleting is True while an instance or binding is being deleted
+	ConditionDeleting = "Deleting"
+)
+
+// stateReasons are the reasons of the Ready condition by state
+var stateReasons = map[string]string{
+	"in_queue":    "Queued",
+	"in progress": "InProgress",
+	"succeeded":   "Succeeded",
+	"failed":      "Failed",
+	"update":      "UpdateRequested",
+	"delete":      "DeleteRequested",
+}
+
+// ReadyCondition returns the Ready condition of an instance or binding in
+// <state>. <message> is the error of a failed operation.
+func ReadyCondition(state, message string) Condition {
+	condition := Condition{
+		Type:   ConditionReady,
+		Status: ConditionFalse,
+		Reason: stateReasons[state],
+	}
+	if condition.Reason == "" {
+		condition.Status = ConditionUnknown
+		condition.Reason = "UnknownState"
+	}
+	if state == "succeeded" {
+		condition.Status = ConditionTrue
+	}
+	if state == "failed" {
+		condition.Message = message
+	}
+	return condition
+}
+
+// Condition is an observation of the state of an object
+type Condition struct {
+	Type   string          `yaml:"type" json:"type"`
+	Status ConditionStatus `yaml:"status" json:"status"`
+	// Reason is a CamelCase reason for the last transition
+	Reason  string `yaml:"reason,omitempty" json:"reason,omitempty"`
+	Message string `yaml:"message,omitempty" json:"message,omitempty"`
+	// LastTransitionTime is the time the status last changed
+	LastTransitionTime metav1.Time `yaml:"lastTransitionTime,omitempty" json:"lastTransitionTime,omitempty"`
+}
+
+// GetCondition returns the condition of the type, nil if it is not set
+func GetCondition(conditions []Condition, conditionType string) *Condition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+// SetCondition adds or updates the condition of its type. The transition
+// time is only updated when the status changes. It returns true if the
+// conditions changed.
+func SetCondition(conditions *[]Condition, condition Condition) bool {
+	existing := GetCondition(*conditions, condition.Type)
+	if existing == nil {
+		condition.LastTransitionTime = metav1.Now()
+		*conditions = append(*conditions, condition)
+		return true
+	}
+	if existing.Status == condition.Status && existing.Reason == condition.Reason && existing.Message == condition.Message {
+		return false
+	}
+	if existing.Status != condition.Status {
+		existing.LastTransitionTime = metav1.Now()
+	}
+	existing.Status = condition.Status
+	existing.Reason = condition.Reason
+	existing.Message = condition.Message
+	return true
+}
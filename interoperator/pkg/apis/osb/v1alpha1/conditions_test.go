@@ -0,0 +1,81 @@
+/*
+Copyright 2018 The Service Fabrik Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSetCondition(t *testing.T) {
+	var conditions []Condition
+	if !SetCondition(&conditions, Condition{Type: ClusterReady, Status: ConditionTrue}) {
+		t.Errorf("SetCondition() = false, want true for a new condition")
+	}
+	added := GetCondition(conditions, ClusterReady)
+	if added == nil || added.LastTransitionTime.IsZero() {
+		t.Fatalf("GetCondition() = %v, want condition with transition time", added)
+	}
+
+	transitionTime := metav1.NewTime(added.LastTransitionTime.Add(-1))
+	added.LastTransitionTime = transitionTime
+	if SetCondition(&conditions, Condition{Type: ClusterReady, Status: ConditionTrue}) {
+		t.Errorf("SetCondition() = true, want false for an unchanged condition")
+	}
+	if !SetCondition(&conditions, Condition{Type: ClusterReady, Status: ConditionTrue, Message: "reachable"}) {
+		t.Errorf("SetCondition() = false, want true for a changed message")
+	}
+	if got := GetCondition(conditions, ClusterReady).LastTransitionTime; !got.Equal(&transitionTime) {
+		t.Errorf("SetCondition() changed the transition time to %v without status change", got)
+	}
+	if !SetCondition(&conditions, Condition{Type: ClusterReady, Status: ConditionFalse, Reason: "Unreachable"}) {
+		t.Errorf("SetCondition() = false, want true for a changed status")
+	}
+	got := GetCondition(conditions, ClusterReady)
+	if got.Status != ConditionFalse || got.Reason != "Unreachable" || got.Message != "" || got.LastTransitionTime.Equal(&transitionTime) {
+		t.Errorf("SetCondition() = %v", got)
+	}
+	if len(conditions) != 1 {
+		t.Errorf("SetCondition() set %d conditions, want 1", len(conditions))
+	}
+	if GetCondition(conditions, ClusterAvailable) != nil {
+		t.Errorf("GetCondition() returned a condition not set")
+	}
+}
+
+func TestReadyCondition(t *testing.T) {
+	tests := []struct {
+		state       string
+		wantStatus  ConditionStatus
+		wantReason  string
+		wantMessage string
+	}{
+		{state: "succeeded", wantStatus: ConditionTrue, wantReason: "Succeeded"},
+		{state: "in progress", wantStatus: ConditionFalse, wantReason: "InProgress"},
+		{state: "failed", wantStatus: ConditionFalse, wantReason: "Failed", wantMessage: "error"},
+		{state: "", wantStatus: ConditionUnknown, wantReason: "UnknownState"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.state, func(t *testing.T) {
+			got := ReadyCondition(tt.state, "error")
+			if got.Type != ConditionReady || got.Status != tt.wantStatus || got.Reason != tt.wantReason || got.Message != tt.wantMessage {
+				t.Errorf("ReadyCondition() = %v, want %v %v %v", got, tt.wantStatus, tt.wantReason, tt.wantMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2018 The Service Fabrik Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KubeconfigKey is the key of the kubeconfig in the secret of a SFCluster
+const KubeconfigKey = "kubeconfig"
+
+// SFClusterSpec defines the desired state of SFCluster
+type SFClusterSpec struct {
+	// SecretRef is the name of the secret, in the namespace of the
+	// SFCluster, holding the kubeconfig of the cluster under KubeconfigKey
+	SecretRef string `json:"secretRef"`
+	// Capacity is the number of service instances the cluster can host.
+	// The capacity is unlimited if it is not set.
+	Capacity int `json:"capacity,omitempty"`
+	// Unschedulable cordons the cluster. No new instances are placed on a
+	// cordoned cluster, the instances deployed to it are still reconciled.
+	Unschedulable bool `json:"unschedulable,omitempty"`
+}
+
+// SFClusterStatus defines the observed state of SFCluster
+type SFClusterStatus struct {
+	// Conditions hold the Ready condition of the cluster, False while the
+	// cluster is unreachable
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SFCluster is the Schema for the sfclusters API. It is a data plane
+// cluster the service instances can be deployed to.
+// +k8s:openapi-gen=true
+type SFCluster struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SFClusterSpec   `json:"spec,omitempty"`
+	Status SFClusterStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SFClusterList contains a list of SFCluster
+type SFClusterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SFCluster `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SFCluster{}, &SFClusterList{})
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2018 The Service Fabrik Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	"golang.org/x/net/context"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kubernetes "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestStorageSFCluster(t *testing.T) {
+	key := types.NamespacedName{
+		Name:      "cluster-id",
+		Namespace: "default",
+	}
+	created := &SFCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cluster-id",
+			Namespace: "default",
+		},
+		Spec: SFClusterSpec{
+			SecretRef: "cluster-id-kubeconfig",
+		},
+	}
+	g := gomega.NewGomegaWithT(t)
+
+	// Test Create
+	fetched := &SFCluster{}
+	g.Expect(c.Create(context.TODO(), created)).NotTo(gomega.HaveOccurred())
+
+	g.Expect(c.Get(context.TODO(), key, fetched)).NotTo(gomega.HaveOccurred())
+	g.Expect(fetched).To(gomega.Equal(created))
+
+	// Test Updating the Labels
+	updatedObject := fetched.DeepCopyObject()
+	updated := updatedObject.(*SFCluster)
+	updated.Labels = map[string]string{"hello": "world"}
+	g.Expect(c.Update(context.TODO(), updated)).NotTo(gomega.HaveOccurred())
+
+	g.Expect(c.Get(context.TODO(), key, fetched)).NotTo(gomega.HaveOccurred())
+	g.Expect(fetched).To(gomega.Equal(updated))
+
+	// Test listing
+	clusterList := &SFClusterList{}
+	options := kubernetes.MatchingLabels(map[string]string{"hello": "world"})
+	options.Namespace = "default"
+	g.Expect(c.List(context.TODO(), options, clusterList)).NotTo(gomega.HaveOccurred())
+	g.Expect(len(clusterList.Items)).To(gomega.Equal(1))
+
+	// Test deepcopy SFClusterList
+	copiedListObject := clusterList.DeepCopyObject()
+	copiedList := copiedListObject.(*SFClusterList)
+	g.Expect(copiedList).To(gomega.Equal(clusterList))
+
+	//Test deepcopy SFClusterSpec and SFClusterStatus
+	copiedSpec := updated.Spec.DeepCopy()
+	g.Expect(copiedSpec).To(gomega.Equal(&updated.Spec))
+	copiedStatus := updated.Status.DeepCopy()
+	g.Expect(copiedStatus).To(gomega.Equal(&updated.Status))
+
+	// Test Delete
+	g.Expect(c.Delete(context.TODO(), fetched)).NotTo(gomega.HaveOccurred())
+	g.Expect(c.Get(context.TODO(), key, fetched)).To(gomega.HaveOccurred())
+}
@@ -29,20 +29,59 @@ const (
 	StatusAction    = "status"
 	BindAction      = "bind"
 	SourcesAction   = "sources"
+	// ExportAction renders the resources exporting the data of an instance
+	// on the cluster it is migrated from
+	ExportAction = "export"
+	// ImportAction renders the resources importing the data of an instance
+	// on the cluster it is migrated to
+	ImportAction = "import"
 )
 
 // TemplateSpec is the specifcation of a template
 type TemplateSpec struct {
-	// +kubebuilder:validation:Enum=provision,status,bind,sources
+	// +kubebuilder:validation:Enum=provision,status,bind,sources,export,import
 	Action string `yaml:"action" json:"action"`
 
-	// +kubebuilder:validation:Enum=gotemplate,helm
+	// +kubebuilder:validation:Enum=gotemplate,helm,jsonnet,kustomize
 	Type           string `yaml:"type" json:"type"`
 	URL            string `yaml:"url,omitempty" json:"url,omitempty"`
 	Content        string `yaml:"content,omitempty" json:"content,omitempty"`
 	ContentEncoded string `yaml:"contentEncoded,omitempty" json:"contentEncoded,omitempty"`
 }
 
+// List of strategies to delete the resources of a plan
+const (
+	// DeleteStrategy deletes the resource
+	DeleteStrategy = "delete"
+	// FieldStrategy sets a field of the resource, e.g. status.state, and
+	// lets the operator of the resource delete it
+	FieldStrategy = "field"
+	// AnnotationStrategy sets an annotation on the resource and lets the
+	// operator of the resource delete it
+	AnnotationStrategy = "annotation"
+	// FinalizerStrategy deletes the resource and waits for its finalizers
+	// to be removed
+	FinalizerStrategy = "finalizer"
+)
+
+// DeletionStrategy defines how the resources of an apiVersion and kind are
+// deleted
+type DeletionStrategy struct {
+	APIVersion string `yaml:"apiVersion" json:"apiVersion"`
+	// Kind of the resources, all the kinds of the apiVersion if empty
+	Kind string `yaml:"kind,omitempty" json:"kind,omitempty"`
+
+	// +kubebuilder:validation:Enum=delete,field,annotation,finalizer
+	Type string `yaml:"type" json:"type"`
+	// Field is the dot separated path of the field set by the field
+	// strategy or the annotation set by the annotation strategy
+	Field string `yaml:"field,omitempty" json:"field,omitempty"`
+	Value string `yaml:"value,omitempty" json:"value,omitempty"`
+	// From lists the values the field strategy replaces. An unset field is
+	// always set. If empty, the field is set whatever its value.
+	From []string `yaml:"from,omitempty" json:"from,omitempty"`
+}
+
 // Schema definition for the input parameters.
 type Schema struct {
 	Parameters *runtime.RawExtension `json:"parameters"`
@@ -76,19 +115,34 @@ type SFPlanSpec struct {
 	Metadata      *runtime.RawExtension `json:"metadata,omitempty"`
 	Free          bool                  `json:"free"`
 	Bindable      bool                  `json:"bindable"`
-	PlanUpdatable bool                  `json:"planUpdatable,omitempty"`
+	PlanUpdatable *bool                 `json:"planUpdatable,omitempty"`
 	Schemas       *ServiceSchemas       `json:"schemas,omitempty"`
 	Templates     []TemplateSpec        `json:"templates"`
 	ServiceID     string                `json:"serviceId"`
 	RawContext    *runtime.RawExtension `json:"context,omitempty"`
 	Manager       *runtime.RawExtension `json:"manager,omitempty"`
+	// DeletionStrategies override how the resources of the plan are deleted
+	DeletionStrategies []DeletionStrategy `json:"deletionStrategies,omitempty"`
 	// Add supported_platform field
 }
 
+// MissingPermission lists the verbs the interoperator is not allowed on the
+// resources of a kind rendered by the plan
+type MissingPermission struct {
+	APIVersion string   `json:"apiVersion"`
+	Kind       string   `json:"kind"`
+	Resource   string   `json:"resource,omitempty"`
+	Verbs      []string `json:"verbs,omitempty"`
+	Message    string   `json:"message,omitempty"`
+}
+
 // SFPlanStatus defines the observed state of SFPlan
 type SFPlanStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// MissingPermissions lists the permissions the interoperator lacks on
+	// the kinds rendered by the templates of the plan
+	MissingPermissions []MissingPermission `json:"missingPermissions,omitempty"`
+	// Error is set if the kinds rendered by the plan could not be computed
+	Error string `json:"error,omitempty"`
 }
 
 // +genclient
@@ -28,6 +28,7 @@ import (
 )
 
 func TestStorageSfPlan(t *testing.T) {
+	planUpdatable := true
 	templateSpec := []TemplateSpec{
 		TemplateSpec{
 			Action:  "provision",
@@ -94,7 +95,7 @@ func TestStorageSfPlan(t *testing.T) {
 			Metadata:      re,
 			Free:          false,
 			Bindable:      true,
-			PlanUpdatable: true,
+			PlanUpdatable: &planUpdatable,
 			Schemas:       schemas,
 			Templates:     templateSpec,
 			ServiceID:     "service-id",
@@ -44,6 +44,12 @@ type SFServiceBindingStatus struct {
 	Response    BindingResponse      `yaml:"response,omitempty" json:"response,omitempty"`
 	AppliedSpec SFServiceBindingSpec `yaml:"appliedSpec,omitempty" json:"appliedSpec,omitempty"`
 	Resources   []Source             `yaml:"resources,omitempty" json:"resources,omitempty"`
+	Deletions   []DeletionStatus     `yaml:"deletions,omitempty" json:"deletions,omitempty"`
+	Conditions  []Condition          `yaml:"conditions,omitempty" json:"conditions,omitempty"`
+	// ObservedGeneration is the generation of the object last reconciled
+	ObservedGeneration int64 `yaml:"observedGeneration,omitempty" json:"observedGeneration,omitempty"`
+	// PendingWave is the wave of sub resources which is not ready yet
+	PendingWave *WaveStatus `yaml:"pendingWave,omitempty" json:"pendingWave,omitempty"`
 }
 
 // BindingResponse defines the details of the binding response
@@ -56,6 +62,7 @@ type BindingResponse struct {
 
 // SFServiceBinding is the Schema for the sfservicebindings API
 // +k8s:openapi-gen=true
+// +kubebuilder:subresource:status
 type SFServiceBinding struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -56,6 +56,56 @@ func (r Source) GetNamespace() string {
 	return r.Namespace
 }
 
+// ResourceChange is the change of a resource planned by a dry run
+type ResourceChange struct {
+	Source `yaml:",inline" json:",inline"`
+	// Action is create, update or delete
+	Action string `yaml:"action" json:"action"`
+	// Diff lists the changed fields, one per line
+	Diff string `yaml:"diff,omitempty" json:"diff,omitempty"`
+}
+
+// DeletionStatus is the progress of the deletion of a resource
+type DeletionStatus struct {
+	Source   `yaml:",inline" json:",inline"`
+	Strategy string `yaml:"strategy" json:"strategy"`
+	Message  string `yaml:"message,omitempty" json:"message,omitempty"`
+}
+
+// WaveStatus is the wave of sub resources which is not ready yet, and the
+// time since when the instance or binding is waiting for it
+type WaveStatus struct {
+	Wave  int         `yaml:"wave" json:"wave"`
+	Since metav1.Time `yaml:"since" json:"since"`
+}
+
+// DryRunStatus is the plan of an update computed without applying it
+type DryRunStatus struct {
+	Spec    SFServiceInstanceSpec `yaml:"spec" json:"spec"`
+	Changes []ResourceChange      `yaml:"changes,omitempty" json:"changes,omitempty"`
+	Error   string                `yaml:"error,omitempty" json:"error,omitempty"`
+}
+
+// MigrationStatus is the progress of the migration of an instance to another
+// cluster
+type MigrationStatus struct {
+	// SourceClusterID is the cluster the instance is migrated from, empty
+	// for the local cluster
+	SourceClusterID string `yaml:"sourceClusterId,omitempty" json:"sourceClusterId,omitempty"`
+	// TargetClusterID is the cluster the instance is migrated to, empty for
+	// the local cluster
+	TargetClusterID string `yaml:"targetClusterId,omitempty" json:"targetClusterId,omitempty"`
+	// Phase is export, provision, import, cleanup, rollback, succeeded or
+	// failed
+	Phase string `yaml:"phase" json:"phase"`
+	// SourceResources are the resources of the instance left on the source
+	// cluster
+	SourceResources []Source `yaml:"sourceResources,omitempty" json:"sourceResources,omitempty"`
+	// Resources are the resources of the instance on the target cluster
+	Resources []Source `yaml:"resources,omitempty" json:"resources,omitempty"`
+	Error     string   `yaml:"error,omitempty" json:"error,omitempty"`
+}
+
 // SFServiceInstanceSpec defines the desired state of SFServiceInstance
 type SFServiceInstanceSpec struct {
 	ServiceID        string                `json:"serviceId"`
@@ -65,6 +115,9 @@ type SFServiceInstanceSpec struct {
 	SpaceGUID        string                `json:"spaceGuid,omitempty"`
 	RawParameters    *runtime.RawExtension `json:"parameters,omitempty"`
 	PreviousValues   *runtime.RawExtension `json:"previousValues,omitempty"`
+	// ClusterID is the name of the SFCluster the instance is deployed to.
+	// The instance is deployed to the local cluster if it is not set.
+	ClusterID string `json:"clusterId,omitempty"`
 }
 
 // SFServiceInstanceStatus defines the observed state of SFServiceInstance
@@ -75,6 +128,14 @@ type SFServiceInstanceStatus struct {
 	Description  string                `yaml:"description,omitempty" json:"description,omitempty"`
 	AppliedSpec  SFServiceInstanceSpec `yaml:"appliedSpec,omitempty" json:"appliedSpec,omitempty"`
 	Resources    []Source              `yaml:"resources,omitempty" json:"resources,omitempty"`
+	DryRun       *DryRunStatus         `yaml:"dryRun,omitempty" json:"dryRun,omitempty"`
+	Deletions    []DeletionStatus      `yaml:"deletions,omitempty" json:"deletions,omitempty"`
+	Migration    *MigrationStatus      `yaml:"migration,omitempty" json:"migration,omitempty"`
+	Conditions   []Condition           `yaml:"conditions,omitempty" json:"conditions,omitempty"`
+	// ObservedGeneration is the generation of the object last reconciled
+	ObservedGeneration int64 `yaml:"observedGeneration,omitempty" json:"observedGeneration,omitempty"`
+	// PendingWave is the wave of sub resources which is not ready yet
+	PendingWave *WaveStatus `yaml:"pendingWave,omitempty" json:"pendingWave,omitempty"`
 }
 
 // +genclient
@@ -82,6 +143,7 @@ type SFServiceInstanceStatus struct {
 
 // SFServiceInstance is the Schema for the sfserviceinstances API
 // +k8s:openapi-gen=true
+// +kubebuilder:subresource:status
 type SFServiceInstance struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
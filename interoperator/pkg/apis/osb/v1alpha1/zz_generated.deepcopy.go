@@ -39,6 +39,23 @@ func (in *BindingResponse) DeepCopy() *BindingResponse {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Condition) DeepCopyInto(out *Condition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Condition.
+func (in *Condition) DeepCopy() *Condition {
+	if in == nil {
+		return nil
+	}
+	out := new(Condition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DashboardClient) DeepCopyInto(out *DashboardClient) {
 	*out = *in
@@ -55,13 +72,237 @@ func (in *DashboardClient) DeepCopy() *DashboardClient {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeletionStatus) DeepCopyInto(out *DeletionStatus) {
+	*out = *in
+	out.Source = in.Source
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeletionStatus.
+func (in *DeletionStatus) DeepCopy() *DeletionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DeletionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeletionStrategy) DeepCopyInto(out *DeletionStrategy) {
+	*out = *in
+	if in.From != nil {
+		in, out := &in.From, &out.From
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeletionStrategy.
+func (in *DeletionStrategy) DeepCopy() *DeletionStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(DeletionStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DryRunStatus) DeepCopyInto(out *DryRunStatus) {
+	*out = *in
+	in.Spec.DeepCopyInto(&out.Spec)
+	if in.Changes != nil {
+		in, out := &in.Changes, &out.Changes
+		*out = make([]ResourceChange, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DryRunStatus.
+func (in *DryRunStatus) DeepCopy() *DryRunStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DryRunStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MigrationStatus) DeepCopyInto(out *MigrationStatus) {
+	*out = *in
+	if in.SourceResources != nil {
+		in, out := &in.SourceResources, &out.SourceResources
+		*out = make([]Source, len(*in))
+		copy(*out, *in)
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]Source, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MigrationStatus.
+func (in *MigrationStatus) DeepCopy() *MigrationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MigrationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MissingPermission) DeepCopyInto(out *MissingPermission) {
+	*out = *in
+	if in.Verbs != nil {
+		in, out := &in.Verbs, &out.Verbs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MissingPermission.
+func (in *MissingPermission) DeepCopy() *MissingPermission {
+	if in == nil {
+		return nil
+	}
+	out := new(MissingPermission)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceChange) DeepCopyInto(out *ResourceChange) {
+	*out = *in
+	out.Source = in.Source
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceChange.
+func (in *ResourceChange) DeepCopy() *ResourceChange {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceChange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SFCluster) DeepCopyInto(out *SFCluster) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SFCluster.
+func (in *SFCluster) DeepCopy() *SFCluster {
+	if in == nil {
+		return nil
+	}
+	out := new(SFCluster)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SFCluster) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SFClusterList) DeepCopyInto(out *SFClusterList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SFCluster, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SFClusterList.
+func (in *SFClusterList) DeepCopy() *SFClusterList {
+	if in == nil {
+		return nil
+	}
+	out := new(SFClusterList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SFClusterList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SFClusterSpec) DeepCopyInto(out *SFClusterSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SFClusterSpec.
+func (in *SFClusterSpec) DeepCopy() *SFClusterSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SFClusterSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SFClusterStatus) DeepCopyInto(out *SFClusterStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SFClusterStatus.
+func (in *SFClusterStatus) DeepCopy() *SFClusterStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SFClusterStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SFPlan) DeepCopyInto(out *SFPlan) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -124,6 +365,11 @@ func (in *SFPlanSpec) DeepCopyInto(out *SFPlanSpec) {
 		*out = new(runtime.RawExtension)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.PlanUpdatable != nil {
+		in, out := &in.PlanUpdatable, &out.PlanUpdatable
+		*out = new(bool)
+		**out = **in
+	}
 	if in.Schemas != nil {
 		in, out := &in.Schemas, &out.Schemas
 		*out = new(ServiceSchemas)
@@ -144,6 +390,13 @@ func (in *SFPlanSpec) DeepCopyInto(out *SFPlanSpec) {
 		*out = new(runtime.RawExtension)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.DeletionStrategies != nil {
+		in, out := &in.DeletionStrategies, &out.DeletionStrategies
+		*out = make([]DeletionStrategy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -160,6 +413,13 @@ func (in *SFPlanSpec) DeepCopy() *SFPlanSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SFPlanStatus) DeepCopyInto(out *SFPlanStatus) {
 	*out = *in
+	if in.MissingPermissions != nil {
+		in, out := &in.MissingPermissions, &out.MissingPermissions
+		*out = make([]MissingPermission, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -303,6 +563,23 @@ func (in *SFServiceBindingStatus) DeepCopyInto(out *SFServiceBindingStatus) {
 		*out = make([]Source, len(*in))
 		copy(*out, *in)
 	}
+	if in.Deletions != nil {
+		in, out := &in.Deletions, &out.Deletions
+		*out = make([]DeletionStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PendingWave != nil {
+		in, out := &in.PendingWave, &out.PendingWave
+		*out = new(WaveStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -417,6 +694,33 @@ func (in *SFServiceInstanceStatus) DeepCopyInto(out *SFServiceInstanceStatus) {
 		*out = make([]Source, len(*in))
 		copy(*out, *in)
 	}
+	if in.DryRun != nil {
+		in, out := &in.DryRun, &out.DryRun
+		*out = new(DryRunStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Deletions != nil {
+		in, out := &in.Deletions, &out.Deletions
+		*out = make([]DeletionStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Migration != nil {
+		in, out := &in.Migration, &out.Migration
+		*out = new(MigrationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PendingWave != nil {
+		in, out := &in.PendingWave, &out.PendingWave
+		*out = new(WaveStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -621,3 +925,20 @@ func (in *TemplateSpec) DeepCopy() *TemplateSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WaveStatus) DeepCopyInto(out *WaveStatus) {
+	*out = *in
+	in.Since.DeepCopyInto(&out.Since)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WaveStatus.
+func (in *WaveStatus) DeepCopy() *WaveStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WaveStatus)
+	in.DeepCopyInto(out)
+	return out
+}
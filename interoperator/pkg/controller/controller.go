@@ -17,6 +17,12 @@ limitations under the License.
 package controller
 
 import (
+	"flag"
+
+	clusterFactory "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/cluster/factory"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/renderer"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/renderer/helm"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/scheduler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
 
@@ -32,3 +38,11 @@ func AddToManager(m manager.Manager) error {
 	}
 	return nil
 }
+
+// AddFlags registers the flags configuring the Controllers in <fs>
+func AddFlags(fs *flag.FlagSet) {
+	clusterFactory.AddFlags(fs)
+	renderer.AddFlags(fs)
+	helm.AddFlags(fs)
+	scheduler.AddFlags(fs)
+}
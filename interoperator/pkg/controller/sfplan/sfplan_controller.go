@@ -19,8 +19,12 @@ package sfplan
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"time"
 
 	osbv1alpha1 "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis/osb/v1alpha1"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/rbac"
+	rendererFactory "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/renderer/factory"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -36,17 +40,25 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
+// permissionCheckInterval is the interval at which the permissions lacked
+// by a plan are checked again
+const permissionCheckInterval = 5 * time.Minute
+
 var log = logf.Log.WithName("plan.controller")
 
 // Add creates a new SFPlan Controller and adds it to the Manager with default RBAC. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
 func Add(mgr manager.Manager) error {
-	return add(mgr, newReconciler(mgr))
+	checker, err := rbac.New(mgr)
+	if err != nil {
+		return err
+	}
+	return add(mgr, newReconciler(mgr, checker))
 }
 
 // newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileSfPlan{Client: mgr.GetClient(), scheme: mgr.GetScheme()}
+func newReconciler(mgr manager.Manager, checker rbac.Checker) reconcile.Reconciler {
+	return &ReconcileSfPlan{Client: mgr.GetClient(), scheme: mgr.GetScheme(), checker: checker}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
@@ -70,13 +82,15 @@ var _ reconcile.Reconciler = &ReconcileSfPlan{}
 // ReconcileSfPlan reconciles a SFPlan object
 type ReconcileSfPlan struct {
 	client.Client
-	scheme *runtime.Scheme
+	scheme  *runtime.Scheme
+	checker rbac.Checker
 }
 
 // Reconcile reads that state of the cluster for a SFPlan object and makes changes based on the state read
 // and what is in the SFPlan.Spec
 // Automatically generate RBAC rules to allow the Controller to read and write Deployments
 // +kubebuilder:rbac:groups=osb.servicefabrik.io,resources=sfplans,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=selfsubjectaccessreviews,verbs=create
 func (r *ReconcileSfPlan) Reconcile(request reconcile.Request) (reconcile.Result, error) {
 	// Fetch the SFPlan instance
 	instance := &osbv1alpha1.SFPlan{}
@@ -85,6 +99,8 @@ func (r *ReconcileSfPlan) Reconcile(request reconcile.Request) (reconcile.Result
 		if errors.IsNotFound(err) {
 			// Object not found, return.  Created objects are automatically garbage collected.
 			// For additional cleanup logic use finalizers.
+			// Drop the templates of the deleted plan from the renderer cache.
+			rendererFactory.InvalidateCache(request.NamespacedName)
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
@@ -151,17 +167,57 @@ func (r *ReconcileSfPlan) Reconcile(request reconcile.Request) (reconcile.Result
 		}
 	}
 
+	status, err := r.computeStatus(service, instance)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if !reflect.DeepEqual(instance.Status, status) {
+		instance.Status = status
+		updateRequired = true
+	}
+
 	if updateRequired {
 		instance.SetLabels(labels)
 		err = r.Update(context.TODO(), instance)
 		if err != nil {
 			return reconcile.Result{}, err
 		}
-		log.Info("Plan labels updated", "plan", instance.GetName())
+		log.Info("Plan updated", "plan", instance.GetName())
+	}
+
+	if status.Error != "" || len(status.MissingPermissions) > 0 {
+		// Check again, the permissions may be granted in the meantime
+		log.Info("Plan lacks permissions", "plan", instance.GetName(), "error", status.Error, "kinds", len(status.MissingPermissions))
+		return reconcile.Result{RequeueAfter: permissionCheckInterval}, nil
 	}
 	return reconcile.Result{}, nil
 }
 
+// computeStatus reports the permissions the interoperator lacks on the
+// kinds rendered by the templates of the plan
+func (r *ReconcileSfPlan) computeStatus(service *osbv1alpha1.SFService, plan *osbv1alpha1.SFPlan) (osbv1alpha1.SFPlanStatus, error) {
+	status := osbv1alpha1.SFPlanStatus{}
+	kinds, kindsErr := rbac.Kinds(service, plan)
+	if kindsErr != nil && !rbac.IsUnknownKinds(kindsErr) {
+		status.Error = kindsErr.Error()
+		return status, nil
+	}
+	missingPermissions, err := r.checker.MissingPermissions(kinds)
+	if err != nil {
+		return status, err
+	}
+	if kindsErr != nil {
+		// The templates may need parameters which the sample instance
+		// does not set, the permissions on their kinds are unknown
+		missingPermissions = append(missingPermissions, osbv1alpha1.MissingPermission{
+			Kind:    "unknown",
+			Message: kindsErr.Error(),
+		})
+	}
+	status.MissingPermissions = missingPermissions
+	return status, nil
+}
+
 // Returns true if a and b point to the same object
 func referSameObject(a, b metav1.OwnerReference) bool {
 	aGV, err := schema.ParseGroupVersion(a.APIVersion)
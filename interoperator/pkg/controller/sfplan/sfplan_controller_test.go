@@ -22,6 +22,7 @@ import (
 	"time"
 
 	osbv1alpha1 "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis/osb/v1alpha1"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/rbac"
 	"github.com/onsi/gomega"
 	"golang.org/x/net/context"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -40,6 +41,7 @@ var expectedRequest = reconcile.Request{NamespacedName: planKey}
 const timeout = time.Second * 5
 
 func TestReconcile(t *testing.T) {
+	planUpdatable := true
 	g := gomega.NewGomegaWithT(t)
 	templateSpec := []osbv1alpha1.TemplateSpec{
 		osbv1alpha1.TemplateSpec{
@@ -102,7 +104,7 @@ func TestReconcile(t *testing.T) {
 			Metadata:      nil,
 			Free:          false,
 			Bindable:      true,
-			PlanUpdatable: true,
+			PlanUpdatable: &planUpdatable,
 			Schemas:       nil,
 			Templates:     templateSpec,
 			ServiceID:     "service-id",
@@ -117,7 +119,9 @@ func TestReconcile(t *testing.T) {
 	g.Expect(err).NotTo(gomega.HaveOccurred())
 	c = mgr.GetClient()
 
-	recFn, requests := SetupTestReconcile(newReconciler(mgr))
+	checker, err := rbac.New(mgr)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	recFn, requests := SetupTestReconcile(newReconciler(mgr, checker))
 	g.Expect(add(mgr, recFn)).NotTo(gomega.HaveOccurred())
 	defer func() {
 		// Drain all requests
@@ -21,16 +21,21 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"time"
 
 	osbv1alpha1 "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis/osb/v1alpha1"
 	clusterFactory "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/cluster/factory"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/renderer"
 	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/resources"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/services"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/watches"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -49,6 +54,12 @@ const (
 	lastOperationKey = "interoperator.servicefabrik.io/lastoperation"
 	errorThreshold   = 10
 	workerCount      = 20
+	// pendingInterval is the interval at which the readiness of a wave of
+	// resources is checked
+	pendingInterval = 10 * time.Second
+	// unavailableInterval is the interval at which a reconcile paused on an
+	// unreachable cluster is retried
+	unavailableInterval = 30 * time.Second
 )
 
 var log = logf.Log.WithName("binding.controller")
@@ -57,21 +68,26 @@ var log = logf.Log.WithName("binding.controller")
 // and Start it when the Manager is Started.
 func Add(mgr manager.Manager) error {
 	clusterFactory, _ := clusterFactory.New(mgr)
-	return add(mgr, newReconciler(mgr, resources.New(), clusterFactory))
+	watchManager, err := watches.New(mgr)
+	if err != nil {
+		return err
+	}
+	return add(mgr, newReconciler(mgr, resources.NewWithMapper(mgr.GetRESTMapper()), clusterFactory, watchManager), watchManager)
 }
 
 // newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager, resourceManager resources.ResourceManager, clusterFactory clusterFactory.ClusterFactory) reconcile.Reconciler {
+func newReconciler(mgr manager.Manager, resourceManager resources.ResourceManager, clusterFactory clusterFactory.ClusterFactory, watchManager watches.Manager) reconcile.Reconciler {
 	return &ReconcileSFServiceBinding{
 		Client:          mgr.GetClient(),
 		scheme:          mgr.GetScheme(),
 		clusterFactory:  clusterFactory,
 		resourceManager: resourceManager,
+		watchManager:    watchManager,
 	}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
-func add(mgr manager.Manager, r reconcile.Reconciler) error {
+func add(mgr manager.Manager, r reconcile.Reconciler, watchManager watches.Manager) error {
 	// Create a new controller
 	c, err := controller.New("sfservicebinding-controller", mgr, controller.Options{Reconciler: r, MaxConcurrentReconciles: workerCount})
 	if err != nil {
@@ -84,38 +100,10 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
-	// TODO dynamically setup rbac rules and watches
-	postgres := &unstructured.Unstructured{}
-	postgres.SetKind("Postgres")
-	postgres.SetAPIVersion("kubedb.com/v1alpha1")
-	directorBind := &unstructured.Unstructured{}
-	directorBind.SetKind("DirectorBind")
-	directorBind.SetAPIVersion("bind.servicefabrik.io/v1alpha1")
-	dockerBind := &unstructured.Unstructured{}
-	dockerBind.SetKind("DockerBind")
-	dockerBind.SetAPIVersion("bind.servicefabrik.io/v1alpha1")
-	postgresqlMtsBind := &unstructured.Unstructured{}
-	postgresqlMtsBind.SetKind("PostgresqlMTBind")
-	postgresqlMtsBind.SetAPIVersion("bind.servicefabrik.io/v1alpha1")
-	vhostMtsBind := &unstructured.Unstructured{}
-	vhostMtsBind.SetKind("VirtualHostBind")
-	vhostMtsBind.SetAPIVersion("bind.servicefabrik.io/v1alpha1")
-	subresources := []runtime.Object{
-		postgres,
-		directorBind,
-		dockerBind,
-		postgresqlMtsBind,
-		vhostMtsBind,
-	}
-
-	for _, subresource := range subresources {
-		err = c.Watch(&source.Kind{Type: subresource}, &handler.EnqueueRequestForOwner{
-			IsController: true,
-			OwnerType:    &osbv1alpha1.SFServiceBinding{},
-		})
-		if err != nil {
-			log.Error(err, "failed to start watch")
-		}
+	// Watch the sub resources of the kinds used by the plans
+	err = watchManager.Start(c, watches.NewEnqueueRequestForOwner(&osbv1alpha1.SFServiceBinding{}, "SFServiceBinding"))
+	if err != nil {
+		return err
 	}
 
 	return nil
@@ -129,6 +117,7 @@ type ReconcileSFServiceBinding struct {
 	scheme          *runtime.Scheme
 	clusterFactory  clusterFactory.ClusterFactory
 	resourceManager resources.ResourceManager
+	watchManager    watches.Manager
 }
 
 // Reconcile reads that state of the cluster for a SFServiceBinding object and makes changes based on the state read
@@ -142,7 +131,8 @@ type ReconcileSFServiceBinding struct {
 // +kubebuilder:rbac:groups=,resources=configmap,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=osb.servicefabrik.io,resources=sfservicebindings,verbs=get;list;watch;create;update;patch;delete
-// TODO dynamically setup rbac rules and watches
+// +kubebuilder:rbac:groups=osb.servicefabrik.io,resources=sfserviceinstances/status;sfservicebindings/status,verbs=get;update;patch
+// TODO dynamically setup rbac rules
 func (r *ReconcileSFServiceBinding) Reconcile(request reconcile.Request) (reconcile.Result, error) {
 	// Fetch the SFServiceBinding instance
 	binding := &osbv1alpha1.SFServiceBinding{}
@@ -173,7 +163,10 @@ func (r *ReconcileSFServiceBinding) Reconcile(request reconcile.Request) (reconc
 		return r.handleError(binding, reconcile.Result{Requeue: true}, nil, "", 0)
 	}
 
-	targetClient, err := r.clusterFactory.GetCluster(instanceID, bindingID, serviceID, planID)
+	// Resume the watches on the sub resources after a restart
+	r.addWatches(binding, watches.SourceKinds(binding.Status.Resources))
+
+	targetClient, err := r.clusterFactory.GetCluster(instanceID, bindingID, serviceID, planID, binding.GetNamespace())
 	if err != nil {
 		return r.handleError(binding, reconcile.Result{}, err, "", 0)
 	}
@@ -189,12 +182,16 @@ func (r *ReconcileSFServiceBinding) Reconcile(request reconcile.Request) (reconc
 		bindSecret.Name = secretName
 		bindSecret.Namespace = binding.GetNamespace()
 		resourceRefs := append(binding.Status.Resources, bindSecret)
-		remainingResource, err := r.resourceManager.DeleteSubResources(targetClient, resourceRefs)
+		strategies, err := r.getDeletionStrategies(binding)
+		if err != nil {
+			return r.handleError(binding, reconcile.Result{}, err, state, 0)
+		}
+		remainingResource, deletions, err := r.resourceManager.DeleteSubResources(targetClient, resourceRefs, strategies)
 		if err != nil {
 			log.Error(err, "Delete sub resources failed")
 			return r.handleError(binding, reconcile.Result{}, err, state, 0)
 		}
-		err = r.setInProgress(request.NamespacedName, state, remainingResource, 0)
+		err = r.setInProgress(request.NamespacedName, state, remainingResource, deletions, 0)
 		if err != nil {
 			return r.handleError(binding, reconcile.Result{}, err, state, 0)
 		}
@@ -204,17 +201,31 @@ func (r *ReconcileSFServiceBinding) Reconcile(request reconcile.Request) (reconc
 		if err != nil {
 			return r.handleError(binding, reconcile.Result{}, err, state, 0)
 		}
-		err = r.resourceManager.SetOwnerReference(binding, expectedResources, r.scheme)
+		err = r.setOwner(binding, expectedResources)
 		if err != nil {
 			return r.handleError(binding, reconcile.Result{}, err, state, 0)
 		}
+		r.addWatches(binding, watches.ObjectKinds(expectedResources))
 
 		resourceRefs, err := r.resourceManager.ReconcileResources(r, targetClient, expectedResources, binding.Status.Resources)
+		if resources.IsPending(err) {
+			log.Info("Waiting for resources to be ready", "objectID", request.Name, "reason", err.Error())
+			pendingErr := err.(*resources.PendingError)
+			since, err := r.setResources(request.NamespacedName, resourceRefs, pendingErr.Wave(), pendingErr.Error(), 0)
+			if err != nil {
+				return r.handleError(binding, reconcile.Result{}, err, state, 0)
+			}
+			if err := resources.CheckWaveTimeout(pendingErr, since); err != nil {
+				log.Error(err, "Resources not ready in time", "objectID", request.Name)
+				return r.handleError(binding, reconcile.Result{}, err, state, 0)
+			}
+			return r.handleError(binding, reconcile.Result{RequeueAfter: pendingInterval}, nil, "", 0)
+		}
 		if err != nil {
 			log.Error(err, "ReconcileResources failed")
 			return r.handleError(binding, reconcile.Result{}, err, state, 0)
 		}
-		err = r.setInProgress(request.NamespacedName, state, resourceRefs, 0)
+		err = r.setInProgress(request.NamespacedName, state, resourceRefs, nil, 0)
 		if err != nil {
 			return r.handleError(binding, reconcile.Result{}, err, state, 0)
 		}
@@ -283,14 +294,14 @@ func (r *ReconcileSFServiceBinding) reconcileFinalizers(object *osbv1alpha1.SFSe
 	return nil
 }
 
-func (r *ReconcileSFServiceBinding) setInProgress(namespacedName types.NamespacedName, state string, resources []osbv1alpha1.Source, retryCount int) error {
+func (r *ReconcileSFServiceBinding) setInProgress(namespacedName types.NamespacedName, state string, resources []osbv1alpha1.Source, deletions []osbv1alpha1.DeletionStatus, retryCount int) error {
 	if state == "in_queue" || state == "update" || state == "delete" {
 		binding := &osbv1alpha1.SFServiceBinding{}
 		err := r.Get(context.TODO(), namespacedName, binding)
 		if err != nil {
 			if retryCount < errorThreshold {
 				log.Info("Retrying", "function", "setInProgress", "retryCount", retryCount+1, "objectID", namespacedName.Name)
-				return r.setInProgress(namespacedName, state, resources, retryCount+1)
+				return r.setInProgress(namespacedName, state, resources, deletions, retryCount+1)
 			}
 			log.Error(err, "Updating status to in progress failed")
 			return err
@@ -303,11 +314,20 @@ func (r *ReconcileSFServiceBinding) setInProgress(namespacedName types.Namespace
 		labels[lastOperationKey] = state
 		binding.SetLabels(labels)
 		binding.Status.Resources = resources
-		err = r.Update(context.Background(), binding)
+		binding.Status.Deletions = deletions
+		binding.Status.PendingWave = nil
+		if state != "delete" {
+			osbv1alpha1.SetCondition(&binding.Status.Conditions, osbv1alpha1.Condition{
+				Type:   osbv1alpha1.ConditionResourcesApplied,
+				Status: osbv1alpha1.ConditionTrue,
+				Reason: "ResourcesApplied",
+			})
+		}
+		err = r.updateWithStatus(binding)
 		if err != nil {
 			if retryCount < errorThreshold {
 				log.Info("Retrying", "function", "setInProgress", "retryCount", retryCount+1, "objectID", namespacedName.Name)
-				return r.setInProgress(namespacedName, state, resources, retryCount+1)
+				return r.setInProgress(namespacedName, state, resources, deletions, retryCount+1)
 			}
 			log.Error(err, "Updating status to in progress failed")
 			return err
@@ -317,6 +337,48 @@ func (r *ReconcileSFServiceBinding) setInProgress(namespacedName types.Namespace
 	return nil
 }
 
+// setResources updates the resources of the binding without changing its
+// state, while the resources of the wave <wave> are not ready. <message> is
+// the reason they are not ready. It returns the time since when the binding
+// is waiting for the wave.
+func (r *ReconcileSFServiceBinding) setResources(namespacedName types.NamespacedName, resources []osbv1alpha1.Source, wave int, message string, retryCount int) (time.Time, error) {
+	binding := &osbv1alpha1.SFServiceBinding{}
+	err := r.Get(context.TODO(), namespacedName, binding)
+	if err != nil {
+		if retryCount < errorThreshold {
+			log.Info("Retrying", "function", "setResources", "retryCount", retryCount+1, "objectID", namespacedName.Name)
+			return r.setResources(namespacedName, resources, wave, message, retryCount+1)
+		}
+		log.Error(err, "Updating resources failed")
+		return time.Time{}, err
+	}
+	binding.Status.Resources = resources
+	pendingWave := binding.Status.PendingWave
+	if pendingWave == nil || pendingWave.Wave != wave {
+		pendingWave = &osbv1alpha1.WaveStatus{
+			Wave:  wave,
+			Since: metav1.Now(),
+		}
+	}
+	binding.Status.PendingWave = pendingWave
+	osbv1alpha1.SetCondition(&binding.Status.Conditions, osbv1alpha1.Condition{
+		Type:    osbv1alpha1.ConditionResourcesApplied,
+		Status:  osbv1alpha1.ConditionFalse,
+		Reason:  "WaitingForResources",
+		Message: message,
+	})
+	err = r.Status().Update(context.Background(), binding)
+	if err != nil {
+		if retryCount < errorThreshold {
+			log.Info("Retrying", "function", "setResources", "retryCount", retryCount+1, "objectID", namespacedName.Name)
+			return r.setResources(namespacedName, resources, wave, message, retryCount+1)
+		}
+		log.Error(err, "Updating resources failed")
+		return time.Time{}, err
+	}
+	return pendingWave.Since.Time, nil
+}
+
 func (r *ReconcileSFServiceBinding) updateUnbindStatus(targetClient client.Client, binding *osbv1alpha1.SFServiceBinding, retryCount int) error {
 	serviceID := binding.Spec.ServiceID
 	planID := binding.Spec.PlanID
@@ -328,6 +390,7 @@ func (r *ReconcileSFServiceBinding) updateUnbindStatus(targetClient client.Clien
 		log.Error(err, "ComputeStatus failed for unbind")
 		return err
 	}
+	r.addWatches(binding, watches.SourceKinds(computedStatus.Sources))
 
 	// Fetch object again before updating status
 	namespacedName := types.NamespacedName{
@@ -341,6 +404,7 @@ func (r *ReconcileSFServiceBinding) updateUnbindStatus(targetClient client.Clien
 	}
 
 	updateRequired := false
+	removeFinalizer := false
 	updatedStatus := binding.Status.DeepCopy()
 	updatedStatus.State = computedStatus.Unbind.State
 	updatedStatus.Error = computedStatus.Unbind.Error
@@ -362,6 +426,13 @@ func (r *ReconcileSFServiceBinding) updateUnbindStatus(targetClient client.Clien
 		}
 	}
 	updatedStatus.Resources = remainingResource
+	var deletions []osbv1alpha1.DeletionStatus
+	for _, deletion := range updatedStatus.Deletions {
+		if containsSource(remainingResource, deletion.Source) {
+			deletions = append(deletions, deletion)
+		}
+	}
+	updatedStatus.Deletions = deletions
 	if !reflect.DeepEqual(&binding.Status, updatedStatus) {
 		updatedStatus.DeepCopyInto(&binding.Status)
 		updateRequired = true
@@ -373,11 +444,17 @@ func (r *ReconcileSFServiceBinding) updateUnbindStatus(targetClient client.Clien
 		binding.SetFinalizers(removeString(binding.GetFinalizers(), finalizerName))
 		binding.SetState("succeeded")
 		updateRequired = true
+		removeFinalizer = true
 	}
 
 	if updateRequired {
 		log.Info("Updating unbind status from template", "binding", namespacedName)
-		if err := r.Update(context.Background(), binding); err != nil {
+		if removeFinalizer {
+			err = r.updateWithStatus(binding)
+		} else {
+			err = r.Status().Update(context.Background(), binding)
+		}
+		if err != nil {
 			if retryCount < errorThreshold {
 				log.Info("Retrying", "function", "updateUnbindStatus", "retryCount", retryCount+1, "bindingID", bindingID)
 				return r.updateUnbindStatus(targetClient, binding, retryCount+1)
@@ -389,6 +466,64 @@ func (r *ReconcileSFServiceBinding) updateUnbindStatus(targetClient client.Clien
 	return nil
 }
 
+// addWatches starts the watches on the kinds of the sub resources used by the
+// plan, in the cluster the instance of the binding is deployed to. The
+// binding is reconciled anyway, a kind which can not be watched is only
+// logged.
+func (r *ReconcileSFServiceBinding) addWatches(binding *osbv1alpha1.SFServiceBinding, gvks []schema.GroupVersionKind) {
+	planID := binding.Spec.PlanID
+	clusterID, err := r.getClusterID(binding)
+	if err != nil {
+		log.Error(err, "failed to watch sub resources", "planID", planID)
+		return
+	}
+	if err := r.watchManager.AddWatches(planID, clusterID, gvks...); err != nil {
+		log.Error(err, "failed to watch sub resources", "planID", planID, "clusterID", clusterID)
+	}
+}
+
+// getClusterID returns the ClusterID of the instance of the binding, empty if
+// the instance is deployed to the local cluster or is not found
+func (r *ReconcileSFServiceBinding) getClusterID(binding *osbv1alpha1.SFServiceBinding) (string, error) {
+	instance := &osbv1alpha1.SFServiceInstance{}
+	err := r.Get(context.TODO(), types.NamespacedName{
+		Name:      binding.Spec.InstanceID,
+		Namespace: binding.GetNamespace(),
+	}, instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return instance.Spec.ClusterID, nil
+}
+
+// setOwner sets the binding as the owner of its resources. The resources
+// deployed to a data plane cluster, along with the instance, are labeled with
+// the binding instead.
+func (r *ReconcileSFServiceBinding) setOwner(binding *osbv1alpha1.SFServiceBinding, expectedResources []*unstructured.Unstructured) error {
+	clusterID, err := r.getClusterID(binding)
+	if err != nil {
+		return err
+	}
+	if clusterID != "" {
+		return resources.SetOwnerLabels(binding, expectedResources, r.scheme)
+	}
+	return r.resourceManager.SetOwnerReference(binding, expectedResources, r.scheme)
+}
+
+// getDeletionStrategies returns the deletion strategies of the plan of the
+// binding. A plan not found is an error like any other, the deletion is retried
+// rather than deleting the resources the plan may keep.
+func (r *ReconcileSFServiceBinding) getDeletionStrategies(binding *osbv1alpha1.SFServiceBinding) ([]osbv1alpha1.DeletionStrategy, error) {
+	_, plan, err := services.FindServiceInfo(r, binding.Spec.ServiceID, binding.Spec.PlanID, services.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	return plan.Spec.DeletionStrategies, nil
+}
+
 func (r *ReconcileSFServiceBinding) updateBindStatus(targetClient client.Client, binding *osbv1alpha1.SFServiceBinding, retryCount int) error {
 	serviceID := binding.Spec.ServiceID
 	planID := binding.Spec.PlanID
@@ -400,6 +535,7 @@ func (r *ReconcileSFServiceBinding) updateBindStatus(targetClient client.Client,
 		log.Error(err, "Compute status failed for bind", "binding", bindingID)
 		return err
 	}
+	r.addWatches(binding, watches.SourceKinds(computedStatus.Sources))
 
 	// Fetch object again before updating status
 	namespacedName := types.NamespacedName{
@@ -457,7 +593,7 @@ func (r *ReconcileSFServiceBinding) updateBindStatus(targetClient client.Client,
 	if !reflect.DeepEqual(&binding.Status, updatedStatus) {
 		updatedStatus.DeepCopyInto(&binding.Status)
 		log.Info("Updating bind status from template", "binding", namespacedName)
-		err = r.Update(context.Background(), binding)
+		err = r.Status().Update(context.Background(), binding)
 		if err != nil {
 			if retryCount < errorThreshold {
 				log.Info("Retrying", "function", "updateBindStatus", "retryCount", retryCount+1, "bindingID", bindingID)
@@ -493,6 +629,12 @@ func removeString(slice []string, s string) (result []string) {
 }
 
 func (r *ReconcileSFServiceBinding) handleError(object *osbv1alpha1.SFServiceBinding, result reconcile.Result, inputErr error, lastOperation string, retryCount int) (reconcile.Result, error) {
+	return r.recordError(object, object.GetGeneration(), result, inputErr, lastOperation, retryCount)
+}
+
+// recordError records the result of the reconcile of the generation
+// <generation> of the object in its status
+func (r *ReconcileSFServiceBinding) recordError(object *osbv1alpha1.SFServiceBinding, generation int64, result reconcile.Result, inputErr error, lastOperation string, retryCount int) (reconcile.Result, error) {
 	objectID := object.GetName()
 	namespace := object.GetNamespace()
 	// Fetch object again before updating
@@ -507,12 +649,31 @@ func (r *ReconcileSFServiceBinding) handleError(object *osbv1alpha1.SFServiceBin
 		}
 		if retryCount < errorThreshold {
 			log.Info("Retrying", "function", "handleError", "retryCount", retryCount+1, "lastOperation", lastOperation, "err", inputErr, "objectID", objectID)
-			return r.handleError(object, result, inputErr, lastOperation, retryCount+1)
+			return r.recordError(object, generation, result, inputErr, lastOperation, retryCount+1)
 		}
 		log.Error(err, "failed to fetch object", "objectID", objectID)
 		return result, inputErr
 	}
 
+	if clusterFactory.IsClusterUnavailable(inputErr) {
+		// Not a failure of the object, the reconcile is paused without
+		// counting the error until the cluster is reachable again
+		log.Info("Cluster unavailable. Pausing reconcile", "objectID", objectID, "reason", inputErr.Error())
+		if setClusterAvailable(&object.Status.Conditions, inputErr) {
+			err := r.Status().Update(context.TODO(), object)
+			if err != nil {
+				if retryCount < errorThreshold {
+					log.Info("Retrying", "function", "handleError", "retryCount", retryCount+1, "lastOperation", lastOperation, "err", inputErr, "objectID", objectID)
+					return r.recordError(object, generation, result, inputErr, lastOperation, retryCount+1)
+				}
+				log.Error(err, "Failed to set condition", "objectID", objectID, "condition", osbv1alpha1.ClusterAvailable)
+			}
+		}
+		return reconcile.Result{RequeueAfter: unavailableInterval}, nil
+	}
+	resumed := inputErr == nil && setClusterAvailable(&object.Status.Conditions, nil)
+	changed := setConditions(object, generation, inputErr)
+
 	labels := object.GetLabels()
 	var count int64
 
@@ -533,11 +694,15 @@ func (r *ReconcileSFServiceBinding) handleError(object *osbv1alpha1.SFServiceBin
 	}
 
 	if inputErr == nil {
-		if count == 0 {
+		if count == 0 && !resumed && !changed {
 			//No change for count
 			return result, inputErr
 		}
 		count = 0
+	} else if renderer.IsLimitExceeded(inputErr) || resources.IsWaveTimeout(inputErr) {
+		// Rendering the templates again exceeds the limits again, and
+		// the wave which timed out is not waited for any longer
+		count = errorThreshold + 1
 	} else {
 		count++
 	}
@@ -546,15 +711,22 @@ func (r *ReconcileSFServiceBinding) handleError(object *osbv1alpha1.SFServiceBin
 		log.Error(inputErr, "Retry threshold reached. Ignoring error", "objectID", objectID)
 		object.Status.State = "failed"
 		object.Status.Error = fmt.Sprintf("Retry threshold reached for %s.\n%s", objectID, inputErr.Error())
+		if renderer.IsLimitExceeded(inputErr) {
+			object.Status.Error = fmt.Sprintf("Rendering templates failed for %s.\n%s", objectID, inputErr.Error())
+		}
+		if resources.IsWaveTimeout(inputErr) {
+			object.Status.Error = fmt.Sprintf("Resources not ready for %s.\n%s", objectID, inputErr.Error())
+		}
 		if lastOperation != "" {
 			labels[lastOperationKey] = lastOperation
 			object.SetLabels(labels)
 		}
-		err := r.Update(context.TODO(), object)
+		setConditions(object, generation, inputErr)
+		err := r.updateWithStatus(object)
 		if err != nil {
 			if retryCount < errorThreshold {
 				log.Info("Retrying", "function", "handleError", "retryCount", retryCount+1, "lastOperation", lastOperation, "err", inputErr, "objectID", objectID)
-				return r.handleError(object, result, inputErr, lastOperation, retryCount+1)
+				return r.recordError(object, generation, result, inputErr, lastOperation, retryCount+1)
 			}
 			log.Error(err, "Failed to set state to failed", "objectID", objectID)
 		}
@@ -563,13 +735,94 @@ func (r *ReconcileSFServiceBinding) handleError(object *osbv1alpha1.SFServiceBin
 
 	labels[errorCountKey] = strconv.FormatInt(count, 10)
 	object.SetLabels(labels)
-	err = r.Update(context.TODO(), object)
+	err = r.updateWithStatus(object)
 	if err != nil {
 		if retryCount < errorThreshold {
 			log.Info("Retrying", "function", "handleError", "retryCount", retryCount+1, "lastOperation", lastOperation, "err", inputErr, "objectID", objectID)
-			return r.handleError(object, result, inputErr, lastOperation, retryCount+1)
+			return r.recordError(object, generation, result, inputErr, lastOperation, retryCount+1)
 		}
 		log.Error(err, "Failed to update error count label", "objectID", objectID, "count", count)
 	}
 	return result, inputErr
 }
+
+// setConditions sets the Ready, Reconciled and Deleting conditions of the
+// binding from its state and the error of the reconcile, and records the
+// generation reconciled. It returns true if the status changed.
+func setConditions(binding *osbv1alpha1.SFServiceBinding, generation int64, inputErr error) bool {
+	conditions := &binding.Status.Conditions
+	changed := osbv1alpha1.SetCondition(conditions, osbv1alpha1.ReadyCondition(binding.GetState(), binding.Status.Error))
+
+	reconciled := osbv1alpha1.Condition{
+		Type:   osbv1alpha1.ConditionReconciled,
+		Status: osbv1alpha1.ConditionTrue,
+		Reason: "ReconcileSucceeded",
+	}
+	if inputErr != nil {
+		reconciled.Status = osbv1alpha1.ConditionFalse
+		reconciled.Reason = "ReconcileFailed"
+		reconciled.Message = inputErr.Error()
+	}
+	changed = osbv1alpha1.SetCondition(conditions, reconciled) || changed
+
+	deleting := osbv1alpha1.Condition{
+		Type:   osbv1alpha1.ConditionDeleting,
+		Status: osbv1alpha1.ConditionFalse,
+		Reason: "NotDeleting",
+	}
+	if binding.GetState() == "delete" || !binding.GetDeletionTimestamp().IsZero() {
+		deleting.Status = osbv1alpha1.ConditionTrue
+		deleting.Reason = "DeleteRequested"
+	}
+	changed = osbv1alpha1.SetCondition(conditions, deleting) || changed
+
+	if generation != 0 && binding.Status.ObservedGeneration != generation {
+		binding.Status.ObservedGeneration = generation
+		changed = true
+	}
+	return changed
+}
+
+// updateWithStatus updates the binding and writes its status through the
+// status subresource. The status is written first, so that it is recorded
+// before a finalizer is removed.
+func (r *ReconcileSFServiceBinding) updateWithStatus(binding *osbv1alpha1.SFServiceBinding) error {
+	updated := binding.DeepCopy()
+	if err := r.Status().Update(context.TODO(), updated); err != nil {
+		return err
+	}
+	binding.SetResourceVersion(updated.GetResourceVersion())
+	return r.Update(context.TODO(), binding)
+}
+
+// setClusterAvailable sets the ClusterAvailable condition to False if
+// <err> is not nil. Otherwise the condition is set to True if it was set
+// before. It returns true if the conditions changed.
+func setClusterAvailable(conditions *[]osbv1alpha1.Condition, err error) bool {
+	if err != nil {
+		return osbv1alpha1.SetCondition(conditions, osbv1alpha1.Condition{
+			Type:    osbv1alpha1.ClusterAvailable,
+			Status:  osbv1alpha1.ConditionFalse,
+			Reason:  "ClusterUnavailable",
+			Message: err.Error(),
+		})
+	}
+	if osbv1alpha1.GetCondition(*conditions, osbv1alpha1.ClusterAvailable) == nil {
+		return false
+	}
+	return osbv1alpha1.SetCondition(conditions, osbv1alpha1.Condition{
+		Type:   osbv1alpha1.ClusterAvailable,
+		Status: osbv1alpha1.ConditionTrue,
+		Reason: "ClusterAvailable",
+	})
+}
+
+// containsSource checks if the slice of resources contains the resource
+func containsSource(slice []osbv1alpha1.Source, s osbv1alpha1.Source) bool {
+	for _, item := range slice {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
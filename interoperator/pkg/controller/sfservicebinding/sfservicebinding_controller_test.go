@@ -25,6 +25,7 @@ import (
 	mock_clusterFactory "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/cluster/factory/mock_factory"
 	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/properties"
 	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/resources/mock_resources"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/watches"
 	"github.com/golang/mock/gomock"
 
 	"github.com/onsi/gomega"
@@ -89,6 +90,8 @@ var service = &osbv1alpha1.SFService{
 	},
 }
 
+var planUpdatable = true
+
 var plan = &osbv1alpha1.SFPlan{
 	ObjectMeta: metav1.ObjectMeta{
 		Name:      "plan-id",
@@ -105,7 +108,7 @@ var plan = &osbv1alpha1.SFPlan{
 		Metadata:      nil,
 		Free:          false,
 		Bindable:      true,
-		PlanUpdatable: true,
+		PlanUpdatable: &planUpdatable,
 		Schemas:       nil,
 		Templates:     templateSpec,
 		ServiceID:     "service-id",
@@ -180,11 +183,13 @@ func TestReconcile(t *testing.T) {
 
 	mockResourceManager := mock_resources.NewMockResourceManager(ctrl)
 	mockClusterFactory := mock_clusterFactory.NewMockClusterFactory(ctrl)
-	reconciler := newReconciler(mgr, mockResourceManager, mockClusterFactory)
+	watchManager, err := watches.New(mgr)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	reconciler := newReconciler(mgr, mockResourceManager, mockClusterFactory, watchManager)
 
 	mockResourceManager.EXPECT().ComputeExpectedResources(gomock.Any(), "instance-id", "binding-id", "service-id", "plan-id", osbv1alpha1.BindAction, "default").Return(expectedResources, nil).AnyTimes()
 	mockResourceManager.EXPECT().SetOwnerReference(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
-	mockClusterFactory.EXPECT().GetCluster("instance-id", "binding-id", "service-id", "plan-id").Return(reconciler, nil).AnyTimes()
+	mockClusterFactory.EXPECT().GetCluster("instance-id", "binding-id", "service-id", "plan-id", "default").Return(reconciler, nil).AnyTimes()
 	mockResourceManager.EXPECT().ReconcileResources(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(appliedResources, err1).Times(1)
 	mockResourceManager.EXPECT().ReconcileResources(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(appliedResources, nil).AnyTimes()
 	mockResourceManager.EXPECT().ComputeStatus(gomock.Any(), gomock.Any(), "instance-id", "binding-id", "service-id", "plan-id", osbv1alpha1.BindAction, "default").Return(&properties.Status{
@@ -196,10 +201,10 @@ func TestReconcile(t *testing.T) {
 			State: "succeeded",
 		},
 	}, nil).AnyTimes()
-	mockResourceManager.EXPECT().DeleteSubResources(gomock.Any(), gomock.Any()).Return(appliedResources, nil).AnyTimes()
+	mockResourceManager.EXPECT().DeleteSubResources(gomock.Any(), gomock.Any(), gomock.Any()).Return(appliedResources, nil, nil).AnyTimes()
 
 	recFn, requests := SetupTestReconcile(reconciler)
-	g.Expect(add(mgr, recFn)).NotTo(gomega.HaveOccurred())
+	g.Expect(add(mgr, recFn, watchManager)).NotTo(gomega.HaveOccurred())
 
 	stopMgr, mgrStopped := StartTestManager(mgr, g)
 
@@ -0,0 +1,359 @@
+/*
+Copyright 2018 The Service Fabrik Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sfserviceinstance
+
+import (
+	"context"
+	"reflect"
+
+	osbv1alpha1 "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis/osb/v1alpha1"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/properties"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/resources"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/services"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/watches"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Phases of the migration of an instance to another cluster
+const (
+	// migrationExport applies the export template on the source cluster
+	migrationExport = "export"
+	// migrationProvision applies the provision template on the target
+	// cluster
+	migrationProvision = "provision"
+	// migrationImport applies the import template on the target cluster and
+	// then switches the instance to the target cluster
+	migrationImport = "import"
+	// migrationCleanup deletes the resources left on the source cluster
+	migrationCleanup = "cleanup"
+	// migrationRollback deletes the resources created by a failed migration
+	migrationRollback  = "rollback"
+	migrationSucceeded = "succeeded"
+	migrationFailed    = "failed"
+)
+
+// isMigrating returns true if the migration is not completed
+func isMigrating(migration *osbv1alpha1.MigrationStatus) bool {
+	return migration != nil && migration.Phase != migrationSucceeded && migration.Phase != migrationFailed
+}
+
+// startMigration returns the migration requested by the migrateToKey
+// annotation of the instance, nil if the instance is not to be migrated.
+// Only instances provisioned successfully are migrated.
+func startMigration(instance *osbv1alpha1.SFServiceInstance) *osbv1alpha1.MigrationStatus {
+	target, ok := instance.GetAnnotations()[migrateToKey]
+	if !ok || target == instance.Spec.ClusterID || isMigrating(instance.Status.Migration) {
+		return nil
+	}
+	if instance.GetState() != "succeeded" || !instance.GetDeletionTimestamp().IsZero() {
+		return nil
+	}
+	return &osbv1alpha1.MigrationStatus{
+		SourceClusterID: instance.Spec.ClusterID,
+		TargetClusterID: target,
+		Phase:           migrationExport,
+		SourceResources: instance.Status.Resources,
+	}
+}
+
+// migrate runs the next step of the migration of the instance. It returns
+// false if the instance is not being migrated.
+func (r *ReconcileSFServiceInstance) migrate(instance *osbv1alpha1.SFServiceInstance) (bool, reconcile.Result, error) {
+	instanceID := instance.GetName()
+	if !isMigrating(instance.Status.Migration) {
+		migration := startMigration(instance)
+		if migration == nil {
+			return false, reconcile.Result{}, nil
+		}
+		bound, err := r.hasBindings(instance)
+		if err != nil {
+			return true, reconcile.Result{}, err
+		}
+		if bound {
+			// The resources of the bindings are not migrated
+			migration.Phase = migrationFailed
+			migration.Error = "instance has bindings. migrate it once its bindings are deleted"
+			log.Info("Rejecting migration", "instance", instanceID, "reason", migration.Error)
+			return true, reconcile.Result{}, r.setMigration(instance, migration, false, 0)
+		}
+		log.Info("Starting migration", "instance", instanceID, "sourceClusterID", migration.SourceClusterID, "targetClusterID", migration.TargetClusterID)
+		return true, reconcile.Result{Requeue: true}, r.setMigration(instance, migration, false, 0)
+	}
+
+	migration := instance.Status.Migration.DeepCopy()
+	if !instance.GetDeletionTimestamp().IsZero() && migration.Phase != migrationRollback && migration.Phase != migrationCleanup {
+		log.Info("Instance deleted, rolling back migration", "instance", instanceID)
+		migration.Phase = migrationRollback
+		migration.Error = "instance deleted during the migration"
+		return true, reconcile.Result{Requeue: true}, r.setMigration(instance, migration, false, 0)
+	}
+
+	sourceClient, err := r.clusterFactory.GetClusterClient(migration.SourceClusterID)
+	if err != nil {
+		return true, reconcile.Result{}, err
+	}
+	targetClient, err := r.clusterFactory.GetClusterClient(migration.TargetClusterID)
+	if err != nil {
+		return true, reconcile.Result{}, err
+	}
+	_, plan, err := services.FindServiceInfo(r, instance.Spec.ServiceID, instance.Spec.PlanID, services.Namespace)
+	if err != nil {
+		return true, reconcile.Result{}, err
+	}
+
+	result := reconcile.Result{}
+	switchCluster := false
+	switch migration.Phase {
+	case migrationExport:
+		if _, err := plan.GetTemplate(osbv1alpha1.ExportAction); err != nil {
+			migration.Phase = migrationProvision
+			result.Requeue = true
+			break
+		}
+		refs, status, err := r.applyResources(instance, plan, sourceClient, migration.SourceClusterID, osbv1alpha1.ExportAction, nil)
+		if err != nil {
+			return true, result, err
+		}
+		migration.SourceResources = mergeSources(migration.SourceResources, refs)
+		result = advanceMigration(migration, status, migrationProvision)
+	case migrationProvision:
+		refs, status, err := r.applyResources(instance, plan, targetClient, migration.TargetClusterID, osbv1alpha1.ProvisionAction, migration.Resources)
+		if err != nil {
+			return true, result, err
+		}
+		migration.Resources = refs
+		result = advanceMigration(migration, status, migrationImport)
+	case migrationImport:
+		status := &properties.InstanceStatus{State: "succeeded"}
+		if _, err := plan.GetTemplate(osbv1alpha1.ImportAction); err == nil {
+			var refs []osbv1alpha1.Source
+			refs, status, err = r.applyResources(instance, plan, targetClient, migration.TargetClusterID, osbv1alpha1.ImportAction, nil)
+			if err != nil {
+				return true, result, err
+			}
+			migration.Resources = mergeSources(migration.Resources, refs)
+		}
+		result = advanceMigration(migration, status, migrationCleanup)
+		switchCluster = migration.Phase == migrationCleanup
+	case migrationCleanup:
+		// The resources on the source cluster are kept until the instance
+		// succeeds on the target cluster
+		status, err := r.computeStatus(instance, plan, targetClient, osbv1alpha1.ProvisionAction, migration.Resources)
+		if err != nil {
+			return true, result, err
+		}
+		if status.State != "succeeded" && instance.GetDeletionTimestamp().IsZero() {
+			log.Info("Waiting for the instance on the target cluster before cleanup", "instance", instanceID, "state", status.State, "error", status.Error)
+			return true, reconcile.Result{RequeueAfter: pendingInterval}, nil
+		}
+		// The resources of the export template on the source cluster are
+		// deleted along with the resources of the instance
+		remaining, _, err := r.resourceManager.DeleteSubResources(sourceClient, migration.SourceResources, plan.Spec.DeletionStrategies)
+		migration.SourceResources = remaining
+		if err == nil && len(remaining) == 0 {
+			migration.Phase = migrationSucceeded
+			log.Info("Migration succeeded", "instance", instanceID, "clusterID", migration.TargetClusterID)
+		} else {
+			result.RequeueAfter = pendingInterval
+		}
+		if setErr := r.setMigration(instance, migration, false, 0); setErr != nil {
+			return true, result, setErr
+		}
+		return true, result, err
+	case migrationRollback:
+		// Delete the resources created on the target cluster and the
+		// resources of the export template on the source cluster
+		strategies := plan.Spec.DeletionStrategies
+		remaining, _, err := r.resourceManager.DeleteSubResources(targetClient, migration.Resources, strategies)
+		migration.Resources = remaining
+		exports := subtractSources(migration.SourceResources, instance.Status.Resources)
+		remainingExports, _, exportErr := r.resourceManager.DeleteSubResources(sourceClient, exports, strategies)
+		migration.SourceResources = mergeSources(subtractSources(migration.SourceResources, exports), remainingExports)
+		if err == nil {
+			err = exportErr
+		}
+		if err == nil && len(remaining) == 0 && len(remainingExports) == 0 {
+			migration.Phase = migrationFailed
+			log.Info("Migration rolled back", "instance", instanceID, "reason", migration.Error)
+		} else {
+			result.RequeueAfter = pendingInterval
+		}
+		if setErr := r.setMigration(instance, migration, false, 0); setErr != nil {
+			return true, result, setErr
+		}
+		return true, result, err
+	}
+	return true, result, r.setMigration(instance, migration, switchCluster, 0)
+}
+
+// applyResources renders the template of the action and applies the
+// resources to the cluster. It returns the applied resources and their
+// status, computed as by computeStatus.
+func (r *ReconcileSFServiceInstance) applyResources(instance *osbv1alpha1.SFServiceInstance, plan *osbv1alpha1.SFPlan, targetClient client.Client, clusterID, action string, lastResources []osbv1alpha1.Source) ([]osbv1alpha1.Source, *properties.InstanceStatus, error) {
+	planID := instance.Spec.PlanID
+	namespace := instance.GetNamespace()
+	expectedResources, err := r.resourceManager.ComputeExpectedResources(r, instance.GetName(), "", instance.Spec.ServiceID, planID, action, namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = r.setOwner(instance, clusterID, expectedResources)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := r.watchManager.AddWatches(planID, clusterID, watches.ObjectKinds(expectedResources)...); err != nil {
+		log.Error(err, "failed to watch sub resources", "planID", planID, "clusterID", clusterID)
+	}
+
+	resourceRefs, err := r.resourceManager.ReconcileResources(r, targetClient, expectedResources, lastResources)
+	if resources.IsPending(err) {
+		return resourceRefs, &properties.InstanceStatus{State: "in progress"}, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	status, err := r.computeStatus(instance, plan, targetClient, action, resourceRefs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resourceRefs, status, nil
+}
+
+// computeStatus computes the status of the instance on the cluster of
+// targetClient after the resources of the action are applied. The status of
+// the provision and import actions is computed by the sources and status
+// templates of the plan, if it has them, as for a provision. Otherwise, and
+// for the export action, it is computed from the readiness of the
+// resources.
+func (r *ReconcileSFServiceInstance) computeStatus(instance *osbv1alpha1.SFServiceInstance, plan *osbv1alpha1.SFPlan, targetClient client.Client, action string, resourceRefs []osbv1alpha1.Source) (*properties.InstanceStatus, error) {
+	_, sourcesErr := plan.GetTemplate(osbv1alpha1.SourcesAction)
+	_, statusErr := plan.GetTemplate(osbv1alpha1.StatusAction)
+	if action != osbv1alpha1.ExportAction && sourcesErr == nil && statusErr == nil {
+		status, err := r.resourceManager.ComputeStatus(r, targetClient, instance.GetName(), "", instance.Spec.ServiceID, instance.Spec.PlanID, osbv1alpha1.ProvisionAction, instance.GetNamespace())
+		if err != nil {
+			return nil, err
+		}
+		return &status.Provision, nil
+	}
+	status, err := r.resourceManager.ComputeReadiness(targetClient, resourceRefs)
+	if err != nil {
+		return nil, err
+	}
+	return &status.Provision, nil
+}
+
+// hasBindings returns true if the instance has bindings
+func (r *ReconcileSFServiceInstance) hasBindings(instance *osbv1alpha1.SFServiceInstance) (bool, error) {
+	bindings := &osbv1alpha1.SFServiceBindingList{}
+	options := &client.ListOptions{Namespace: instance.GetNamespace()}
+	if err := r.List(context.TODO(), options, bindings); err != nil {
+		return false, err
+	}
+	for _, binding := range bindings.Items {
+		if binding.Spec.InstanceID == instance.GetName() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// advanceMigration moves the migration to the next phase once the resources
+// of the current phase succeed and rolls it back if they fail
+func advanceMigration(migration *osbv1alpha1.MigrationStatus, status *properties.InstanceStatus, next string) reconcile.Result {
+	switch status.State {
+	case "succeeded":
+		migration.Phase = next
+		return reconcile.Result{Requeue: true}
+	case "failed":
+		migration.Phase = migrationRollback
+		migration.Error = status.Error
+		return reconcile.Result{Requeue: true}
+	}
+	return reconcile.Result{RequeueAfter: pendingInterval}
+}
+
+// setMigration updates the migration status of the instance. If
+// switchCluster is set, the instance is switched to the target cluster along
+// with its resources. The migrateToKey annotation is removed once the
+// migration is completed.
+func (r *ReconcileSFServiceInstance) setMigration(instance *osbv1alpha1.SFServiceInstance, migration *osbv1alpha1.MigrationStatus, switchCluster bool, retryCount int) error {
+	instanceID := instance.GetName()
+	namespacedName := types.NamespacedName{
+		Name:      instanceID,
+		Namespace: instance.GetNamespace(),
+	}
+	err := r.Get(context.TODO(), namespacedName, instance)
+	if err != nil {
+		if retryCount < errorThreshold {
+			log.Info("Retrying", "function", "setMigration", "retryCount", retryCount+1, "instanceID", instanceID)
+			return r.setMigration(instance, migration, switchCluster, retryCount+1)
+		}
+		log.Error(err, "Updating migration status failed", "instance", instanceID)
+		return err
+	}
+	if reflect.DeepEqual(instance.Status.Migration, migration) && !switchCluster {
+		return nil
+	}
+	instance.Status.Migration = migration
+	if switchCluster {
+		instance.Spec.ClusterID = migration.TargetClusterID
+		instance.Status.Resources = migration.Resources
+	}
+	if !isMigrating(migration) || switchCluster {
+		annotations := instance.GetAnnotations()
+		delete(annotations, migrateToKey)
+		instance.SetAnnotations(annotations)
+	}
+	err = r.updateWithStatus(instance)
+	if err != nil {
+		if retryCount < errorThreshold {
+			log.Info("Retrying", "function", "setMigration", "retryCount", retryCount+1, "instanceID", instanceID)
+			return r.setMigration(instance, migration, switchCluster, retryCount+1)
+		}
+		log.Error(err, "Updating migration status failed", "instance", instanceID)
+		return err
+	}
+	log.Info("Updated migration status", "instance", instanceID, "phase", migration.Phase)
+	return nil
+}
+
+// mergeSources returns the sources of a followed by the sources of b not in a
+func mergeSources(a, b []osbv1alpha1.Source) []osbv1alpha1.Source {
+	var result []osbv1alpha1.Source
+	result = append(result, a...)
+	return append(result, subtractSources(b, a)...)
+}
+
+// subtractSources returns the sources of a not in b
+func subtractSources(a, b []osbv1alpha1.Source) []osbv1alpha1.Source {
+	var result []osbv1alpha1.Source
+	for _, source := range a {
+		found := false
+		for _, other := range b {
+			if source == other {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, source)
+		}
+	}
+	return result
+}
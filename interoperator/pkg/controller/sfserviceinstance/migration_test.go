@@ -0,0 +1,304 @@
+/*
+Copyright 2018 The Service Fabrik Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sfserviceinstance
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis"
+	osbv1alpha1 "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis/osb/v1alpha1"
+	mock_clusterFactory "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/cluster/factory/mock_factory"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/properties"
+
+	"github.com/golang/mock/gomock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_startMigration(t *testing.T) {
+	resources := []osbv1alpha1.Source{
+		osbv1alpha1.Source{APIVersion: "v1", Kind: "ConfigMap", Name: "instance-id", Namespace: "default"},
+	}
+	newInstance := func(annotations map[string]string, state string, migration *osbv1alpha1.MigrationStatus) *osbv1alpha1.SFServiceInstance {
+		return &osbv1alpha1.SFServiceInstance{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "instance-id",
+				Namespace:   "default",
+				Annotations: annotations,
+			},
+			Spec: osbv1alpha1.SFServiceInstanceSpec{
+				ClusterID: "cluster-1",
+			},
+			Status: osbv1alpha1.SFServiceInstanceStatus{
+				State:     state,
+				Resources: resources,
+				Migration: migration,
+			},
+		}
+	}
+	tests := []struct {
+		name     string
+		instance *osbv1alpha1.SFServiceInstance
+		want     *osbv1alpha1.MigrationStatus
+	}{
+		{
+			name:     "start migration to the requested cluster",
+			instance: newInstance(map[string]string{migrateToKey: "cluster-2"}, "succeeded", nil),
+			want: &osbv1alpha1.MigrationStatus{
+				SourceClusterID: "cluster-1",
+				TargetClusterID: "cluster-2",
+				Phase:           migrationExport,
+				SourceResources: resources,
+			},
+		},
+		{
+			name:     "start migration to the local cluster",
+			instance: newInstance(map[string]string{migrateToKey: ""}, "succeeded", nil),
+			want: &osbv1alpha1.MigrationStatus{
+				SourceClusterID: "cluster-1",
+				Phase:           migrationExport,
+				SourceResources: resources,
+			},
+		},
+		{
+			name: "start migration after a failed migration",
+			instance: newInstance(map[string]string{migrateToKey: "cluster-2"}, "succeeded", &osbv1alpha1.MigrationStatus{
+				Phase: migrationFailed,
+			}),
+			want: &osbv1alpha1.MigrationStatus{
+				SourceClusterID: "cluster-1",
+				TargetClusterID: "cluster-2",
+				Phase:           migrationExport,
+				SourceResources: resources,
+			},
+		},
+		{
+			name:     "no migration without annotation",
+			instance: newInstance(nil, "succeeded", nil),
+			want:     nil,
+		},
+		{
+			name:     "no migration to the current cluster",
+			instance: newInstance(map[string]string{migrateToKey: "cluster-1"}, "succeeded", nil),
+			want:     nil,
+		},
+		{
+			name:     "no migration of an instance in progress",
+			instance: newInstance(map[string]string{migrateToKey: "cluster-2"}, "in progress", nil),
+			want:     nil,
+		},
+		{
+			name: "no migration while migrating",
+			instance: newInstance(map[string]string{migrateToKey: "cluster-2"}, "succeeded", &osbv1alpha1.MigrationStatus{
+				Phase: migrationProvision,
+			}),
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := startMigration(tt.instance); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("startMigration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_migrate_bindings(t *testing.T) {
+	if err := apis.AddToScheme(scheme.Scheme); err != nil {
+		t.Fatalf("AddToScheme() error = %v", err)
+	}
+	newInstance := func() *osbv1alpha1.SFServiceInstance {
+		return &osbv1alpha1.SFServiceInstance{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "instance-id",
+				Namespace:   "default",
+				Annotations: map[string]string{migrateToKey: "cluster-2"},
+			},
+			Spec: osbv1alpha1.SFServiceInstanceSpec{ClusterID: "cluster-1"},
+			Status: osbv1alpha1.SFServiceInstanceStatus{
+				State: "succeeded",
+			},
+		}
+	}
+	binding := &osbv1alpha1.SFServiceBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "binding-id", Namespace: "default"},
+		Spec:       osbv1alpha1.SFServiceBindingSpec{ID: "binding-id", InstanceID: "instance-id"},
+	}
+	otherBinding := binding.DeepCopy()
+	otherBinding.SetName("other-binding-id")
+	otherBinding.Spec.InstanceID = "other-instance-id"
+
+	tests := []struct {
+		name       string
+		bindings   []*osbv1alpha1.SFServiceBinding
+		wantPhase  string
+		annotation bool
+	}{
+		{
+			name:       "start migration of an instance without bindings",
+			bindings:   []*osbv1alpha1.SFServiceBinding{otherBinding},
+			wantPhase:  migrationExport,
+			annotation: true,
+		},
+		{
+			name:      "reject migration of an instance with bindings",
+			bindings:  []*osbv1alpha1.SFServiceBinding{binding, otherBinding},
+			wantPhase: migrationFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewFakeClient(newInstance())
+			for _, b := range tt.bindings {
+				if err := client.Create(context.TODO(), b.DeepCopy()); err != nil {
+					t.Fatalf("Create() error = %v", err)
+				}
+			}
+			r := &ReconcileSFServiceInstance{Client: client}
+			migrating, _, err := r.migrate(newInstance())
+			if !migrating || err != nil {
+				t.Fatalf("migrate() = %v, %v, want true, nil", migrating, err)
+			}
+			instance := &osbv1alpha1.SFServiceInstance{}
+			if err := client.Get(context.TODO(), types.NamespacedName{Name: "instance-id", Namespace: "default"}, instance); err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if instance.Status.Migration == nil || instance.Status.Migration.Phase != tt.wantPhase {
+				t.Errorf("migrate() migration = %v, want phase %v", instance.Status.Migration, tt.wantPhase)
+			}
+			if _, ok := instance.GetAnnotations()[migrateToKey]; ok != tt.annotation {
+				t.Errorf("migrate() annotation kept = %v, want %v", ok, tt.annotation)
+			}
+		})
+	}
+}
+
+func Test_migrate_plan(t *testing.T) {
+	if err := apis.AddToScheme(scheme.Scheme); err != nil {
+		t.Fatalf("AddToScheme() error = %v", err)
+	}
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// The plan is looked up in the namespace of the services, not in the
+	// namespace of the instance
+	labels := map[string]string{"serviceId": "service-id", "planId": "plan-id"}
+	service := &osbv1alpha1.SFService{
+		ObjectMeta: metav1.ObjectMeta{Name: "service-id", Namespace: "default", Labels: labels},
+		Spec:       osbv1alpha1.SFServiceSpec{ID: "service-id"},
+	}
+	plan := &osbv1alpha1.SFPlan{
+		ObjectMeta: metav1.ObjectMeta{Name: "plan-id", Namespace: "default", Labels: labels},
+		Spec:       osbv1alpha1.SFPlanSpec{ID: "plan-id", ServiceID: "service-id"},
+	}
+	instance := &osbv1alpha1.SFServiceInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "instance-id", Namespace: "sf-instance-id"},
+		Spec:       osbv1alpha1.SFServiceInstanceSpec{ServiceID: "service-id", PlanID: "plan-id", ClusterID: "cluster-1"},
+		Status: osbv1alpha1.SFServiceInstanceStatus{
+			State: "succeeded",
+			Migration: &osbv1alpha1.MigrationStatus{
+				Phase:           migrationExport,
+				SourceClusterID: "cluster-1",
+				TargetClusterID: "cluster-2",
+			},
+		},
+	}
+	client := fake.NewFakeClient(service, plan, instance.DeepCopy())
+	factory := mock_clusterFactory.NewMockClusterFactory(ctrl)
+	factory.EXPECT().GetClusterClient(gomock.Any()).Return(client, nil).AnyTimes()
+
+	r := &ReconcileSFServiceInstance{Client: client, clusterFactory: factory}
+	migrating, _, err := r.migrate(instance)
+	if !migrating || err != nil {
+		t.Fatalf("migrate() = %v, %v, want true, nil", migrating, err)
+	}
+	got := &osbv1alpha1.SFServiceInstance{}
+	if err := client.Get(context.TODO(), types.NamespacedName{Name: "instance-id", Namespace: "sf-instance-id"}, got); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	// The plan has no export template
+	if got.Status.Migration == nil || got.Status.Migration.Phase != migrationProvision {
+		t.Errorf("migrate() migration = %v, want phase %v", got.Status.Migration, migrationProvision)
+	}
+}
+
+func Test_advanceMigration(t *testing.T) {
+	tests := []struct {
+		name      string
+		state     string
+		wantPhase string
+		wantError string
+	}{
+		{
+			name:      "advance once succeeded",
+			state:     "succeeded",
+			wantPhase: migrationImport,
+		},
+		{
+			name:      "roll back once failed",
+			state:     "failed",
+			wantPhase: migrationRollback,
+			wantError: "reason",
+		},
+		{
+			name:      "wait while in progress",
+			state:     "in progress",
+			wantPhase: migrationProvision,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migration := &osbv1alpha1.MigrationStatus{Phase: migrationProvision}
+			status := &properties.InstanceStatus{State: tt.state}
+			if tt.state == "failed" {
+				status.Error = "reason"
+			}
+			result := advanceMigration(migration, status, migrationImport)
+			if migration.Phase != tt.wantPhase || migration.Error != tt.wantError {
+				t.Errorf("advanceMigration() phase = %v, error = %v, want %v, %v", migration.Phase, migration.Error, tt.wantPhase, tt.wantError)
+			}
+			if (result.RequeueAfter == pendingInterval) != (tt.wantPhase == migrationProvision) {
+				t.Errorf("advanceMigration() result = %v", result)
+			}
+		})
+	}
+}
+
+func Test_mergeSources(t *testing.T) {
+	a := osbv1alpha1.Source{APIVersion: "v1", Kind: "ConfigMap", Name: "a", Namespace: "default"}
+	b := osbv1alpha1.Source{APIVersion: "v1", Kind: "ConfigMap", Name: "b", Namespace: "default"}
+	c := osbv1alpha1.Source{APIVersion: "v1", Kind: "Secret", Name: "a", Namespace: "default"}
+
+	got := mergeSources([]osbv1alpha1.Source{a, b}, []osbv1alpha1.Source{b, c})
+	want := []osbv1alpha1.Source{a, b, c}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeSources() = %v, want %v", got, want)
+	}
+	got = subtractSources([]osbv1alpha1.Source{a, b, c}, []osbv1alpha1.Source{b})
+	want = []osbv1alpha1.Source{a, c}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("subtractSources() = %v, want %v", got, want)
+	}
+	if got := mergeSources(nil, nil); got != nil {
+		t.Errorf("mergeSources() = %v, want nil", got)
+	}
+}
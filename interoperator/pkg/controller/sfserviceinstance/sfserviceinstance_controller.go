@@ -21,16 +21,21 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"time"
 
 	osbv1alpha1 "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis/osb/v1alpha1"
 	clusterFactory "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/cluster/factory"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/renderer"
 	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/resources"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/services"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/watches"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/scheduler"
 
-	appsv1 "k8s.io/api/apps/v1"
-	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -46,8 +51,19 @@ const (
 	finalizerName    = "interoperator.servicefabrik.io"
 	errorCountKey    = "interoperator.servicefabrik.io/error"
 	lastOperationKey = "interoperator.servicefabrik.io/lastoperation"
-	errorThreshold   = 10
-	workerCount      = 10
+	dryRunKey        = "interoperator.servicefabrik.io/dryrun"
+	// migrateToKey is the annotation requesting the migration of the
+	// instance to the SFCluster named by its value, or to the local cluster
+	// if its value is empty
+	migrateToKey   = "interoperator.servicefabrik.io/migrateto"
+	errorThreshold = 10
+	workerCount    = 10
+	// pendingInterval is the interval at which the readiness of a wave of
+	// resources is checked
+	pendingInterval = 10 * time.Second
+	// unavailableInterval is the interval at which a reconcile paused on an
+	// unreachable cluster is retried
+	unavailableInterval = 30 * time.Second
 )
 
 var log = logf.Log.WithName("instance.controller")
@@ -56,21 +72,31 @@ var log = logf.Log.WithName("instance.controller")
 // and Start it when the Manager is Started.
 func Add(mgr manager.Manager) error {
 	clusterFactory, _ := clusterFactory.New(mgr)
-	return add(mgr, newReconciler(mgr, resources.New(), clusterFactory))
+	watchManager, err := watches.New(mgr)
+	if err != nil {
+		return err
+	}
+	instanceScheduler, err := scheduler.Default()
+	if err != nil {
+		return err
+	}
+	return add(mgr, newReconciler(mgr, resources.NewWithMapper(mgr.GetRESTMapper()), clusterFactory, watchManager, instanceScheduler), watchManager)
 }
 
 // newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager, resourceManager resources.ResourceManager, clusterFactory clusterFactory.ClusterFactory) reconcile.Reconciler {
+func newReconciler(mgr manager.Manager, resourceManager resources.ResourceManager, clusterFactory clusterFactory.ClusterFactory, watchManager watches.Manager, instanceScheduler scheduler.Scheduler) reconcile.Reconciler {
 	return &ReconcileSFServiceInstance{
 		Client:          mgr.GetClient(),
 		scheme:          mgr.GetScheme(),
 		clusterFactory:  clusterFactory,
 		resourceManager: resourceManager,
+		watchManager:    watchManager,
+		scheduler:       instanceScheduler,
 	}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
-func add(mgr manager.Manager, r reconcile.Reconciler) error {
+func add(mgr manager.Manager, r reconcile.Reconciler, watchManager watches.Manager) error {
 	// Create a new controller
 	c, err := controller.New("sfserviceinstance-controller", mgr, controller.Options{Reconciler: r, MaxConcurrentReconciles: workerCount})
 	if err != nil {
@@ -83,44 +109,10 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
-	// TODO dynamically setup rbac rules and watches
-	postgres := &unstructured.Unstructured{}
-	postgres.SetKind("Postgres")
-	postgres.SetAPIVersion("kubedb.com/v1alpha1")
-	postgres2 := &unstructured.Unstructured{}
-	postgres2.SetKind("Postgresql")
-	postgres2.SetAPIVersion("kubernetes.sapcloud.io/v1alpha1")
-	director := &unstructured.Unstructured{}
-	director.SetKind("Director")
-	director.SetAPIVersion("deployment.servicefabrik.io/v1alpha1")
-	docker := &unstructured.Unstructured{}
-	docker.SetKind("Docker")
-	docker.SetAPIVersion("deployment.servicefabrik.io/v1alpha1")
-	postgresqlmts := &unstructured.Unstructured{}
-	postgresqlmts.SetKind("PostgresqlMT")
-	postgresqlmts.SetAPIVersion("deployment.servicefabrik.io/v1alpha1")
-	vhostmts := &unstructured.Unstructured{}
-	vhostmts.SetKind("VirtualHost")
-	vhostmts.SetAPIVersion("deployment.servicefabrik.io/v1alpha1")
-	subresources := []runtime.Object{
-		&appsv1.Deployment{},
-		&corev1.ConfigMap{},
-		postgres,
-		postgres2,
-		director,
-		docker,
-		postgresqlmts,
-		vhostmts,
-	}
-
-	for _, subresource := range subresources {
-		err = c.Watch(&source.Kind{Type: subresource}, &handler.EnqueueRequestForOwner{
-			IsController: true,
-			OwnerType:    &osbv1alpha1.SFServiceInstance{},
-		})
-		if err != nil {
-			log.Error(err, "failed to start watch")
-		}
+	// Watch the sub resources of the kinds used by the plans
+	err = watchManager.Start(c, watches.NewEnqueueRequestForOwner(&osbv1alpha1.SFServiceInstance{}, "SFServiceInstance"))
+	if err != nil {
+		return err
 	}
 
 	return nil
@@ -134,6 +126,8 @@ type ReconcileSFServiceInstance struct {
 	scheme          *runtime.Scheme
 	clusterFactory  clusterFactory.ClusterFactory
 	resourceManager resources.ResourceManager
+	watchManager    watches.Manager
+	scheduler       scheduler.Scheduler
 }
 
 // Reconcile reads that state of the cluster for a SFServiceInstance object and makes changes based on the state read
@@ -148,7 +142,9 @@ type ReconcileSFServiceInstance struct {
 // +kubebuilder:rbac:groups=,resources=configmap,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=interoperator.servicefabrik.io,resources=sfserviceinstances,verbs=get;list;watch;create;update;patch;delete
-// TODO dynamically setup rbac rules and watches
+// +kubebuilder:rbac:groups=osb.servicefabrik.io,resources=sfclusters,verbs=get;list;watch;update
+// +kubebuilder:rbac:groups=osb.servicefabrik.io,resources=sfserviceinstances/status;sfservicebindings/status,verbs=get;update;patch
+// TODO dynamically setup rbac rules
 func (r *ReconcileSFServiceInstance) Reconcile(request reconcile.Request) (reconcile.Result, error) {
 	// Fetch the ServiceInstance instance
 	instance := &osbv1alpha1.SFServiceInstance{}
@@ -179,7 +175,25 @@ func (r *ReconcileSFServiceInstance) Reconcile(request reconcile.Request) (recon
 		return r.handleError(instance, reconcile.Result{Requeue: true}, nil, "", 0)
 	}
 
-	targetClient, err := r.clusterFactory.GetCluster(instanceID, bindingID, serviceID, planID)
+	// Resume the watches on the sub resources after a restart
+	r.addWatches(instance, watches.SourceKinds(instance.Status.Resources))
+
+	if migrating, result, err := r.migrate(instance); migrating {
+		return r.handleError(instance, result, err, "", 0)
+	}
+
+	if state == "in_queue" && instance.Spec.ClusterID == "" && len(instance.Status.Resources) == 0 && instance.GetDeletionTimestamp().IsZero() {
+		scheduled, err := r.schedule(instance, 0)
+		if err != nil {
+			return r.handleError(instance, reconcile.Result{}, err, "", 0)
+		}
+		if scheduled {
+			// The update of the instance triggers its provisioning
+			return r.handleError(instance, reconcile.Result{}, nil, "", 0)
+		}
+	}
+
+	targetClient, err := r.clusterFactory.GetCluster(instanceID, bindingID, serviceID, planID, instance.GetNamespace())
 	if err != nil {
 		return r.handleError(instance, reconcile.Result{}, err, "", 0)
 	}
@@ -187,33 +201,59 @@ func (r *ReconcileSFServiceInstance) Reconcile(request reconcile.Request) (recon
 	if state == "delete" && !instance.GetDeletionTimestamp().IsZero() {
 		// The object is being deleted
 		// so lets handle our external dependency
-		remainingResource, err := r.resourceManager.DeleteSubResources(targetClient, instance.Status.Resources)
+		strategies, err := r.getDeletionStrategies(instance)
+		if err != nil {
+			return r.handleError(instance, reconcile.Result{}, err, state, 0)
+		}
+		remainingResource, deletions, err := r.resourceManager.DeleteSubResources(targetClient, instance.Status.Resources, strategies)
 		if err != nil {
 			log.Error(err, "Delete sub resources failed")
 			return r.handleError(instance, reconcile.Result{}, err, state, 0)
 		}
-		err = r.setInProgress(request.NamespacedName, state, remainingResource, 0)
+		err = r.setInProgress(request.NamespacedName, state, remainingResource, deletions, 0)
 		if err != nil {
 			return r.handleError(instance, reconcile.Result{}, err, state, 0)
 		}
 		lastOperation = state
+	} else if state == "update" && instance.GetAnnotations()[dryRunKey] == "true" {
+		// Record the changes of the update for review, the update is
+		// applied once the annotation is removed
+		err = r.dryRun(targetClient, instance, 0)
+		if err != nil {
+			return r.handleError(instance, reconcile.Result{}, err, "", 0)
+		}
+		return r.handleError(instance, reconcile.Result{}, nil, "", 0)
 	} else if state == "in_queue" || state == "update" {
 		expectedResources, err := r.resourceManager.ComputeExpectedResources(r, instanceID, bindingID, serviceID, planID, osbv1alpha1.ProvisionAction, instance.GetNamespace())
 		if err != nil {
 			return r.handleError(instance, reconcile.Result{}, err, state, 0)
 		}
 
-		err = r.resourceManager.SetOwnerReference(instance, expectedResources, r.scheme)
+		err = r.setOwner(instance, instance.Spec.ClusterID, expectedResources)
 		if err != nil {
 			return r.handleError(instance, reconcile.Result{}, err, state, 0)
 		}
+		r.addWatches(instance, watches.ObjectKinds(expectedResources))
 
 		resourceRefs, err := r.resourceManager.ReconcileResources(r, targetClient, expectedResources, instance.Status.Resources)
+		if resources.IsPending(err) {
+			log.Info("Waiting for resources to be ready", "objectID", request.Name, "reason", err.Error())
+			pendingErr := err.(*resources.PendingError)
+			since, err := r.setResources(request.NamespacedName, resourceRefs, pendingErr.Wave(), pendingErr.Error(), 0)
+			if err != nil {
+				return r.handleError(instance, reconcile.Result{}, err, state, 0)
+			}
+			if err := resources.CheckWaveTimeout(pendingErr, since); err != nil {
+				log.Error(err, "Resources not ready in time", "objectID", request.Name)
+				return r.handleError(instance, reconcile.Result{}, err, state, 0)
+			}
+			return r.handleError(instance, reconcile.Result{RequeueAfter: pendingInterval}, nil, "", 0)
+		}
 		if err != nil {
 			log.Error(err, "ReconcileResources failed")
 			return r.handleError(instance, reconcile.Result{}, err, state, 0)
 		}
-		err = r.setInProgress(request.NamespacedName, state, resourceRefs, 0)
+		err = r.setInProgress(request.NamespacedName, state, resourceRefs, nil, 0)
 		if err != nil {
 			return r.handleError(instance, reconcile.Result{}, err, state, 0)
 		}
@@ -282,14 +322,14 @@ func (r *ReconcileSFServiceInstance) reconcileFinalizers(object *osbv1alpha1.SFS
 	return nil
 }
 
-func (r *ReconcileSFServiceInstance) setInProgress(namespacedName types.NamespacedName, state string, resources []osbv1alpha1.Source, retryCount int) error {
+func (r *ReconcileSFServiceInstance) setInProgress(namespacedName types.NamespacedName, state string, resources []osbv1alpha1.Source, deletions []osbv1alpha1.DeletionStatus, retryCount int) error {
 	if state == "in_queue" || state == "update" || state == "delete" {
 		instance := &osbv1alpha1.SFServiceInstance{}
 		err := r.Get(context.TODO(), namespacedName, instance)
 		if err != nil {
 			if retryCount < errorThreshold {
 				log.Info("Retrying", "function", "setInProgress", "retryCount", retryCount+1, "objectID", namespacedName.Name)
-				return r.setInProgress(namespacedName, state, resources, retryCount+1)
+				return r.setInProgress(namespacedName, state, resources, deletions, retryCount+1)
 			}
 			log.Error(err, "Updating status to in progress failed")
 			return err
@@ -302,11 +342,21 @@ func (r *ReconcileSFServiceInstance) setInProgress(namespacedName types.Namespac
 		labels[lastOperationKey] = state
 		instance.SetLabels(labels)
 		instance.Status.Resources = resources
-		err = r.Update(context.Background(), instance)
+		instance.Status.Deletions = deletions
+		instance.Status.PendingWave = nil
+		if state != "delete" {
+			osbv1alpha1.SetCondition(&instance.Status.Conditions, osbv1alpha1.Condition{
+				Type:   osbv1alpha1.ConditionResourcesApplied,
+				Status: osbv1alpha1.ConditionTrue,
+				Reason: "ResourcesApplied",
+			})
+		}
+		instance.Status.DryRun = nil
+		err = r.updateWithStatus(instance)
 		if err != nil {
 			if retryCount < errorThreshold {
 				log.Info("Retrying", "function", "setInProgress", "retryCount", retryCount+1, "objectID", namespacedName.Name)
-				return r.setInProgress(namespacedName, state, resources, retryCount+1)
+				return r.setInProgress(namespacedName, state, resources, deletions, retryCount+1)
 			}
 			log.Error(err, "Updating status to in progress failed")
 			return err
@@ -316,6 +366,102 @@ func (r *ReconcileSFServiceInstance) setInProgress(namespacedName types.Namespac
 	return nil
 }
 
+// setResources updates the resources of the instance without changing its
+// state, while the resources of the wave <wave> are not ready. <message> is
+// the reason they are not ready. It returns the time since when the instance
+// is waiting for the wave.
+func (r *ReconcileSFServiceInstance) setResources(namespacedName types.NamespacedName, resources []osbv1alpha1.Source, wave int, message string, retryCount int) (time.Time, error) {
+	instance := &osbv1alpha1.SFServiceInstance{}
+	err := r.Get(context.TODO(), namespacedName, instance)
+	if err != nil {
+		if retryCount < errorThreshold {
+			log.Info("Retrying", "function", "setResources", "retryCount", retryCount+1, "objectID", namespacedName.Name)
+			return r.setResources(namespacedName, resources, wave, message, retryCount+1)
+		}
+		log.Error(err, "Updating resources failed")
+		return time.Time{}, err
+	}
+	instance.Status.Resources = resources
+	pendingWave := instance.Status.PendingWave
+	if pendingWave == nil || pendingWave.Wave != wave {
+		pendingWave = &osbv1alpha1.WaveStatus{
+			Wave:  wave,
+			Since: metav1.Now(),
+		}
+	}
+	instance.Status.PendingWave = pendingWave
+	osbv1alpha1.SetCondition(&instance.Status.Conditions, osbv1alpha1.Condition{
+		Type:    osbv1alpha1.ConditionResourcesApplied,
+		Status:  osbv1alpha1.ConditionFalse,
+		Reason:  "WaitingForResources",
+		Message: message,
+	})
+	err = r.Status().Update(context.Background(), instance)
+	if err != nil {
+		if retryCount < errorThreshold {
+			log.Info("Retrying", "function", "setResources", "retryCount", retryCount+1, "objectID", namespacedName.Name)
+			return r.setResources(namespacedName, resources, wave, message, retryCount+1)
+		}
+		log.Error(err, "Updating resources failed")
+		return time.Time{}, err
+	}
+	return pendingWave.Since.Time, nil
+}
+
+// dryRun records in the status of the instance the changes the update of
+// the instance would make to its resources
+func (r *ReconcileSFServiceInstance) dryRun(targetClient client.Client, instance *osbv1alpha1.SFServiceInstance, retryCount int) error {
+	instanceID := instance.GetName()
+	dryRunStatus := &osbv1alpha1.DryRunStatus{
+		Spec: *instance.Spec.DeepCopy(),
+	}
+	expectedResources, err := r.resourceManager.ComputeExpectedResources(r, instanceID, "", instance.Spec.ServiceID, instance.Spec.PlanID, osbv1alpha1.ProvisionAction, instance.GetNamespace())
+	if err == nil {
+		err = r.setOwner(instance, instance.Spec.ClusterID, expectedResources)
+	}
+	if err == nil {
+		dryRunStatus.Changes, err = r.resourceManager.DryRunResources(targetClient, expectedResources, instance.Status.Resources)
+	}
+	if err != nil {
+		log.Error(err, "Dry run failed", "instance", instanceID)
+		dryRunStatus.Error = err.Error()
+	}
+	if len(dryRunStatus.Changes) == 0 {
+		// Compare with the status as read back from the api server
+		dryRunStatus.Changes = nil
+	}
+
+	// Fetch object again before updating status
+	namespacedName := types.NamespacedName{
+		Name:      instanceID,
+		Namespace: instance.GetNamespace(),
+	}
+	err = r.Get(context.TODO(), namespacedName, instance)
+	if err != nil {
+		if retryCount < errorThreshold {
+			log.Info("Retrying", "function", "dryRun", "retryCount", retryCount+1, "instanceID", instanceID)
+			return r.dryRun(targetClient, instance, retryCount+1)
+		}
+		log.Error(err, "Updating dry run status failed", "instance", instanceID)
+		return err
+	}
+	if reflect.DeepEqual(instance.Status.DryRun, dryRunStatus) {
+		return nil
+	}
+	instance.Status.DryRun = dryRunStatus
+	err = r.Status().Update(context.Background(), instance)
+	if err != nil {
+		if retryCount < errorThreshold {
+			log.Info("Retrying", "function", "dryRun", "retryCount", retryCount+1, "instanceID", instanceID)
+			return r.dryRun(targetClient, instance, retryCount+1)
+		}
+		log.Error(err, "Updating dry run status failed", "instance", instanceID)
+		return err
+	}
+	log.Info("Updated dry run status", "instance", instanceID, "changes", len(dryRunStatus.Changes))
+	return nil
+}
+
 func (r *ReconcileSFServiceInstance) updateDeprovisionStatus(targetClient client.Client, instance *osbv1alpha1.SFServiceInstance, retryCount int) error {
 	serviceID := instance.Spec.ServiceID
 	planID := instance.Spec.PlanID
@@ -327,6 +473,7 @@ func (r *ReconcileSFServiceInstance) updateDeprovisionStatus(targetClient client
 		log.Error(err, "ComputeStatus failed for deprovision")
 		return err
 	}
+	r.addWatches(instance, watches.SourceKinds(computedStatus.Sources))
 
 	// Fetch object again before updating status
 	namespacedName := types.NamespacedName{
@@ -340,6 +487,7 @@ func (r *ReconcileSFServiceInstance) updateDeprovisionStatus(targetClient client
 	}
 
 	updateRequired := false
+	removeFinalizer := false
 	updatedStatus := instance.Status.DeepCopy()
 	updatedStatus.State = computedStatus.Deprovision.State
 	updatedStatus.Error = computedStatus.Deprovision.Error
@@ -362,6 +510,13 @@ func (r *ReconcileSFServiceInstance) updateDeprovisionStatus(targetClient client
 		}
 	}
 	updatedStatus.Resources = remainingResource
+	var deletions []osbv1alpha1.DeletionStatus
+	for _, deletion := range updatedStatus.Deletions {
+		if containsSource(remainingResource, deletion.Source) {
+			deletions = append(deletions, deletion)
+		}
+	}
+	updatedStatus.Deletions = deletions
 	if !reflect.DeepEqual(&instance.Status, updatedStatus) {
 		updatedStatus.DeepCopyInto(&instance.Status)
 		updateRequired = true
@@ -373,11 +528,17 @@ func (r *ReconcileSFServiceInstance) updateDeprovisionStatus(targetClient client
 		instance.SetFinalizers(removeString(instance.GetFinalizers(), finalizerName))
 		instance.SetState("succeeded")
 		updateRequired = true
+		removeFinalizer = true
 	}
 
 	if updateRequired {
 		log.Info("Updating deprovision status from template", "instance", namespacedName)
-		if err := r.Update(context.Background(), instance); err != nil {
+		if removeFinalizer {
+			err = r.updateWithStatus(instance)
+		} else {
+			err = r.Status().Update(context.Background(), instance)
+		}
+		if err != nil {
 			if retryCount < errorThreshold {
 				log.Info("Retrying", "function", "updateDeprovisionStatus", "retryCount", retryCount+1, "instanceID", instanceID)
 				return r.updateDeprovisionStatus(targetClient, instance, retryCount+1)
@@ -389,6 +550,90 @@ func (r *ReconcileSFServiceInstance) updateDeprovisionStatus(targetClient client
 	return nil
 }
 
+// addWatches starts the watches on the kinds of the sub resources used by the
+// plan, in the cluster the instance is deployed to. The instance is
+// reconciled anyway, a kind which can not be watched is only logged.
+func (r *ReconcileSFServiceInstance) addWatches(instance *osbv1alpha1.SFServiceInstance, gvks []schema.GroupVersionKind) {
+	planID := instance.Spec.PlanID
+	if err := r.watchManager.AddWatches(planID, instance.Spec.ClusterID, gvks...); err != nil {
+		log.Error(err, "failed to watch sub resources", "planID", planID, "clusterID", instance.Spec.ClusterID)
+	}
+}
+
+// schedule places a new instance on one of the clusters and records the
+// cluster in its spec. Without clusters, the instance is deployed to the
+// local cluster and is not updated. The instances of all the namespaces are
+// counted on the clusters.
+func (r *ReconcileSFServiceInstance) schedule(instance *osbv1alpha1.SFServiceInstance, retryCount int) (bool, error) {
+	clusters := &osbv1alpha1.SFClusterList{}
+	options := &client.ListOptions{Namespace: clusterFactory.Namespace()}
+	if err := r.List(context.TODO(), options, clusters); err != nil {
+		return false, err
+	}
+	if len(clusters.Items) == 0 {
+		return false, nil
+	}
+	instances := &osbv1alpha1.SFServiceInstanceList{}
+	if err := r.List(context.TODO(), &client.ListOptions{}, instances); err != nil {
+		return false, err
+	}
+	_, plan, err := services.FindServiceInfo(r, instance.Spec.ServiceID, instance.Spec.PlanID, services.Namespace)
+	if err != nil {
+		return false, err
+	}
+
+	clusterID, err := r.scheduler.Schedule(&scheduler.Request{
+		Instance:  instance,
+		Plan:      plan,
+		Clusters:  clusters.Items,
+		Instances: instances.Items,
+	})
+	if err != nil {
+		log.Error(err, "Scheduling instance failed", "objectID", instance.GetName())
+		return false, err
+	}
+	instance.Spec.ClusterID = clusterID
+	err = r.Update(context.TODO(), instance)
+	if err != nil {
+		if retryCount < errorThreshold && errors.IsConflict(err) {
+			log.Info("Retrying", "function", "schedule", "retryCount", retryCount+1, "objectID", instance.GetName())
+			err = r.Get(context.TODO(), types.NamespacedName{Name: instance.GetName(), Namespace: instance.GetNamespace()}, instance)
+			if err != nil {
+				return false, err
+			}
+			if instance.Spec.ClusterID != "" {
+				// Scheduled by a previous reconcile
+				return true, nil
+			}
+			return r.schedule(instance, retryCount+1)
+		}
+		return false, err
+	}
+	log.Info("Instance scheduled", "objectID", instance.GetName(), "clusterID", clusterID)
+	return true, nil
+}
+
+// setOwner sets the instance as the owner of its resources deployed to the
+// cluster. The resources deployed to a data plane cluster are labeled with
+// the instance instead.
+func (r *ReconcileSFServiceInstance) setOwner(instance *osbv1alpha1.SFServiceInstance, clusterID string, expectedResources []*unstructured.Unstructured) error {
+	if clusterID != "" {
+		return resources.SetOwnerLabels(instance, expectedResources, r.scheme)
+	}
+	return r.resourceManager.SetOwnerReference(instance, expectedResources, r.scheme)
+}
+
+// getDeletionStrategies returns the deletion strategies of the plan of the
+// instance. A plan not found is an error like any other, the deletion is retried
+// rather than deleting the resources the plan may keep.
+func (r *ReconcileSFServiceInstance) getDeletionStrategies(instance *osbv1alpha1.SFServiceInstance) ([]osbv1alpha1.DeletionStrategy, error) {
+	_, plan, err := services.FindServiceInfo(r, instance.Spec.ServiceID, instance.Spec.PlanID, services.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	return plan.Spec.DeletionStrategies, nil
+}
+
 func (r *ReconcileSFServiceInstance) updateStatus(targetClient client.Client, instance *osbv1alpha1.SFServiceInstance, retryCount int) error {
 	serviceID := instance.Spec.ServiceID
 	planID := instance.Spec.PlanID
@@ -400,6 +645,7 @@ func (r *ReconcileSFServiceInstance) updateStatus(targetClient client.Client, in
 		log.Error(err, "Compute status failed", "instance", instanceID)
 		return err
 	}
+	r.addWatches(instance, watches.SourceKinds(computedStatus.Sources))
 
 	// Fetch object again before updating status
 	namespacedName := types.NamespacedName{
@@ -420,7 +666,7 @@ func (r *ReconcileSFServiceInstance) updateStatus(targetClient client.Client, in
 	if !reflect.DeepEqual(&instance.Status, updatedStatus) {
 		updatedStatus.DeepCopyInto(&instance.Status)
 		log.Info("Updating provision status from template", "instance", namespacedName)
-		err = r.Update(context.Background(), instance)
+		err = r.Status().Update(context.Background(), instance)
 		if err != nil {
 			if retryCount < errorThreshold {
 				log.Info("Retrying", "function", "updateStatus", "retryCount", retryCount+1, "instanceID", instanceID)
@@ -456,6 +702,12 @@ func removeString(slice []string, s string) (result []string) {
 }
 
 func (r *ReconcileSFServiceInstance) handleError(object *osbv1alpha1.SFServiceInstance, result reconcile.Result, inputErr error, lastOperation string, retryCount int) (reconcile.Result, error) {
+	return r.recordError(object, object.GetGeneration(), result, inputErr, lastOperation, retryCount)
+}
+
+// recordError records the result of the reconcile of the generation
+// <generation> of the object in its status
+func (r *ReconcileSFServiceInstance) recordError(object *osbv1alpha1.SFServiceInstance, generation int64, result reconcile.Result, inputErr error, lastOperation string, retryCount int) (reconcile.Result, error) {
 	objectID := object.GetName()
 	namespace := object.GetNamespace()
 	// Fetch object again before updating
@@ -470,12 +722,31 @@ func (r *ReconcileSFServiceInstance) handleError(object *osbv1alpha1.SFServiceIn
 		}
 		if retryCount < errorThreshold {
 			log.Info("Retrying", "function", "handleError", "retryCount", retryCount+1, "lastOperation", lastOperation, "err", inputErr, "objectID", objectID)
-			return r.handleError(object, result, inputErr, lastOperation, retryCount+1)
+			return r.recordError(object, generation, result, inputErr, lastOperation, retryCount+1)
 		}
 		log.Error(err, "failed to fetch object", "objectID", objectID)
 		return result, inputErr
 	}
 
+	if clusterFactory.IsClusterUnavailable(inputErr) {
+		// Not a failure of the object, the reconcile is paused without
+		// counting the error until the cluster is reachable again
+		log.Info("Cluster unavailable. Pausing reconcile", "objectID", objectID, "reason", inputErr.Error())
+		if setClusterAvailable(&object.Status.Conditions, inputErr) {
+			err := r.Status().Update(context.TODO(), object)
+			if err != nil {
+				if retryCount < errorThreshold {
+					log.Info("Retrying", "function", "handleError", "retryCount", retryCount+1, "lastOperation", lastOperation, "err", inputErr, "objectID", objectID)
+					return r.recordError(object, generation, result, inputErr, lastOperation, retryCount+1)
+				}
+				log.Error(err, "Failed to set condition", "objectID", objectID, "condition", osbv1alpha1.ClusterAvailable)
+			}
+		}
+		return reconcile.Result{RequeueAfter: unavailableInterval}, nil
+	}
+	resumed := inputErr == nil && setClusterAvailable(&object.Status.Conditions, nil)
+	changed := setConditions(object, generation, inputErr)
+
 	labels := object.GetLabels()
 	var count int64
 
@@ -496,11 +767,15 @@ func (r *ReconcileSFServiceInstance) handleError(object *osbv1alpha1.SFServiceIn
 	}
 
 	if inputErr == nil {
-		if count == 0 {
+		if count == 0 && !resumed && !changed {
 			//No change for count
 			return result, inputErr
 		}
 		count = 0
+	} else if renderer.IsLimitExceeded(inputErr) || resources.IsWaveTimeout(inputErr) {
+		// Rendering the templates again exceeds the limits again, and
+		// the wave which timed out is not waited for any longer
+		count = errorThreshold + 1
 	} else {
 		count++
 	}
@@ -509,16 +784,23 @@ func (r *ReconcileSFServiceInstance) handleError(object *osbv1alpha1.SFServiceIn
 		log.Error(inputErr, "Retry threshold reached. Ignoring error", "objectID", objectID)
 		object.Status.State = "failed"
 		object.Status.Error = fmt.Sprintf("Retry threshold reached for %s.\n%s", objectID, inputErr.Error())
+		if renderer.IsLimitExceeded(inputErr) {
+			object.Status.Error = fmt.Sprintf("Rendering templates failed for %s.\n%s", objectID, inputErr.Error())
+		}
+		if resources.IsWaveTimeout(inputErr) {
+			object.Status.Error = fmt.Sprintf("Resources not ready for %s.\n%s", objectID, inputErr.Error())
+		}
 		object.Status.Description = "Service Broker Error, status code: ETIMEDOUT, error code: 10008"
 		if lastOperation != "" {
 			labels[lastOperationKey] = lastOperation
 			object.SetLabels(labels)
 		}
-		err := r.Update(context.TODO(), object)
+		setConditions(object, generation, inputErr)
+		err := r.updateWithStatus(object)
 		if err != nil {
 			if retryCount < errorThreshold {
 				log.Info("Retrying", "function", "handleError", "retryCount", retryCount+1, "lastOperation", lastOperation, "err", inputErr, "objectID", objectID)
-				return r.handleError(object, result, inputErr, lastOperation, retryCount+1)
+				return r.recordError(object, generation, result, inputErr, lastOperation, retryCount+1)
 			}
 			log.Error(err, "Failed to set state to failed", "objectID", objectID)
 		}
@@ -527,13 +809,94 @@ func (r *ReconcileSFServiceInstance) handleError(object *osbv1alpha1.SFServiceIn
 
 	labels[errorCountKey] = strconv.FormatInt(count, 10)
 	object.SetLabels(labels)
-	err = r.Update(context.TODO(), object)
+	err = r.updateWithStatus(object)
 	if err != nil {
 		if retryCount < errorThreshold {
 			log.Info("Retrying", "function", "handleError", "retryCount", retryCount+1, "lastOperation", lastOperation, "err", inputErr, "objectID", objectID)
-			return r.handleError(object, result, inputErr, lastOperation, retryCount+1)
+			return r.recordError(object, generation, result, inputErr, lastOperation, retryCount+1)
 		}
 		log.Error(err, "Failed to update error count label", "objectID", objectID, "count", count)
 	}
 	return result, inputErr
 }
+
+// setConditions sets the Ready, Reconciled and Deleting conditions of the
+// instance from its state and the error of the reconcile, and records the
+// generation reconciled. It returns true if the status changed.
+func setConditions(instance *osbv1alpha1.SFServiceInstance, generation int64, inputErr error) bool {
+	conditions := &instance.Status.Conditions
+	changed := osbv1alpha1.SetCondition(conditions, osbv1alpha1.ReadyCondition(instance.GetState(), instance.Status.Error))
+
+	reconciled := osbv1alpha1.Condition{
+		Type:   osbv1alpha1.ConditionReconciled,
+		Status: osbv1alpha1.ConditionTrue,
+		Reason: "ReconcileSucceeded",
+	}
+	if inputErr != nil {
+		reconciled.Status = osbv1alpha1.ConditionFalse
+		reconciled.Reason = "ReconcileFailed"
+		reconciled.Message = inputErr.Error()
+	}
+	changed = osbv1alpha1.SetCondition(conditions, reconciled) || changed
+
+	deleting := osbv1alpha1.Condition{
+		Type:   osbv1alpha1.ConditionDeleting,
+		Status: osbv1alpha1.ConditionFalse,
+		Reason: "NotDeleting",
+	}
+	if instance.GetState() == "delete" || !instance.GetDeletionTimestamp().IsZero() {
+		deleting.Status = osbv1alpha1.ConditionTrue
+		deleting.Reason = "DeleteRequested"
+	}
+	changed = osbv1alpha1.SetCondition(conditions, deleting) || changed
+
+	if generation != 0 && instance.Status.ObservedGeneration != generation {
+		instance.Status.ObservedGeneration = generation
+		changed = true
+	}
+	return changed
+}
+
+// updateWithStatus updates the instance and writes its status through the
+// status subresource. The status is written first, so that it is recorded
+// before a finalizer is removed.
+func (r *ReconcileSFServiceInstance) updateWithStatus(instance *osbv1alpha1.SFServiceInstance) error {
+	updated := instance.DeepCopy()
+	if err := r.Status().Update(context.TODO(), updated); err != nil {
+		return err
+	}
+	instance.SetResourceVersion(updated.GetResourceVersion())
+	return r.Update(context.TODO(), instance)
+}
+
+// setClusterAvailable sets the ClusterAvailable condition to False if
+// <err> is not nil. Otherwise the condition is set to True if it was set
+// before. It returns true if the conditions changed.
+func setClusterAvailable(conditions *[]osbv1alpha1.Condition, err error) bool {
+	if err != nil {
+		return osbv1alpha1.SetCondition(conditions, osbv1alpha1.Condition{
+			Type:    osbv1alpha1.ClusterAvailable,
+			Status:  osbv1alpha1.ConditionFalse,
+			Reason:  "ClusterUnavailable",
+			Message: err.Error(),
+		})
+	}
+	if osbv1alpha1.GetCondition(*conditions, osbv1alpha1.ClusterAvailable) == nil {
+		return false
+	}
+	return osbv1alpha1.SetCondition(conditions, osbv1alpha1.Condition{
+		Type:   osbv1alpha1.ClusterAvailable,
+		Status: osbv1alpha1.ConditionTrue,
+		Reason: "ClusterAvailable",
+	})
+}
+
+// containsSource checks if the slice of resources contains the resource
+func containsSource(slice []osbv1alpha1.Source, s osbv1alpha1.Source) bool {
+	for _, item := range slice {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
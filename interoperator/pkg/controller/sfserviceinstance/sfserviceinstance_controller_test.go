@@ -25,17 +25,23 @@ import (
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis"
 	osbv1alpha1 "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis/osb/v1alpha1"
 	mock_clusterFactory "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/cluster/factory/mock_factory"
 	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/resources/mock_resources"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/watches"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/scheduler"
 
 	"github.com/golang/mock/gomock"
 	"github.com/onsi/gomega"
 	"golang.org/x/net/context"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
@@ -94,6 +100,8 @@ var service = &osbv1alpha1.SFService{
 	},
 }
 
+var planUpdatable = true
+
 var plan = &osbv1alpha1.SFPlan{
 	ObjectMeta: metav1.ObjectMeta{
 		Name:      "plan-id",
@@ -110,7 +118,7 @@ var plan = &osbv1alpha1.SFPlan{
 		Metadata:      nil,
 		Free:          false,
 		Bindable:      true,
-		PlanUpdatable: true,
+		PlanUpdatable: &planUpdatable,
 		Schemas:       nil,
 		Templates:     templateSpec,
 		ServiceID:     "service-id",
@@ -163,11 +171,15 @@ func TestReconcile(t *testing.T) {
 
 	mockResourceManager := mock_resources.NewMockResourceManager(ctrl)
 	mockClusterFactory := mock_clusterFactory.NewMockClusterFactory(ctrl)
-	reconciler := newReconciler(mgr, mockResourceManager, mockClusterFactory)
+	watchManager, err := watches.New(mgr)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	instanceScheduler, err := scheduler.Default()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	reconciler := newReconciler(mgr, mockResourceManager, mockClusterFactory, watchManager, instanceScheduler)
 
 	mockResourceManager.EXPECT().ComputeExpectedResources(gomock.Any(), "instance-id", "", "service-id", "plan-id", osbv1alpha1.ProvisionAction, "default").Return(expectedResources, nil).AnyTimes()
 	mockResourceManager.EXPECT().SetOwnerReference(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
-	mockClusterFactory.EXPECT().GetCluster("instance-id", "", "service-id", "plan-id").Return(reconciler, nil).AnyTimes()
+	mockClusterFactory.EXPECT().GetCluster("instance-id", "", "service-id", "plan-id", "default").Return(reconciler, nil).AnyTimes()
 	mockResourceManager.EXPECT().ReconcileResources(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(appliedResources, err1).Times(1)
 	mockResourceManager.EXPECT().ReconcileResources(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(appliedResources, nil).AnyTimes()
 	mockResourceManager.EXPECT().ComputeStatus(gomock.Any(), gomock.Any(), "instance-id", "", "service-id", "plan-id", osbv1alpha1.ProvisionAction, "default").Return(&properties.Status{
@@ -178,10 +190,10 @@ func TestReconcile(t *testing.T) {
 			State: "succeeded",
 		},
 	}, nil).AnyTimes()
-	mockResourceManager.EXPECT().DeleteSubResources(gomock.Any(), gomock.Any()).Return(appliedResources, nil).AnyTimes()
+	mockResourceManager.EXPECT().DeleteSubResources(gomock.Any(), gomock.Any(), gomock.Any()).Return(appliedResources, nil, nil).AnyTimes()
 
 	recFn, requests := SetupTestReconcile(reconciler)
-	g.Expect(add(mgr, recFn)).NotTo(gomega.HaveOccurred())
+	g.Expect(add(mgr, recFn, watchManager)).NotTo(gomega.HaveOccurred())
 
 	stopMgr, mgrStopped := StartTestManager(mgr, g)
 
@@ -249,3 +261,140 @@ func drainAllRequests(requests <-chan reconcile.Request, remainingTime time.Dura
 		return 0
 	}
 }
+
+func Test_setClusterAvailable(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	var conditions []osbv1alpha1.Condition
+	g.Expect(setClusterAvailable(&conditions, nil)).To(gomega.BeFalse())
+	g.Expect(conditions).To(gomega.BeEmpty())
+
+	g.Expect(setClusterAvailable(&conditions, fmt.Errorf("cluster-1 is unavailable"))).To(gomega.BeTrue())
+	g.Expect(setClusterAvailable(&conditions, fmt.Errorf("cluster-1 is unavailable"))).To(gomega.BeFalse())
+	condition := osbv1alpha1.GetCondition(conditions, osbv1alpha1.ClusterAvailable)
+	g.Expect(condition.Status).To(gomega.Equal(osbv1alpha1.ConditionFalse))
+	g.Expect(condition.Message).To(gomega.Equal("cluster-1 is unavailable"))
+
+	g.Expect(setClusterAvailable(&conditions, nil)).To(gomega.BeTrue())
+	condition = osbv1alpha1.GetCondition(conditions, osbv1alpha1.ClusterAvailable)
+	g.Expect(condition.Status).To(gomega.Equal(osbv1alpha1.ConditionTrue))
+	g.Expect(condition.Message).To(gomega.BeEmpty())
+}
+
+func Test_setConditions(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	instance := &osbv1alpha1.SFServiceInstance{
+		ObjectMeta: metav1.ObjectMeta{Generation: 3},
+		Status:     osbv1alpha1.SFServiceInstanceStatus{State: "in progress"},
+	}
+	g.Expect(setConditions(instance, 3, nil)).To(gomega.BeTrue())
+	g.Expect(instance.Status.ObservedGeneration).To(gomega.Equal(int64(3)))
+	g.Expect(osbv1alpha1.GetCondition(instance.Status.Conditions, osbv1alpha1.ConditionReady).Status).To(gomega.Equal(osbv1alpha1.ConditionFalse))
+	g.Expect(osbv1alpha1.GetCondition(instance.Status.Conditions, osbv1alpha1.ConditionReconciled).Status).To(gomega.Equal(osbv1alpha1.ConditionTrue))
+	g.Expect(osbv1alpha1.GetCondition(instance.Status.Conditions, osbv1alpha1.ConditionDeleting).Status).To(gomega.Equal(osbv1alpha1.ConditionFalse))
+
+	g.Expect(setConditions(instance, 3, nil)).To(gomega.BeFalse())
+
+	// The spec changed while the generation 3 was reconciled
+	instance.SetGeneration(4)
+	g.Expect(setConditions(instance, 3, nil)).To(gomega.BeFalse())
+	g.Expect(instance.Status.ObservedGeneration).To(gomega.Equal(int64(3)))
+
+	instance.Status.State = "delete"
+	g.Expect(setConditions(instance, 4, fmt.Errorf("delete failed"))).To(gomega.BeTrue())
+	g.Expect(instance.Status.ObservedGeneration).To(gomega.Equal(int64(4)))
+	reconciled := osbv1alpha1.GetCondition(instance.Status.Conditions, osbv1alpha1.ConditionReconciled)
+	g.Expect(reconciled.Status).To(gomega.Equal(osbv1alpha1.ConditionFalse))
+	g.Expect(reconciled.Message).To(gomega.Equal("delete failed"))
+	g.Expect(osbv1alpha1.GetCondition(instance.Status.Conditions, osbv1alpha1.ConditionDeleting).Status).To(gomega.Equal(osbv1alpha1.ConditionTrue))
+}
+
+// failingListClient fails to list the objects
+type failingListClient struct {
+	client.Client
+}
+
+func (c failingListClient) List(ctx context.Context, opts *client.ListOptions, list runtime.Object) error {
+	return fmt.Errorf("list failed")
+}
+
+func Test_getDeletionStrategies(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	g.Expect(apis.AddToScheme(scheme.Scheme)).To(gomega.Succeed())
+
+	labels := map[string]string{"serviceId": "service-id", "planId": "plan-id"}
+	service := &osbv1alpha1.SFService{
+		ObjectMeta: metav1.ObjectMeta{Name: "service-id", Namespace: "default", Labels: labels},
+		Spec:       osbv1alpha1.SFServiceSpec{ID: "service-id"},
+	}
+	strategies := []osbv1alpha1.DeletionStrategy{{APIVersion: "v1", Kind: "Secret", Type: "finalizer"}}
+	plan := &osbv1alpha1.SFPlan{
+		ObjectMeta: metav1.ObjectMeta{Name: "plan-id", Namespace: "default", Labels: labels},
+		Spec: osbv1alpha1.SFPlanSpec{
+			ID:                 "plan-id",
+			ServiceID:          "service-id",
+			DeletionStrategies: strategies,
+		},
+	}
+	// The plan is looked up in the namespace of the services
+	instance := &osbv1alpha1.SFServiceInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "instance-id", Namespace: "sf-instance-id"},
+		Spec:       osbv1alpha1.SFServiceInstanceSpec{ServiceID: "service-id", PlanID: "plan-id"},
+	}
+
+	r := &ReconcileSFServiceInstance{Client: fake.NewFakeClient(service, plan)}
+	got, err := r.getDeletionStrategies(instance)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(got).To(gomega.Equal(strategies))
+
+	// The deletion is retried if the plan is not found
+	r = &ReconcileSFServiceInstance{Client: fake.NewFakeClient(service)}
+	_, err = r.getDeletionStrategies(instance)
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	// Other errors are retried
+	r = &ReconcileSFServiceInstance{Client: failingListClient{fake.NewFakeClient(service, plan)}}
+	_, err = r.getDeletionStrategies(instance)
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func Test_schedule(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	g.Expect(apis.AddToScheme(scheme.Scheme)).To(gomega.Succeed())
+
+	// The services, plans and clusters live in the default namespace, the
+	// instances in their own namespaces
+	labels := map[string]string{"serviceId": "service-id", "planId": "plan-id"}
+	service := &osbv1alpha1.SFService{
+		ObjectMeta: metav1.ObjectMeta{Name: "service-id", Namespace: "default", Labels: labels},
+		Spec:       osbv1alpha1.SFServiceSpec{ID: "service-id"},
+	}
+	plan := &osbv1alpha1.SFPlan{
+		ObjectMeta: metav1.ObjectMeta{Name: "plan-id", Namespace: "default", Labels: labels},
+		Spec:       osbv1alpha1.SFPlanSpec{ID: "plan-id", ServiceID: "service-id"},
+	}
+	cluster := func(name string) *osbv1alpha1.SFCluster {
+		return &osbv1alpha1.SFCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       osbv1alpha1.SFClusterSpec{SecretRef: name + "-kubeconfig"},
+		}
+	}
+	scheduled := &osbv1alpha1.SFServiceInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "scheduled-id", Namespace: "sf-scheduled-id"},
+		Spec:       osbv1alpha1.SFServiceInstanceSpec{ServiceID: "service-id", PlanID: "plan-id", ClusterID: "cluster-1"},
+	}
+	instance := &osbv1alpha1.SFServiceInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "instance-id", Namespace: "sf-instance-id"},
+		Spec:       osbv1alpha1.SFServiceInstanceSpec{ServiceID: "service-id", PlanID: "plan-id"},
+	}
+
+	r := &ReconcileSFServiceInstance{
+		Client:    fake.NewFakeClient(service, plan, cluster("cluster-1"), cluster("cluster-2"), scheduled, instance),
+		scheduler: scheduler.NewLeastInstances(),
+	}
+	ok, err := r.schedule(instance, 0)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(ok).To(gomega.BeTrue())
+	g.Expect(instance.Spec.ClusterID).To(gomega.Equal("cluster-2"))
+}
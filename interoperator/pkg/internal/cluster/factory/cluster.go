@@ -0,0 +1,188 @@
+package factory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	kubernetes "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// cluster is the long-lived client of a data plane cluster. It is reused
+// as long as the kubeconfig of the cluster is unchanged.
+type cluster struct {
+	client kubernetes.Client
+	// cfg is the client config built from the kubeconfig
+	cfg *rest.Config
+	// checksum is the checksum of the kubeconfig the client is built from
+	checksum string
+	// stop stops the informers of the cache of the client
+	stop chan struct{}
+
+	// discovery probes the API server of the cluster
+	discovery discovery.ServerVersionInterface
+	// mu guards the result of the last probe
+	mu       sync.Mutex
+	probed   time.Time
+	probeErr error
+}
+
+// newCluster returns a client of the cluster reading from an informer
+// cache, like the client of the manager. Unstructured objects are read from
+// the API server.
+func newCluster(cfg *rest.Config, scheme *runtime.Scheme, checksum string) (*cluster, error) {
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	// probes of an unreachable cluster must not block the reconciles
+	probeCfg := rest.CopyConfig(cfg)
+	probeCfg.Timeout = probeTimeout
+	probeClient, err := discovery.NewDiscoveryClientForConfig(probeCfg)
+	if err != nil {
+		return nil, err
+	}
+	mapper := &refreshingMapper{
+		DeferredDiscoveryRESTMapper: restmapper.NewDeferredDiscoveryRESTMapper(cached.NewMemCacheClient(discoveryClient)),
+	}
+	client, err := kubernetes.New(cfg, kubernetes.Options{
+		Scheme: scheme,
+		Mapper: mapper,
+	})
+	if err != nil {
+		return nil, err
+	}
+	informers, err := cache.New(cfg, cache.Options{
+		Scheme: scheme,
+		Mapper: mapper,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stop := make(chan struct{})
+	go func() {
+		if err := informers.Start(stop); err != nil {
+			log.Error(err, "failed to start the cache of the cluster")
+		}
+	}()
+	return &cluster{
+		client: &kubernetes.DelegatingClient{
+			Reader: &kubernetes.DelegatingReader{
+				CacheReader:  &syncedReader{cache: informers, scheme: scheme, stop: stop},
+				ClientReader: client,
+			},
+			Writer:       client,
+			StatusClient: client,
+		},
+		cfg:       cfg,
+		checksum:  checksum,
+		stop:      stop,
+		discovery: probeClient,
+	}, nil
+}
+
+// close stops the informers of the cluster
+func (c *cluster) close() {
+	close(c.stop)
+}
+
+// probe returns the error of the last probe of the API server of the
+// cluster, probing it again if the last probe is older than <interval>.
+// The returned bool is true if the result differs from the one of the last
+// probe.
+func (c *cluster) probe(interval time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.probed.IsZero() && time.Since(c.probed) < interval {
+		return false, c.probeErr
+	}
+	_, err := c.discovery.ServerVersion()
+	changed := c.probed.IsZero() || (err == nil) != (c.probeErr == nil)
+	c.probed = time.Now()
+	c.probeErr = err
+	return changed, err
+}
+
+// fail records the failure to reach the API server of the cluster as the
+// result of the last probe. The returned bool is true if the cluster was
+// reachable before.
+func (c *cluster) fail(err error) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	changed := c.probed.IsZero() || c.probeErr == nil
+	c.probed = time.Now()
+	c.probeErr = err
+	return changed
+}
+
+// refreshingMapper refreshes the discovery information once if a kind is not
+// found, as its custom resource definition may have been created since
+type refreshingMapper struct {
+	*restmapper.DeferredDiscoveryRESTMapper
+}
+
+func (m *refreshingMapper) RESTMapping(gk schema.GroupKind, versions ...string) (*meta.RESTMapping, error) {
+	mapping, err := m.DeferredDiscoveryRESTMapper.RESTMapping(gk, versions...)
+	if meta.IsNoMatchError(err) {
+		m.Reset()
+		mapping, err = m.DeferredDiscoveryRESTMapper.RESTMapping(gk, versions...)
+	}
+	return mapping, err
+}
+
+// syncedReader waits for the informer of a kind to be synced before reading
+// it from the cache, as the informers of the cache are started
+// asynchronously
+type syncedReader struct {
+	cache  cache.Cache
+	scheme *runtime.Scheme
+	stop   <-chan struct{}
+}
+
+func (r *syncedReader) Get(ctx context.Context, key kubernetes.ObjectKey, obj runtime.Object) error {
+	if err := r.waitForSync(obj); err != nil {
+		return err
+	}
+	return r.cache.Get(ctx, key, obj)
+}
+
+func (r *syncedReader) List(ctx context.Context, opts *kubernetes.ListOptions, list runtime.Object) error {
+	gvk, err := apiutil.GVKForObject(list, r.scheme)
+	if err != nil {
+		return err
+	}
+	// the informer is of the kind of the items of the list
+	gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+	obj, err := r.scheme.New(gvk)
+	if err != nil {
+		return err
+	}
+	if err := r.waitForSync(obj); err != nil {
+		return err
+	}
+	return r.cache.List(ctx, opts, list)
+}
+
+func (r *syncedReader) waitForSync(obj runtime.Object) error {
+	informer, err := r.cache.GetInformer(obj)
+	if err != nil {
+		return err
+	}
+	if !informer.HasSynced() && !toolscache.WaitForCacheSync(r.stop, informer.HasSynced) {
+		return fmt.Errorf("cache of the cluster is stopped")
+	}
+	return nil
+}
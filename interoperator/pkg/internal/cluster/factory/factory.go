@@ -1,11 +1,20 @@
 package factory
 
 import (
+	"context"
+	"crypto/sha256"
 	"fmt"
+	"sync"
 
+	osbv1alpha1 "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis/osb/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/rest"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
 	kubernetes "sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
 )
@@ -16,45 +25,228 @@ var log = logf.Log.WithName("cluster.factory")
 //go:generate mockgen -source factory.go -destination ./mock_factory/mock_factory.go
 type ClusterFactory interface {
 	// TODO pass the entire SFServiceInstance and SfServiceBinding
-	GetCluster(instanceID, bindingID, serviceID, planID string) (kubernetes.Client, error)
+	GetCluster(instanceID, bindingID, serviceID, planID, namespace string) (kubernetes.Client, error)
+	// GetClusterClient returns a kubernetes client for the SFCluster
+	// <clusterID>, or for the local cluster if <clusterID> is empty
+	GetClusterClient(clusterID string) (kubernetes.Client, error)
+	// GetClusterRESTConfig returns the client config of the cached client
+	// of the SFCluster <clusterID>, along with the checksum of its
+	// kubeconfig
+	GetClusterRESTConfig(clusterID string) (*rest.Config, string, error)
 }
 
 type clusterFactory struct {
 	mgr manager.Manager
-	cfg *rest.Config
+
+	mu sync.Mutex
+	// clusters are the clients of the SFClusters by name
+	clusters  map[string]*cluster
+	watchOnce sync.Once
 }
 
-// New returns a new ClusterFactory using the provided manager
+var (
+	factoriesLock sync.Mutex
+	factories     = make(map[manager.Manager]*clusterFactory)
+)
+
+// New returns the ClusterFactory using the provided manager. The same
+// ClusterFactory is returned for the same manager, so that the controllers
+// share the clients of the clusters.
 func New(mgr manager.Manager) (ClusterFactory, error) {
 	if mgr == nil {
 		return nil, fmt.Errorf("invalid input to new manager")
 	}
-	return &clusterFactory{
-		mgr: mgr,
+	factoriesLock.Lock()
+	defer factoriesLock.Unlock()
+	if f, ok := factories[mgr]; ok {
+		return f, nil
+	}
+	f := &clusterFactory{
+		mgr:      mgr,
+		clusters: make(map[string]*cluster),
+	}
+	factories[mgr] = f
+	return f, nil
+}
+
+// watchClusters evicts the client of a cluster once the SFCluster is
+// deleted. It is called on the first use of a SFCluster, the informer of the
+// manager requires the custom resource definition.
+func (f *clusterFactory) watchClusters() {
+	f.watchOnce.Do(func() {
+		informer, err := f.mgr.GetCache().GetInformer(&osbv1alpha1.SFCluster{})
+		if err != nil {
+			log.Error(err, "unable to watch clusters, the clients of deleted clusters are kept")
+			return
+		}
+		informer.AddEventHandler(f.clusterHandler())
+	})
+}
+
+// clusterHandler evicts the client of a deleted SFCluster
+func (f *clusterFactory) clusterHandler() toolscache.ResourceEventHandler {
+	return toolscache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if cluster, ok := obj.(*osbv1alpha1.SFCluster); ok && cluster.GetNamespace() == clusterNamespace {
+				f.evict(cluster.GetName())
+			}
+		},
+	}
+}
+
+// evict stops and drops the cached client of the SFCluster
+func (f *clusterFactory) evict(clusterID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if c, ok := f.clusters[clusterID]; ok {
+		log.Info("cluster deleted. dropping client", "clusterID", clusterID)
+		c.close()
+		delete(f.clusters, clusterID)
+	}
+}
+
+// GetCluster gets the cluster the instance is deployed to and returns a
+// kubernetes client for it. The instance is deployed to the SFCluster named
+// by its ClusterID, or to the local cluster if it has none.
+func (f *clusterFactory) GetCluster(instanceID, bindingID, serviceID, planID, namespace string) (kubernetes.Client, error) {
+	clusterID, err := f.getClusterID(instanceID, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return f.GetClusterClient(clusterID)
+}
+
+// GetClusterClient returns a kubernetes client for the SFCluster
+// <clusterID>, or for the local cluster if <clusterID> is empty
+func (f *clusterFactory) GetClusterClient(clusterID string) (kubernetes.Client, error) {
+	if clusterID == "" {
+		// The client of the manager is cached and shared by the controllers
+		return f.mgr.GetClient(), nil
+	}
+	c, err := f.getCluster(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	return &healthClient{
+		Client:    c.client,
+		factory:   f,
+		cluster:   c,
+		clusterID: clusterID,
 	}, nil
+}
 
+// GetClusterRESTConfig returns the client config of the cached client of the
+// SFCluster <clusterID>, along with the checksum of its kubeconfig
+func (f *clusterFactory) GetClusterRESTConfig(clusterID string) (*rest.Config, string, error) {
+	c, err := f.getCluster(clusterID)
+	if err != nil {
+		return nil, "", err
+	}
+	return c.cfg, c.checksum, nil
 }
 
-// GetCluster gets a cluster and returns a kubernetes client for it
-func (f *clusterFactory) GetCluster(instanceID, bindingID, serviceID, planID string) (kubernetes.Client, error) {
-	var err error
-	cfg := f.cfg
-	if cfg == nil {
-		cfg, err = config.GetConfig()
-		if err != nil {
-			log.Error(err, "unable to get client config")
-			return nil, err
+// getCluster returns the cached client of the SFCluster. The client is
+// created once and reused until the kubeconfig of the cluster changes. A
+// ClusterUnavailableError is returned while the API server of the cluster
+// is unreachable.
+func (f *clusterFactory) getCluster(clusterID string) (*cluster, error) {
+	cfg, checksum, err := GetClusterConfig(f.mgr.GetClient(), clusterID, clusterNamespace)
+	if err != nil {
+		if apiErrors.IsNotFound(err) {
+			f.evict(clusterID)
 		}
+		log.Error(err, "unable to get client config", "clusterID", clusterID)
+		return nil, err
 	}
+	f.watchClusters()
 
-	options := kubernetes.Options{
-		Scheme: f.mgr.GetScheme(),
-		Mapper: f.mgr.GetRESTMapper(),
+	c, err := f.cachedCluster(cfg, checksum, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	// probed outside of the lock, an unreachable cluster must not block
+	// the clients of the other clusters
+	if err := f.checkHealth(c, clusterID); err != nil {
+		return nil, err
 	}
-	client, err := kubernetes.New(cfg, options)
+	return c, nil
+}
+
+// cachedCluster returns the cached cluster, creating it if it is not cached
+// or was built from another kubeconfig
+func (f *clusterFactory) cachedCluster(cfg *rest.Config, checksum, clusterID string) (*cluster, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if c, ok := f.clusters[clusterID]; ok {
+		if c.checksum == checksum {
+			return c, nil
+		}
+		log.Info("kubeconfig of cluster changed. recreating client", "clusterID", clusterID)
+		c.close()
+		delete(f.clusters, clusterID)
+	}
+
+	c, err := newCluster(cfg, f.mgr.GetScheme(), checksum)
 	if err != nil {
-		log.Error(err, "unable create kubernetes client")
+		log.Error(err, "unable create kubernetes client", "clusterID", clusterID)
 		return nil, err
 	}
-	return client, nil
+	f.clusters[clusterID] = c
+	return c, nil
+}
+
+// getClusterID returns the ClusterID of the instance. Instances not found
+// are handled as deployed to the local cluster.
+func (f *clusterFactory) getClusterID(instanceID, namespace string) (string, error) {
+	if instanceID == "" {
+		return "", nil
+	}
+	instance := &osbv1alpha1.SFServiceInstance{}
+	err := f.mgr.GetClient().Get(context.TODO(), types.NamespacedName{
+		Name:      instanceID,
+		Namespace: namespace,
+	}, instance)
+	if err != nil {
+		if apiErrors.IsNotFound(err) {
+			return "", nil
+		}
+		log.Error(err, "unable to get instance", "instanceID", instanceID)
+		return "", err
+	}
+	return instance.Spec.ClusterID, nil
+}
+
+// GetClusterConfig returns the client config of the SFCluster from the
+// kubeconfig in its secret, along with the checksum of the kubeconfig
+func GetClusterConfig(client kubernetes.Client, clusterID, namespace string) (*rest.Config, string, error) {
+	cluster := &osbv1alpha1.SFCluster{}
+	err := client.Get(context.TODO(), types.NamespacedName{
+		Name:      clusterID,
+		Namespace: namespace,
+	}, cluster)
+	if err != nil {
+		return nil, "", err
+	}
+
+	secret := &corev1.Secret{}
+	err = client.Get(context.TODO(), types.NamespacedName{
+		Name:      cluster.Spec.SecretRef,
+		Namespace: namespace,
+	}, secret)
+	if err != nil {
+		return nil, "", err
+	}
+
+	kubeconfig, ok := secret.Data[osbv1alpha1.KubeconfigKey]
+	if !ok {
+		return nil, "", fmt.Errorf("secret %s of cluster %s has no %s", cluster.Spec.SecretRef, clusterID, osbv1alpha1.KubeconfigKey)
+	}
+	cfg, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, "", err
+	}
+	return cfg, fmt.Sprintf("%x", sha256.Sum256(kubeconfig)), nil
 }
@@ -1,9 +1,18 @@
 package factory
 
 import (
+	"context"
 	"reflect"
 	"testing"
 
+	osbv1alpha1 "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis/osb/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	kubernetes "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
 
@@ -31,7 +40,8 @@ func TestNew(t *testing.T) {
 				mgr: mgr,
 			},
 			want: &clusterFactory{
-				mgr: mgr,
+				mgr:      mgr,
+				clusters: make(map[string]*cluster),
 			},
 			wantErr: false,
 		},
@@ -52,59 +62,255 @@ func TestNew(t *testing.T) {
 
 func TestClusterFactory_GetCluster(t *testing.T) {
 	factory := &clusterFactory{
-		mgr: mgr,
-		cfg: cfg,
+		mgr:      mgr,
+		clusters: make(map[string]*cluster),
 	}
 	type args struct {
 		instanceID string
 		bindingID  string
 		serviceID  string
 		planID     string
+		namespace  string
 	}
 	tests := []struct {
 		name    string
 		f       ClusterFactory
 		args    args
-		want    bool
+		want    kubernetes.Client
 		wantErr bool
 	}{
 		{
 			name: "error on no config",
 			f: &clusterFactory{
-				mgr: mgr,
+				mgr:      newFakeManager(t),
+				clusters: make(map[string]*cluster),
 			},
 			args: args{
-				instanceID: "instanceId",
+				instanceID: "instance-on-unknown-cluster",
 				bindingID:  "bindingID",
 				serviceID:  "serviceID",
 				planID:     "planID",
+				namespace:  "default",
 			},
-			want:    false,
+			want:    nil,
 			wantErr: true,
 		},
 		{
-			name: "return new client",
+			name: "return client of the manager for the local cluster",
 			f:    factory,
 			args: args{
 				instanceID: "instanceId",
 				bindingID:  "bindingID",
 				serviceID:  "serviceID",
 				planID:     "planID",
+				namespace:  "default",
 			},
-			want:    true,
+			want:    mgr.GetClient(),
 			wantErr: false,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := tt.f.GetCluster(tt.args.instanceID, tt.args.bindingID, tt.args.serviceID, tt.args.planID)
+			got, err := tt.f.GetCluster(tt.args.instanceID, tt.args.bindingID, tt.args.serviceID, tt.args.planID, tt.args.namespace)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ClusterFactory.GetCluster() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if (got != nil) != tt.want {
+			if got != tt.want {
 				t.Errorf("ClusterFactory.GetCluster() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+const kubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: data-plane
+  cluster:
+    server: https://data-plane.example.com
+contexts:
+- name: data-plane
+  context:
+    cluster: data-plane
+    user: interoperator
+current-context: data-plane
+users:
+- name: interoperator
+  user:
+    token: token
+`
+
+func newScheme(t *testing.T) *runtime.Scheme {
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("AddToScheme() error = %v", err)
+	}
+	if err := osbv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("AddToScheme() error = %v", err)
+	}
+	return s
+}
+
+// fakeManager serves a fake client holding the SFCluster cluster-id and its
+// kubeconfig, and an instance deployed to an unknown cluster
+type fakeManager struct {
+	manager.Manager
+	client kubernetes.Client
+	scheme *runtime.Scheme
+}
+
+func newFakeManager(t *testing.T) *fakeManager {
+	s := newScheme(t)
+	return &fakeManager{
+		client: fake.NewFakeClientWithScheme(s,
+			&osbv1alpha1.SFCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster-id", Namespace: "default"},
+				Spec:       osbv1alpha1.SFClusterSpec{SecretRef: "cluster-id-kubeconfig"},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster-id-kubeconfig", Namespace: "default"},
+				Data:       map[string][]byte{osbv1alpha1.KubeconfigKey: []byte(kubeconfig)},
+			},
+			&osbv1alpha1.SFServiceInstance{
+				ObjectMeta: metav1.ObjectMeta{Name: "instance-on-unknown-cluster", Namespace: "default"},
+				Spec:       osbv1alpha1.SFServiceInstanceSpec{ClusterID: "unknown"},
+			},
+		),
+		scheme: s,
+	}
+}
+
+func (m *fakeManager) GetClient() kubernetes.Client {
+	return m.client
+}
+
+func (m *fakeManager) GetScheme() *runtime.Scheme {
+	return m.scheme
+}
+
+func Test_clusterFactory_cachedCluster(t *testing.T) {
+	mgr := newFakeManager(t)
+	f := &clusterFactory{
+		mgr:      mgr,
+		clusters: make(map[string]*cluster),
+	}
+	cached := func() *cluster {
+		cfg, checksum, err := GetClusterConfig(mgr.client, "cluster-id", Namespace())
+		if err != nil {
+			t.Fatalf("GetClusterConfig() error = %v", err)
+		}
+		c, err := f.cachedCluster(cfg, checksum, "cluster-id")
+		if err != nil {
+			t.Fatalf("cachedCluster() error = %v", err)
+		}
+		return c
+	}
+
+	first := cached()
+	if got := cached(); got != first {
+		t.Errorf("cachedCluster() rebuilt the client while the kubeconfig is unchanged")
+	}
+
+	// The client is rebuilt once the kubeconfig changes
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-id-kubeconfig", Namespace: "default"},
+		Data:       map[string][]byte{osbv1alpha1.KubeconfigKey: []byte(kubeconfig + "preferences: {}\n")},
+	}
+	if err := mgr.client.Update(context.TODO(), secret); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	second := cached()
+	if second == first {
+		t.Errorf("cachedCluster() reused the client after the kubeconfig changed")
+	}
+	select {
+	case <-first.stop:
+	default:
+		t.Errorf("cachedCluster() did not stop the previous client")
+	}
+
+	// The SFClusters of other namespaces are ignored
+	f.clusterHandler().OnDelete(&osbv1alpha1.SFCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-id", Namespace: "sf-instance-id"},
+	})
+	if _, ok := f.clusters["cluster-id"]; !ok {
+		t.Errorf("clusterHandler() evicted the client for a cluster of another namespace")
+	}
+
+	// The client is evicted once the SFCluster is deleted
+	f.clusterHandler().OnDelete(&osbv1alpha1.SFCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-id", Namespace: "default"},
+	})
+	if _, ok := f.clusters["cluster-id"]; ok {
+		t.Errorf("clusterHandler() did not evict the client of the deleted cluster")
+	}
+	select {
+	case <-second.stop:
+	default:
+		t.Errorf("clusterHandler() did not stop the client of the deleted cluster")
+	}
+	if got := cached(); got == second {
+		t.Errorf("cachedCluster() reused the client of the deleted cluster")
+	}
+}
+
+func TestGetClusterConfig(t *testing.T) {
+	s := newScheme(t)
+	client := fake.NewFakeClientWithScheme(s,
+		&osbv1alpha1.SFCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-id", Namespace: "default"},
+			Spec:       osbv1alpha1.SFClusterSpec{SecretRef: "cluster-id-kubeconfig"},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-id-kubeconfig", Namespace: "default"},
+			Data:       map[string][]byte{osbv1alpha1.KubeconfigKey: []byte(kubeconfig)},
+		},
+		&osbv1alpha1.SFCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "no-kubeconfig", Namespace: "default"},
+			Spec:       osbv1alpha1.SFClusterSpec{SecretRef: "no-kubeconfig"},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "no-kubeconfig", Namespace: "default"},
+		},
+	)
+
+	tests := []struct {
+		name      string
+		clusterID string
+		want      string
+		wantErr   bool
+	}{
+		{
+			name:      "return config from kubeconfig",
+			clusterID: "cluster-id",
+			want:      "https://data-plane.example.com",
+			wantErr:   false,
+		},
+		{
+			name:      "error on unknown cluster",
+			clusterID: "unknown",
+			wantErr:   true,
+		},
+		{
+			name:      "error on secret without kubeconfig",
+			clusterID: "no-kubeconfig",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, checksum, err := GetClusterConfig(client, tt.clusterID, "default")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetClusterConfig() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != nil && got.Host != tt.want {
+				t.Errorf("GetClusterConfig() host = %v, want %v", got.Host, tt.want)
+			}
+			if (checksum != "") != (got != nil) {
+				t.Errorf("GetClusterConfig() checksum = %v", checksum)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package factory
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	osbv1alpha1 "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis/osb/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	kubernetes "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	defaultProbeInterval    = 30 * time.Second
+	probeTimeout            = 10 * time.Second
+	defaultClusterNamespace = "default"
+)
+
+var (
+	probeInterval    = defaultProbeInterval
+	clusterNamespace = defaultClusterNamespace
+)
+
+// AddFlags registers the --cluster-probe-interval and --cluster-namespace
+// flags in <fs>
+func AddFlags(fs *flag.FlagSet) {
+	fs.DurationVar(&probeInterval, "cluster-probe-interval", defaultProbeInterval, "Minimum interval between two health probes of the API server of a cluster.")
+	fs.StringVar(&clusterNamespace, "cluster-namespace", defaultClusterNamespace, "Namespace of the SFClusters and of the secrets of their kubeconfig.")
+}
+
+// Namespace returns the namespace of the SFClusters and of the secrets of
+// their kubeconfig, whatever the namespace of the instances
+func Namespace() string {
+	return clusterNamespace
+}
+
+// ClusterUnavailableError is returned for a cluster whose API server is
+// unreachable. Reconciles against the cluster are expected to be paused
+// rather than failed.
+type ClusterUnavailableError struct {
+	ClusterID string
+	Err       error
+}
+
+func (e *ClusterUnavailableError) Error() string {
+	return fmt.Sprintf("cluster %s is unavailable. %v", e.ClusterID, e.Err)
+}
+
+// IsClusterUnavailable returns true if the error is a ClusterUnavailableError
+func IsClusterUnavailable(err error) bool {
+	_, ok := err.(*ClusterUnavailableError)
+	return ok
+}
+
+// checkHealth probes the cluster and records the result in the Ready
+// condition of the SFCluster when it changes
+func (f *clusterFactory) checkHealth(c *cluster, clusterID string) error {
+	changed, err := c.probe(probeInterval)
+	if changed {
+		if err != nil {
+			log.Error(err, "cluster is unavailable", "clusterID", clusterID)
+		} else {
+			log.Info("cluster is available", "clusterID", clusterID)
+		}
+		f.setReady(clusterID, err)
+	}
+	if err != nil {
+		return &ClusterUnavailableError{ClusterID: clusterID, Err: err}
+	}
+	return nil
+}
+
+// isNetworkError returns true if the error is a failure to reach the API
+// server, rather than an error returned by the API server
+func isNetworkError(err error) bool {
+	switch err.(type) {
+	case *url.Error, net.Error:
+		return true
+	}
+	return utilnet.IsConnectionReset(err) || utilnet.IsProbableEOF(err)
+}
+
+// healthClient is the client of a cluster returning a
+// ClusterUnavailableError when the API server is unreachable. The failure is
+// recorded as the result of the last probe, so that the cluster is reported
+// unavailable until the next probe succeeds.
+type healthClient struct {
+	kubernetes.Client
+	factory   *clusterFactory
+	cluster   *cluster
+	clusterID string
+}
+
+func (h *healthClient) check(err error) error {
+	if err == nil || !isNetworkError(err) {
+		return err
+	}
+	if h.cluster.fail(err) {
+		log.Error(err, "cluster is unavailable", "clusterID", h.clusterID)
+		h.factory.setReady(h.clusterID, err)
+	}
+	return &ClusterUnavailableError{ClusterID: h.clusterID, Err: err}
+}
+
+func (h *healthClient) Get(ctx context.Context, key kubernetes.ObjectKey, obj runtime.Object) error {
+	return h.check(h.Client.Get(ctx, key, obj))
+}
+
+func (h *healthClient) List(ctx context.Context, opts *kubernetes.ListOptions, list runtime.Object) error {
+	return h.check(h.Client.List(ctx, opts, list))
+}
+
+func (h *healthClient) Create(ctx context.Context, obj runtime.Object) error {
+	return h.check(h.Client.Create(ctx, obj))
+}
+
+func (h *healthClient) Delete(ctx context.Context, obj runtime.Object, opts ...kubernetes.DeleteOptionFunc) error {
+	return h.check(h.Client.Delete(ctx, obj, opts...))
+}
+
+func (h *healthClient) Update(ctx context.Context, obj runtime.Object) error {
+	return h.check(h.Client.Update(ctx, obj))
+}
+
+func (h *healthClient) Status() kubernetes.StatusWriter {
+	return &healthStatusWriter{StatusWriter: h.Client.Status(), client: h}
+}
+
+type healthStatusWriter struct {
+	kubernetes.StatusWriter
+	client *healthClient
+}
+
+func (w *healthStatusWriter) Update(ctx context.Context, obj runtime.Object) error {
+	return w.client.check(w.StatusWriter.Update(ctx, obj))
+}
+
+// setReady sets the Ready condition of the SFCluster. Failures are only
+// logged, the condition is set again on the next change.
+func (f *clusterFactory) setReady(clusterID string, probeErr error) {
+	condition := osbv1alpha1.Condition{
+		Type:   osbv1alpha1.ClusterReady,
+		Status: osbv1alpha1.ConditionTrue,
+		Reason: "APIServerReachable",
+	}
+	if probeErr != nil {
+		condition.Status = osbv1alpha1.ConditionFalse
+		condition.Reason = "APIServerUnreachable"
+		condition.Message = probeErr.Error()
+	}
+
+	client := f.mgr.GetClient()
+	cluster := &osbv1alpha1.SFCluster{}
+	err := client.Get(context.TODO(), types.NamespacedName{
+		Name:      clusterID,
+		Namespace: clusterNamespace,
+	}, cluster)
+	if err != nil {
+		log.Error(err, "unable to get cluster", "clusterID", clusterID)
+		return
+	}
+	if !osbv1alpha1.SetCondition(&cluster.Status.Conditions, condition) {
+		return
+	}
+	if err := client.Update(context.TODO(), cluster); err != nil {
+		log.Error(err, "unable to set Ready condition of cluster", "clusterID", clusterID)
+	}
+}
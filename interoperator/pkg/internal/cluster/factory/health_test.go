@@ -0,0 +1,118 @@
+package factory
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	osbv1alpha1 "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis/osb/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/version"
+	kubernetes "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type fakeProbe struct {
+	calls int
+	err   error
+}
+
+func (p *fakeProbe) ServerVersion() (*version.Info, error) {
+	p.calls++
+	return &version.Info{}, p.err
+}
+
+func Test_cluster_probe(t *testing.T) {
+	p := &fakeProbe{}
+	c := &cluster{discovery: p}
+
+	if changed, err := c.probe(time.Hour); !changed || err != nil {
+		t.Errorf("probe() = %v, %v, want true, nil", changed, err)
+	}
+	p.err = fmt.Errorf("connection refused")
+	if changed, err := c.probe(time.Hour); changed || err != nil || p.calls != 1 {
+		t.Errorf("probe() within interval = %v, %v, calls %d, want false, nil, 1", changed, err, p.calls)
+	}
+	if changed, err := c.probe(0); !changed || err != p.err {
+		t.Errorf("probe() = %v, %v, want true, %v", changed, err, p.err)
+	}
+	if changed, err := c.probe(0); changed || err != p.err {
+		t.Errorf("probe() = %v, %v, want false, %v", changed, err, p.err)
+	}
+}
+
+func TestIsClusterUnavailable(t *testing.T) {
+	if !IsClusterUnavailable(&ClusterUnavailableError{ClusterID: "cluster-1"}) {
+		t.Errorf("IsClusterUnavailable() = false, want true")
+	}
+	if IsClusterUnavailable(fmt.Errorf("cluster-1")) {
+		t.Errorf("IsClusterUnavailable() = true, want false")
+	}
+}
+
+// unreachableClient fails the requests of the secret "unreachable" with a
+// network error
+type unreachableClient struct {
+	kubernetes.Client
+}
+
+func (c *unreachableClient) Get(ctx context.Context, key kubernetes.ObjectKey, obj runtime.Object) error {
+	if key.Name == "unreachable" {
+		return &url.Error{Op: "Get", URL: "https://cluster-id", Err: fmt.Errorf("connection refused")}
+	}
+	return c.Client.Get(ctx, key, obj)
+}
+
+func Test_healthClient(t *testing.T) {
+	mgr := newFakeManager(t)
+	f := &clusterFactory{mgr: mgr}
+	c := &cluster{}
+	h := &healthClient{
+		Client:    &unreachableClient{Client: mgr.GetClient()},
+		factory:   f,
+		cluster:   c,
+		clusterID: "cluster-id",
+	}
+
+	err := h.Get(context.TODO(), types.NamespacedName{Name: "missing", Namespace: "default"}, &corev1.Secret{})
+	if !apiErrors.IsNotFound(err) {
+		t.Errorf("Get() error = %v, want NotFound", err)
+	}
+	if !c.probed.IsZero() {
+		t.Errorf("Get() recorded the error of the API server as a probe")
+	}
+
+	err = h.Get(context.TODO(), types.NamespacedName{Name: "unreachable", Namespace: "default"}, &corev1.Secret{})
+	if !IsClusterUnavailable(err) {
+		t.Fatalf("Get() error = %v, want ClusterUnavailableError", err)
+	}
+	if c.probeErr == nil {
+		t.Errorf("Get() did not record the failure as a probe")
+	}
+	if changed, err := c.probe(time.Hour); changed || err == nil {
+		t.Errorf("probe() = %v, %v, want false, error", changed, err)
+	}
+
+	sfcluster := &osbv1alpha1.SFCluster{}
+	if err := mgr.GetClient().Get(context.TODO(), types.NamespacedName{Name: "cluster-id", Namespace: "default"}, sfcluster); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	condition := osbv1alpha1.GetCondition(sfcluster.Status.Conditions, osbv1alpha1.ClusterReady)
+	if condition == nil || condition.Status != osbv1alpha1.ConditionFalse {
+		t.Errorf("Ready condition = %v, want False", condition)
+	}
+}
+
+func Test_isNetworkError(t *testing.T) {
+	if !isNetworkError(&url.Error{Op: "Get", URL: "https://cluster-id", Err: fmt.Errorf("i/o timeout")}) {
+		t.Errorf("isNetworkError() = false, want true")
+	}
+	if isNetworkError(apiErrors.NewNotFound(corev1.Resource("secrets"), "secret")) {
+		t.Errorf("isNetworkError() = true, want false")
+	}
+}
@@ -6,6 +6,7 @@ package mock_factory
 
 import (
 	gomock "github.com/golang/mock/gomock"
+	rest "k8s.io/client-go/rest"
 	reflect "reflect"
 	client "sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -34,16 +35,47 @@ func (m *MockClusterFactory) EXPECT() *MockClusterFactoryMockRecorder {
 }
 
 // GetCluster mocks base method
-func (m *MockClusterFactory) GetCluster(instanceID, bindingID, serviceID, planID string) (client.Client, error) {
+func (m *MockClusterFactory) GetCluster(instanceID, bindingID, serviceID, planID, namespace string) (client.Client, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetCluster", instanceID, bindingID, serviceID, planID)
+	ret := m.ctrl.Call(m, "GetCluster", instanceID, bindingID, serviceID, planID, namespace)
 	ret0, _ := ret[0].(client.Client)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetCluster indicates an expected call of GetCluster
-func (mr *MockClusterFactoryMockRecorder) GetCluster(instanceID, bindingID, serviceID, planID interface{}) *gomock.Call {
+func (mr *MockClusterFactoryMockRecorder) GetCluster(instanceID, bindingID, serviceID, planID, namespace interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCluster", reflect.TypeOf((*MockClusterFactory)(nil).GetCluster), instanceID, bindingID, serviceID, planID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCluster", reflect.TypeOf((*MockClusterFactory)(nil).GetCluster), instanceID, bindingID, serviceID, planID, namespace)
+}
+
+// GetClusterClient mocks base method
+func (m *MockClusterFactory) GetClusterClient(clusterID string) (client.Client, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetClusterClient", clusterID)
+	ret0, _ := ret[0].(client.Client)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetClusterClient indicates an expected call of GetClusterClient
+func (mr *MockClusterFactoryMockRecorder) GetClusterClient(clusterID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClusterClient", reflect.TypeOf((*MockClusterFactory)(nil).GetClusterClient), clusterID)
+}
+
+// GetClusterRESTConfig mocks base method
+func (m *MockClusterFactory) GetClusterRESTConfig(clusterID string) (*rest.Config, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetClusterRESTConfig", clusterID)
+	ret0, _ := ret[0].(*rest.Config)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetClusterRESTConfig indicates an expected call of GetClusterRESTConfig
+func (mr *MockClusterFactoryMockRecorder) GetClusterRESTConfig(clusterID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClusterRESTConfig", reflect.TypeOf((*MockClusterFactory)(nil).GetClusterRESTConfig), clusterID)
 }
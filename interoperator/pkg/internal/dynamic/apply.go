@@ -0,0 +1,122 @@
+package dynamic
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/jsonmergepatch"
+	"k8s.io/apimachinery/pkg/util/mergepatch"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// LastAppliedConfigAnnotation is the annotation storing the configuration
+// last applied by the interoperator on a resource. It is used to compute
+// the fields removed from the templates.
+const LastAppliedConfigAnnotation = "interoperator.servicefabrik.io/last-applied-configuration"
+
+// SetLastAppliedConfiguration stores the configuration of the object in its
+// LastAppliedConfigAnnotation and returns the object, annotation included,
+// serialized as json
+func SetLastAppliedConfiguration(obj *unstructured.Unstructured) ([]byte, error) {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	delete(annotations, LastAppliedConfigAnnotation)
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+	obj.SetAnnotations(annotations)
+
+	config, err := json.Marshal(obj.Object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize %s %s. %v", obj.GetKind(), obj.GetName(), err)
+	}
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[LastAppliedConfigAnnotation] = string(config)
+	obj.SetAnnotations(annotations)
+
+	return json.Marshal(obj.Object)
+}
+
+// ThreeWayMerge applies the expected configuration on the current state of a
+// resource. Fields set in expected are overwritten, fields present in the
+// last applied configuration of current but not in expected are removed and
+// all the other fields of current, e.g. set by other controllers, are kept.
+// Lists and maps of the kubernetes built-in types are merged with strategic
+// merge patch, while for other types lists are replaced as a whole.
+// It returns the merged resource and true if it differs from current.
+// The LastAppliedConfigAnnotation of expected is set.
+func ThreeWayMerge(current, expected *unstructured.Unstructured) (*unstructured.Unstructured, bool, error) {
+	kind := current.GetKind()
+	name := current.GetName()
+
+	modified, err := SetLastAppliedConfiguration(expected)
+	if err != nil {
+		return nil, false, err
+	}
+	var original []byte
+	if lastApplied, ok := current.GetAnnotations()[LastAppliedConfigAnnotation]; ok {
+		original = []byte(lastApplied)
+	}
+	currentJSON, err := json.Marshal(current.Object)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to serialize %s %s. %v", kind, name, err)
+	}
+
+	var patch, merged []byte
+	versionedObject, schemeErr := scheme.Scheme.New(current.GroupVersionKind())
+	if schemeErr == nil {
+		var lookupPatchMeta strategicpatch.LookupPatchMeta
+		lookupPatchMeta, err = strategicpatch.NewPatchMetaFromStruct(versionedObject)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to get patch metadata of %s %s. %v", kind, name, err)
+		}
+		patch, err = strategicpatch.CreateThreeWayMergePatch(original, modified, currentJSON, lookupPatchMeta, true)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to compute patch for %s %s. %v", kind, name, err)
+		}
+		merged, err = strategicpatch.StrategicMergePatchUsingLookupPatchMeta(currentJSON, patch, lookupPatchMeta)
+	} else {
+		preconditions := []mergepatch.PreconditionFunc{
+			mergepatch.RequireKeyUnchanged("apiVersion"),
+			mergepatch.RequireKeyUnchanged("kind"),
+			mergepatch.RequireMetadataKeyUnchanged("name"),
+		}
+		patch, err = jsonmergepatch.CreateThreeWayJSONMergePatch(original, modified, currentJSON, preconditions...)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to compute patch for %s %s. %v", kind, name, err)
+		}
+		merged, err = jsonpatch.MergePatch(currentJSON, patch)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to apply patch on %s %s. %v", kind, name, err)
+	}
+
+	mergedObject := &unstructured.Unstructured{}
+	err = mergedObject.UnmarshalJSON(merged)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to deserialize %s %s. %v", kind, name, err)
+	}
+	// Compare the decoded objects, the integers of current may not have
+	// the same type as the decoded ones
+	if reflect.DeepEqual(mergedObject.Object, normalize(currentJSON)) {
+		return current, false, nil
+	}
+	return mergedObject, true, nil
+}
+
+// normalize decodes the json serialized object the same way as the merged one
+func normalize(content []byte) map[string]interface{} {
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(content); err != nil {
+		return nil
+	}
+	return obj.Object
+}
@@ -0,0 +1,204 @@
+package dynamic
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestThreeWayMerge(t *testing.T) {
+	toUnstructured := func(content string) *unstructured.Unstructured {
+		objects, err := StringToUnstructured(content)
+		if err != nil {
+			t.Fatalf("StringToUnstructured() error = %v", err)
+		}
+		return objects[0]
+	}
+	// applied returns the object as created with the configuration
+	applied := func(content string) *unstructured.Unstructured {
+		obj := toUnstructured(content)
+		if _, err := SetLastAppliedConfiguration(obj); err != nil {
+			t.Fatalf("SetLastAppliedConfiguration() error = %v", err)
+		}
+		return obj
+	}
+	// appliedWith returns the live object with the configuration applied
+	// last, fields set by other controllers included
+	appliedWith := func(config string, live string) *unstructured.Unstructured {
+		obj := toUnstructured(live)
+		obj.SetAnnotations(applied(config).GetAnnotations())
+		return obj
+	}
+	withField := func(obj *unstructured.Unstructured, value interface{}, fields ...string) *unstructured.Unstructured {
+		if err := unstructured.SetNestedField(obj.Object, value, fields...); err != nil {
+			t.Fatalf("SetNestedField() error = %v", err)
+		}
+		return obj
+	}
+
+	director := `apiVersion: deployment.servicefabrik.io/v1alpha1
+kind: Director
+metadata:
+  name: instance-id
+  namespace: default
+spec:
+  options: hello
+  tags:
+  - foo
+  - bar`
+	directorWithoutTags := `apiVersion: deployment.servicefabrik.io/v1alpha1
+kind: Director
+metadata:
+  name: instance-id
+  namespace: default
+spec:
+  options: hello`
+	directorWithMoreTags := `apiVersion: deployment.servicefabrik.io/v1alpha1
+kind: Director
+metadata:
+  name: instance-id
+  namespace: default
+spec:
+  options: hello
+  tags:
+  - foo
+  - bar
+  - baz`
+	deployment := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: instance-id
+  namespace: default
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: main
+        image: postgres:10`
+	deploymentLive := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: instance-id
+  namespace: default
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: main
+        image: postgres:10
+        imagePullPolicy: IfNotPresent`
+	deploymentUpdated := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: instance-id
+  namespace: default
+spec:
+  template:
+    spec:
+      containers:
+      - name: main
+        image: postgres:11`
+
+	tests := []struct {
+		name      string
+		current   *unstructured.Unstructured
+		expected  string
+		fields    []string
+		want      interface{}
+		wantFound bool
+		want1     bool
+	}{
+		{
+			name:      "unchanged resource is not updated",
+			current:   withField(applied(director), "in_progress", "status", "state"),
+			expected:  director,
+			fields:    []string{"spec", "options"},
+			want:      "hello",
+			wantFound: true,
+			want1:     false,
+		},
+		{
+			name:      "field dropped from the template is removed",
+			current:   applied(director),
+			expected:  directorWithoutTags,
+			fields:    []string{"spec", "tags"},
+			wantFound: false,
+			want1:     true,
+		},
+		{
+			name:      "field set by another controller is kept",
+			current:   withField(applied(director), "in_progress", "status", "state"),
+			expected:  directorWithoutTags,
+			fields:    []string{"status", "state"},
+			want:      "in_progress",
+			wantFound: true,
+			want1:     true,
+		},
+		{
+			name:      "list longer than the live list is applied",
+			current:   applied(director),
+			expected:  directorWithMoreTags,
+			fields:    []string{"spec", "tags"},
+			want:      []interface{}{"foo", "bar", "baz"},
+			wantFound: true,
+			want1:     true,
+		},
+		{
+			name:      "resource without last applied configuration is not pruned",
+			current:   toUnstructured(director),
+			expected:  directorWithoutTags,
+			fields:    []string{"spec", "tags"},
+			want:      []interface{}{"foo", "bar"},
+			wantFound: true,
+			want1:     true,
+		},
+		{
+			name:     "containers of built-in types are merged by name",
+			current:  appliedWith(deployment, deploymentLive),
+			expected: deploymentUpdated,
+			fields:   []string{"spec", "template", "spec", "containers"},
+			want: []interface{}{
+				map[string]interface{}{
+					"name":            "main",
+					"image":           "postgres:11",
+					"imagePullPolicy": "IfNotPresent",
+				},
+			},
+			wantFound: true,
+			want1:     true,
+		},
+		{
+			name:      "field of built-in type dropped from the template is removed",
+			current:   appliedWith(deployment, deploymentLive),
+			expected:  deploymentUpdated,
+			fields:    []string{"spec", "replicas"},
+			wantFound: false,
+			want1:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, got1, err := ThreeWayMerge(tt.current, toUnstructured(tt.expected))
+			if err != nil {
+				t.Errorf("ThreeWayMerge() error = %v", err)
+				return
+			}
+			if got1 != tt.want1 {
+				t.Errorf("ThreeWayMerge() got1 = %v, want %v", got1, tt.want1)
+			}
+			value, found, _ := unstructured.NestedFieldCopy(got.Object, tt.fields...)
+			if found != tt.wantFound {
+				t.Errorf("ThreeWayMerge() %v found = %v, want %v", tt.fields, found, tt.wantFound)
+			}
+			if found && !reflect.DeepEqual(value, tt.want) {
+				t.Errorf("ThreeWayMerge() %v = %v, want %v", tt.fields, value, tt.want)
+			}
+			if _, ok := got.GetAnnotations()[LastAppliedConfigAnnotation]; !ok {
+				t.Errorf("ThreeWayMerge() annotation %s not set", LastAppliedConfigAnnotation)
+			}
+		})
+	}
+}
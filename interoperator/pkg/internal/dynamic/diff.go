@@ -0,0 +1,89 @@
+package dynamic
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Diff returns the fields changed from current to modified, one per line.
+// Added fields are prefixed with +, removed fields with - and changed fields
+// with ~. The LastAppliedConfigAnnotation is ignored.
+func Diff(current, modified map[string]interface{}) string {
+	var lines []string
+	diffValues(&lines, "", current, modified)
+	return strings.Join(lines, "\n")
+}
+
+func diffValues(lines *[]string, path string, current, modified interface{}) {
+	if path == "metadata.annotations."+LastAppliedConfigAnnotation {
+		return
+	}
+	if reflect.DeepEqual(normalizeValue(current), normalizeValue(modified)) {
+		return
+	}
+	switch {
+	case current == nil:
+		*lines = append(*lines, fmt.Sprintf("+ %s: %s", path, formatValue(modified)))
+	case modified == nil:
+		*lines = append(*lines, fmt.Sprintf("- %s", path))
+	default:
+		currentMap, ok1 := current.(map[string]interface{})
+		modifiedMap, ok2 := modified.(map[string]interface{})
+		if ok1 && ok2 {
+			keys := make(map[string]struct{})
+			for key := range currentMap {
+				keys[key] = struct{}{}
+			}
+			for key := range modifiedMap {
+				keys[key] = struct{}{}
+			}
+			sortedKeys := make([]string, 0, len(keys))
+			for key := range keys {
+				sortedKeys = append(sortedKeys, key)
+			}
+			sort.Strings(sortedKeys)
+			for _, key := range sortedKeys {
+				fieldPath := key
+				if path != "" {
+					fieldPath = path + "." + key
+				}
+				diffValues(lines, fieldPath, currentMap[key], modifiedMap[key])
+			}
+			return
+		}
+		currentList, ok1 := current.([]interface{})
+		modifiedList, ok2 := modified.([]interface{})
+		if ok1 && ok2 && len(currentList) == len(modifiedList) {
+			for i := range currentList {
+				diffValues(lines, fmt.Sprintf("%s[%d]", path, i), currentList[i], modifiedList[i])
+			}
+			return
+		}
+		*lines = append(*lines, fmt.Sprintf("~ %s: %s -> %s", path, formatValue(current), formatValue(modified)))
+	}
+}
+
+// normalizeValue converts the value to its json representation, so that
+// numbers compare equal regardless of their type
+func normalizeValue(value interface{}) interface{} {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(content, &normalized); err != nil {
+		return value
+	}
+	return normalized
+}
+
+func formatValue(value interface{}) string {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(content)
+}
@@ -0,0 +1,92 @@
+package dynamic
+
+import (
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	type args struct {
+		current  map[string]interface{}
+		modified map[string]interface{}
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "equal objects",
+			args: args{
+				current:  map[string]interface{}{"spec": map[string]interface{}{"replicas": int64(1)}},
+				modified: map[string]interface{}{"spec": map[string]interface{}{"replicas": 1}},
+			},
+			want: "",
+		},
+		{
+			name: "added, removed and changed fields",
+			args: args{
+				current: map[string]interface{}{
+					"spec": map[string]interface{}{
+						"replicas": int64(1),
+						"image":    "postgres:10",
+					},
+				},
+				modified: map[string]interface{}{
+					"spec": map[string]interface{}{
+						"replicas": int64(3),
+						"labels":   map[string]interface{}{"app": "db"},
+					},
+				},
+			},
+			want: "- spec.image\n+ spec.labels: {\"app\":\"db\"}\n~ spec.replicas: 1 -> 3",
+		},
+		{
+			name: "lists",
+			args: args{
+				current: map[string]interface{}{
+					"containers": []interface{}{map[string]interface{}{"name": "main", "image": "postgres:10"}},
+					"tags":       []interface{}{"foo"},
+				},
+				modified: map[string]interface{}{
+					"containers": []interface{}{map[string]interface{}{"name": "main", "image": "postgres:11"}},
+					"tags":       []interface{}{"foo", "bar"},
+				},
+			},
+			want: "~ containers[0].image: \"postgres:10\" -> \"postgres:11\"\n~ tags: [\"foo\"] -> [\"foo\",\"bar\"]",
+		},
+		{
+			name: "new object",
+			args: args{
+				current: nil,
+				modified: map[string]interface{}{
+					"kind":     "ConfigMap",
+					"metadata": map[string]interface{}{"name": "config"},
+				},
+			},
+			want: "+ kind: \"ConfigMap\"\n+ metadata: {\"name\":\"config\"}",
+		},
+		{
+			name: "last applied configuration is ignored",
+			args: args{
+				current: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"annotations": map[string]interface{}{LastAppliedConfigAnnotation: "{}"},
+					},
+				},
+				modified: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"annotations": map[string]interface{}{LastAppliedConfigAnnotation: "{\"data\":{}}"},
+					},
+				},
+			},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff(tt.args.current, tt.args.modified); got != tt.want {
+				t.Errorf("Diff() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
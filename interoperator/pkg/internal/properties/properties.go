@@ -30,6 +30,9 @@ type Status struct {
 	Bind        GenericStatus  `yaml:"bind" json:"bind"`
 	Unbind      GenericStatus  `yaml:"unbind" json:"unbind"`
 	Deprovision GenericStatus  `yaml:"deprovision" json:"deprovision"`
+	// Sources are the resources listed in sources.yaml, the status is
+	// computed from them
+	Sources []osbv1alpha1.Source `yaml:"-" json:"-"`
 }
 
 // ParseSources decodes sources yaml into a map
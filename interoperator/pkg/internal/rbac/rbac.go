@@ -0,0 +1,282 @@
+package rbac
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	osbv1alpha1 "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis/osb/v1alpha1"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/render"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached"
+	"k8s.io/client-go/restmapper"
+	kubernetes "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// sampleName is the name of the instance and binding the templates of a
+// plan are rendered for to compute the kinds they use
+const sampleName = "interoperator-rbac-check"
+
+// RequiredVerbs are the verbs the interoperator uses on the resources
+// rendered by the plans
+var RequiredVerbs = []string{"get", "list", "watch", "create", "update", "patch", "delete"}
+
+// UnknownKindsError is returned when the templates of a plan can not be
+// rendered for the sample instance, for example because they need
+// parameters without defaults. The kinds rendered by these templates are
+// unknown.
+type UnknownKindsError struct {
+	messages []string
+}
+
+func (e *UnknownKindsError) Error() string {
+	return fmt.Sprintf("kinds rendered by the plan unknown. %s", strings.Join(e.messages, ", "))
+}
+
+// IsUnknownKinds returns true if <err> is a UnknownKindsError
+func IsUnknownKinds(err error) bool {
+	_, ok := err.(*UnknownKindsError)
+	return ok
+}
+
+// Checker computes the permissions the interoperator lacks on the kinds
+// rendered by the plans
+type Checker interface {
+	MissingPermissions(gvks []schema.GroupVersionKind) ([]osbv1alpha1.MissingPermission, error)
+}
+
+type checker struct {
+	client kubernetes.Client
+	mapper meta.RESTMapper
+}
+
+// New returns a new Checker reviewing the permissions of the interoperator
+// in the cluster of the provided manager
+func New(mgr manager.Manager) (Checker, error) {
+	if mgr == nil {
+		return nil, fmt.Errorf("invalid input to new rbac checker")
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(mgr.GetConfig())
+	if err != nil {
+		return nil, err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(cached.NewMemCacheClient(discoveryClient))
+	return NewChecker(mgr.GetClient(), mapper), nil
+}
+
+// NewChecker returns a new Checker using the client and the mapper
+func NewChecker(client kubernetes.Client, mapper meta.RESTMapper) Checker {
+	return &checker{
+		client: client,
+		mapper: mapper,
+	}
+}
+
+// MissingPermissions returns the RequiredVerbs the interoperator is not
+// allowed on the resources of the kinds in all namespaces. Kinds not served
+// by the cluster are reported without verbs.
+func (c *checker) MissingPermissions(gvks []schema.GroupVersionKind) ([]osbv1alpha1.MissingPermission, error) {
+	var missingPermissions []osbv1alpha1.MissingPermission
+	for _, gvk := range uniqueKinds(gvks) {
+		apiVersion, kind := gvk.ToAPIVersionAndKind()
+		missingPermission := osbv1alpha1.MissingPermission{
+			APIVersion: apiVersion,
+			Kind:       kind,
+		}
+		mapping, err := c.restMapping(gvk)
+		if err != nil {
+			missingPermission.Message = err.Error()
+			missingPermissions = append(missingPermissions, missingPermission)
+			continue
+		}
+		missingPermission.Resource = mapping.Resource.Resource
+		for _, verb := range RequiredVerbs {
+			review := &authorizationv1.SelfSubjectAccessReview{
+				Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+					ResourceAttributes: &authorizationv1.ResourceAttributes{
+						Namespace: metav1.NamespaceAll,
+						Verb:      verb,
+						Group:     mapping.Resource.Group,
+						Version:   mapping.Resource.Version,
+						Resource:  mapping.Resource.Resource,
+					},
+				},
+			}
+			err := c.client.Create(context.TODO(), review)
+			if err != nil {
+				return nil, err
+			}
+			if !review.Status.Allowed {
+				missingPermission.Verbs = append(missingPermission.Verbs, verb)
+			}
+		}
+		if len(missingPermission.Verbs) > 0 {
+			missingPermissions = append(missingPermissions, missingPermission)
+		}
+	}
+	return missingPermissions, nil
+}
+
+// restMapping maps the kind to its resource. The discovery information is
+// refreshed once if the kind is not found, as its custom resource
+// definition may have been created since.
+func (c *checker) restMapping(gvk schema.GroupVersionKind) (*meta.RESTMapping, error) {
+	mapping, err := c.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		if resettable, ok := c.mapper.(interface{ Reset() }); ok {
+			resettable.Reset()
+			mapping, err = c.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		}
+	}
+	return mapping, err
+}
+
+// Kinds returns the kinds of the resources rendered by the templates of the
+// plan and listed in its sources template. The templates are rendered for a
+// sample instance, and a sample binding if the plan is bindable, with the
+// defaults of the parameter schemas of the plan. The kinds of the templates
+// which can not be rendered are skipped and reported in a
+// UnknownKindsError.
+func Kinds(service *osbv1alpha1.SFService, plan *osbv1alpha1.SFPlan) ([]schema.GroupVersionKind, error) {
+	var instanceSchema, bindingSchema *runtime.RawExtension
+	if plan.Spec.Schemas != nil {
+		instanceSchema = plan.Spec.Schemas.Instance.Create.Parameters
+		bindingSchema = plan.Spec.Schemas.Binding.Create.Parameters
+	}
+	objects := &render.Objects{
+		Service: service,
+		Plan:    plan,
+		Instance: &osbv1alpha1.SFServiceInstance{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      sampleName,
+				Namespace: plan.GetNamespace(),
+			},
+			Spec: osbv1alpha1.SFServiceInstanceSpec{
+				ServiceID:     service.Spec.ID,
+				PlanID:        plan.Spec.ID,
+				RawParameters: sampleParameters(instanceSchema),
+			},
+		},
+	}
+	actions := []string{osbv1alpha1.ProvisionAction}
+	if plan.Spec.Bindable {
+		objects.Binding = &osbv1alpha1.SFServiceBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      sampleName,
+				Namespace: plan.GetNamespace(),
+			},
+			Spec: osbv1alpha1.SFServiceBindingSpec{
+				ServiceID:     service.Spec.ID,
+				PlanID:        plan.Spec.ID,
+				InstanceID:    sampleName,
+				ID:            sampleName,
+				RawParameters: sampleParameters(bindingSchema),
+			},
+		}
+		actions = append(actions, osbv1alpha1.BindAction)
+	}
+
+	var gvks []schema.GroupVersionKind
+	var unknown []string
+	for _, action := range actions {
+		if _, err := plan.GetTemplate(action); err == nil {
+			resources, err := render.ExpectedResources(objects, action)
+			if err != nil {
+				unknown = append(unknown, fmt.Sprintf("failed to render %s template. %v", action, err))
+			}
+			for _, resource := range resources {
+				gvks = append(gvks, resource.GroupVersionKind())
+			}
+		}
+		if _, err := plan.GetTemplate(osbv1alpha1.SourcesAction); err == nil {
+			sources, err := render.Sources(objects, action)
+			if err != nil {
+				unknown = append(unknown, fmt.Sprintf("failed to render %s template for %s. %v", osbv1alpha1.SourcesAction, action, err))
+			}
+			for _, source := range sources {
+				gvks = append(gvks, schema.FromAPIVersionAndKind(source.APIVersion, source.Kind))
+			}
+		}
+	}
+	if len(unknown) > 0 {
+		return uniqueKinds(gvks), &UnknownKindsError{messages: unknown}
+	}
+	return uniqueKinds(gvks), nil
+}
+
+// sampleParameters returns the parameters set to the defaults of the
+// properties of the schema, or nil if the schema has no defaults
+func sampleParameters(parametersSchema *runtime.RawExtension) *runtime.RawExtension {
+	if parametersSchema == nil || len(parametersSchema.Raw) == 0 {
+		return nil
+	}
+	var s interface{}
+	if err := json.Unmarshal(parametersSchema.Raw, &s); err != nil {
+		return nil
+	}
+	parameters, ok := schemaDefault(s).(map[string]interface{})
+	if !ok || len(parameters) == 0 {
+		return nil
+	}
+	raw, err := json.Marshal(parameters)
+	if err != nil {
+		return nil
+	}
+	return &runtime.RawExtension{Raw: raw}
+}
+
+// schemaDefault returns the default of the schema, or the defaults of its
+// properties for an object schema without default
+func schemaDefault(s interface{}) interface{} {
+	schemaMap, ok := s.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	if value, ok := schemaMap["default"]; ok {
+		return value
+	}
+	properties, ok := schemaMap["properties"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	values := make(map[string]interface{})
+	for name, property := range properties {
+		if value := schemaDefault(property); value != nil {
+			values[name] = value
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
+
+// uniqueKinds returns the kinds without duplicates and empty kinds, sorted
+// by their string representation
+func uniqueKinds(gvks []schema.GroupVersionKind) []schema.GroupVersionKind {
+	seen := make(map[schema.GroupVersionKind]struct{}, len(gvks))
+	result := make([]schema.GroupVersionKind, 0, len(gvks))
+	for _, gvk := range gvks {
+		if gvk.Kind == "" {
+			continue
+		}
+		if _, ok := seen[gvk]; ok {
+			continue
+		}
+		seen[gvk] = struct{}{}
+		result = append(result, gvk)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].String() < result[j].String()
+	})
+	return result
+}
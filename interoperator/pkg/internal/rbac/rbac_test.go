@@ -0,0 +1,209 @@
+package rbac
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	osbv1alpha1 "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis/osb/v1alpha1"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	kubernetes "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var (
+	configMap = schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+	secret    = schema.GroupVersionKind{Version: "v1", Kind: "Secret"}
+	director  = schema.GroupVersionKind{Group: "deployment.servicefabrik.io", Version: "v1alpha1", Kind: "Director"}
+)
+
+// reviewClient allows the verbs of the resources in <allowed>
+type reviewClient struct {
+	kubernetes.Client
+	allowed map[string][]string
+}
+
+func (c *reviewClient) Create(ctx context.Context, obj runtime.Object) error {
+	if review, ok := obj.(*authorizationv1.SelfSubjectAccessReview); ok {
+		attributes := review.Spec.ResourceAttributes
+		review.Status.Allowed = containsString(c.allowed[attributes.Resource], attributes.Verb)
+		return nil
+	}
+	return c.Client.Create(ctx, obj)
+}
+
+func containsString(slice []string, s string) bool {
+	for _, item := range slice {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func Test_checker_MissingPermissions(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{})
+	mapper.Add(configMap, meta.RESTScopeNamespace)
+	mapper.Add(secret, meta.RESTScopeNamespace)
+	client := &reviewClient{
+		Client: fake.NewFakeClientWithScheme(scheme.Scheme),
+		allowed: map[string][]string{
+			"configmaps": RequiredVerbs,
+			"secrets":    []string{"get", "list", "watch"},
+		},
+	}
+	c := NewChecker(client, mapper)
+
+	got, err := c.MissingPermissions([]schema.GroupVersionKind{configMap, secret, director, secret})
+	if err != nil {
+		t.Fatalf("checker.MissingPermissions() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("checker.MissingPermissions() = %v, want 2 kinds", got)
+	}
+	want := osbv1alpha1.MissingPermission{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Resource:   "secrets",
+		Verbs:      []string{"create", "update", "patch", "delete"},
+	}
+	if !reflect.DeepEqual(got[0], want) {
+		t.Errorf("checker.MissingPermissions()[0] = %v, want %v", got[0], want)
+	}
+	if got[1].Kind != "Director" || got[1].Message == "" || got[1].Verbs != nil {
+		t.Errorf("checker.MissingPermissions()[1] = %v, want unknown Director", got[1])
+	}
+}
+
+func TestKinds(t *testing.T) {
+	service := &osbv1alpha1.SFService{
+		ObjectMeta: metav1.ObjectMeta{Name: "service-id", Namespace: "default"},
+		Spec:       osbv1alpha1.SFServiceSpec{ID: "service-id"},
+	}
+	plan := &osbv1alpha1.SFPlan{
+		ObjectMeta: metav1.ObjectMeta{Name: "plan-id", Namespace: "default"},
+		Spec: osbv1alpha1.SFPlanSpec{
+			ID:        "plan-id",
+			ServiceID: "service-id",
+			Bindable:  true,
+			Templates: []osbv1alpha1.TemplateSpec{
+				{
+					Action: osbv1alpha1.ProvisionAction,
+					Type:   "gotemplate",
+					Content: `apiVersion: deployment.servicefabrik.io/v1alpha1
+kind: Director
+metadata:
+  name: {{ .instance.metadata.name }}
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .instance.metadata.name }}`,
+				},
+				{
+					Action: osbv1alpha1.BindAction,
+					Type:   "gotemplate",
+					Content: `apiVersion: v1
+kind: Secret
+metadata:
+  name: {{ .binding.metadata.name }}`,
+				},
+				{
+					Action: osbv1alpha1.SourcesAction,
+					Type:   "gotemplate",
+					Content: `config:
+  apiVersion: v1
+  kind: ConfigMap
+  name: {{ .instance.metadata.name }}`,
+				},
+			},
+		},
+	}
+
+	got, err := Kinds(service, plan)
+	if err != nil {
+		t.Fatalf("Kinds() error = %v", err)
+	}
+	want := []schema.GroupVersionKind{configMap, secret, director}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Kinds() = %v, want %v", got, want)
+	}
+
+	// The templates are rendered with the defaults of the parameters
+	plan.Spec.Templates[1].Content = `apiVersion: v1
+kind: {{ .binding.spec.parameters.kind }}
+metadata:
+  name: {{ .binding.metadata.name }}`
+	plan.Spec.Schemas = &osbv1alpha1.ServiceSchemas{
+		Binding: osbv1alpha1.ServiceBindingSchema{
+			Create: osbv1alpha1.Schema{
+				Parameters: &runtime.RawExtension{Raw: []byte(`{"type": "object", "properties": {"kind": {"type": "string", "default": "Secret"}}}`)},
+			},
+		},
+	}
+	got, err = Kinds(service, plan)
+	if err != nil {
+		t.Fatalf("Kinds() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Kinds() = %v, want %v", got, want)
+	}
+
+	// The kinds of the templates which can not be rendered are unknown
+	plan.Spec.Templates[0].Content = `{{ fail "invalid" }}`
+	got, err = Kinds(service, plan)
+	if !IsUnknownKinds(err) {
+		t.Errorf("Kinds() error = %v, want UnknownKindsError", err)
+	}
+	want = []schema.GroupVersionKind{configMap, secret}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Kinds() = %v, want %v", got, want)
+	}
+}
+
+func Test_sampleParameters(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   string
+	}{
+		{
+			name:   "defaults of the properties",
+			schema: `{"type": "object", "properties": {"size": {"type": "integer", "default": 1}, "name": {"type": "string"}, "backup": {"type": "object", "properties": {"enabled": {"type": "boolean", "default": true}}}}}`,
+			want:   `{"backup":{"enabled":true},"size":1}`,
+		},
+		{
+			name:   "default of the schema",
+			schema: `{"type": "object", "default": {"size": 2}}`,
+			want:   `{"size":2}`,
+		},
+		{
+			name:   "no defaults",
+			schema: `{"type": "object", "properties": {"name": {"type": "string"}}}`,
+		},
+		{
+			name:   "invalid schema",
+			schema: `{`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sampleParameters(&runtime.RawExtension{Raw: []byte(tt.schema)})
+			if tt.want == "" {
+				if got != nil {
+					t.Errorf("sampleParameters() = %s, want nil", got.Raw)
+				}
+				return
+			}
+			if got == nil || string(got.Raw) != tt.want {
+				t.Errorf("sampleParameters() = %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package factory
+
+import (
+	"sync"
+
+	osbv1alpha1 "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis/osb/v1alpha1"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/renderer"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// planCache is the cache shared by the renderers of all the plans
+var planCache = newTemplateCache()
+
+// templateCache stores the parsed templates and loaded charts of the plans.
+// The entries are shared across plans with the same templates. The entries
+// of a plan are dropped when the plan changes, i.e. when a renderer is
+// requested for a different resource version of the plan, and when the
+// plan is deleted.
+type templateCache struct {
+	lock    sync.Mutex
+	entries map[string]interface{}
+	// refs counts the plans using an entry
+	refs  map[string]int
+	plans map[types.NamespacedName]*planEntries
+}
+
+type planEntries struct {
+	resourceVersion string
+	keys            map[string]struct{}
+}
+
+func newTemplateCache() *templateCache {
+	return &templateCache{
+		entries: make(map[string]interface{}),
+		refs:    make(map[string]int),
+		plans:   make(map[types.NamespacedName]*planEntries),
+	}
+}
+
+// forPlan returns the view of the cache for the plan, invalidating the
+// entries of an older version of the plan
+func (c *templateCache) forPlan(plan *osbv1alpha1.SFPlan) renderer.Cache {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	name := types.NamespacedName{
+		Namespace: plan.GetNamespace(),
+		Name:      plan.GetName(),
+	}
+	entries, ok := c.plans[name]
+	if ok && entries.resourceVersion != plan.GetResourceVersion() {
+		c.invalidate(name)
+		ok = false
+	}
+	if !ok {
+		c.plans[name] = &planEntries{
+			resourceVersion: plan.GetResourceVersion(),
+			keys:            make(map[string]struct{}),
+		}
+	}
+	return &planView{
+		cache:           c,
+		plan:            name,
+		resourceVersion: plan.GetResourceVersion(),
+	}
+}
+
+// invalidate drops the entries of the plan which are not used by other
+// plans. The caller must hold the lock.
+func (c *templateCache) invalidate(plan types.NamespacedName) {
+	entries, ok := c.plans[plan]
+	if !ok {
+		return
+	}
+	for key := range entries.keys {
+		c.refs[key]--
+		if c.refs[key] <= 0 {
+			delete(c.refs, key)
+			delete(c.entries, key)
+		}
+	}
+	delete(c.plans, plan)
+}
+
+// planView is the renderer.Cache of a version of a plan
+type planView struct {
+	cache           *templateCache
+	plan            types.NamespacedName
+	resourceVersion string
+}
+
+func (v *planView) Get(key string) (interface{}, bool) {
+	v.cache.lock.Lock()
+	defer v.cache.lock.Unlock()
+
+	value, ok := v.cache.entries[key]
+	if ok {
+		v.track(key)
+	}
+	return value, ok
+}
+
+func (v *planView) Add(key string, value interface{}) {
+	v.cache.lock.Lock()
+	defer v.cache.lock.Unlock()
+
+	if !v.track(key) {
+		// The plan changed while rendering, do not keep the entry
+		return
+	}
+	v.cache.entries[key] = value
+}
+
+// track records the entry as used by the plan. It returns false if the
+// view is outdated. The caller must hold the lock.
+func (v *planView) track(key string) bool {
+	entries, ok := v.cache.plans[v.plan]
+	if !ok || entries.resourceVersion != v.resourceVersion {
+		return false
+	}
+	if _, ok := entries.keys[key]; !ok {
+		entries.keys[key] = struct{}{}
+		v.cache.refs[key]++
+	}
+	return true
+}
+
+// InvalidateCache drops the cached templates and charts of the plan
+func InvalidateCache(plan types.NamespacedName) {
+	planCache.lock.Lock()
+	defer planCache.lock.Unlock()
+	planCache.invalidate(plan)
+}
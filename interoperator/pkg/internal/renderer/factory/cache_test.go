@@ -0,0 +1,92 @@
+package factory
+
+import (
+	"testing"
+
+	osbv1alpha1 "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis/osb/v1alpha1"
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestTemplateCache(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	cache := newTemplateCache()
+	plan := &osbv1alpha1.SFPlan{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "plan-id",
+			Namespace:       "default",
+			ResourceVersion: "1",
+		},
+	}
+	otherPlan := plan.DeepCopy()
+	otherPlan.SetName("other-plan-id")
+
+	view := cache.forPlan(plan)
+	_, ok := view.Get("key")
+	g.Expect(ok).To(gomega.BeFalse())
+	view.Add("key", "value")
+	value, ok := cache.forPlan(plan).Get("key")
+	g.Expect(ok).To(gomega.BeTrue())
+	g.Expect(value).To(gomega.Equal("value"))
+
+	// Shared with the other plan
+	otherView := cache.forPlan(otherPlan)
+	_, ok = otherView.Get("key")
+	g.Expect(ok).To(gomega.BeTrue())
+
+	// New version of the plan drops its entries, unless they are shared
+	plan.SetResourceVersion("2")
+	_, ok = cache.forPlan(plan).Get("key")
+	g.Expect(ok).To(gomega.BeTrue())
+	cache.invalidate(types.NamespacedName{Name: "other-plan-id", Namespace: "default"})
+	_, ok = cache.forPlan(plan).Get("key")
+	g.Expect(ok).To(gomega.BeTrue())
+
+	plan.SetResourceVersion("3")
+	_, ok = cache.forPlan(plan).Get("key")
+	g.Expect(ok).To(gomega.BeFalse())
+	g.Expect(cache.entries).To(gomega.BeEmpty())
+	g.Expect(cache.refs).To(gomega.BeEmpty())
+
+	// Entries added through an outdated view are not kept
+	view.Add("key", "value")
+	g.Expect(cache.entries).To(gomega.BeEmpty())
+}
+
+func TestGetCachedRenderer(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	plan := &osbv1alpha1.SFPlan{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "cached-plan-id",
+			Namespace:       "default",
+			ResourceVersion: "1",
+		},
+	}
+	name := types.NamespacedName{Name: "cached-plan-id", Namespace: "default"}
+	defer InvalidateCache(name)
+
+	template := &osbv1alpha1.TemplateSpec{
+		Action:  "provision",
+		Type:    "gotemplate",
+		Content: "{{ .instance.metadata.name }}",
+	}
+	instance := &osbv1alpha1.SFServiceInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "instance-id"},
+	}
+	for i := 0; i < 2; i++ {
+		r, err := GetCachedRenderer(template.Type, plan)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		input, err := GetRendererInput(template, nil, plan, instance, nil, name)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		output, err := r.Render(input)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(output.FileContent("main")).To(gomega.Equal("instance-id"))
+		g.Expect(planCache.plans[name].keys).To(gomega.HaveLen(1))
+	}
+
+	InvalidateCache(name)
+	g.Expect(planCache.plans).NotTo(gomega.HaveKey(name))
+}
@@ -3,28 +3,95 @@ package factory
 import (
 	"encoding/base64"
 	"fmt"
+	"sync"
 
 	osbv1alpha1 "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis/osb/v1alpha1"
 	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/dynamic"
 	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/renderer"
 	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/renderer/gotemplate"
 	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/renderer/helm"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/renderer/jsonnet"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/renderer/kustomize"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
 )
 
-// GetRenderer returns a renderer based on the type
+var (
+	clientSetLock sync.Mutex
+	// clientSet is the kubernetes client shared by the helm renderers
+	clientSet *kubernetes.Clientset
+)
+
+// getClientSet returns the kubernetes client shared by the helm renderers,
+// creating it on first use. The helm renderer creates its own client if
+// this fails, which it does not need if the capabilities are configured.
+func getClientSet() *kubernetes.Clientset {
+	clientSetLock.Lock()
+	defer clientSetLock.Unlock()
+
+	if clientSet == nil {
+		cfg, err := config.GetConfig()
+		if err != nil {
+			return nil
+		}
+		clientSet, err = kubernetes.NewForConfig(cfg)
+		if err != nil {
+			clientSet = nil
+		}
+	}
+	return clientSet
+}
+
+// GetRenderer returns a renderer based on the type. The renders are
+// bounded by the limits configured in the renderer package. The helm
+// renderer uses the shared kubernetes client if <clientSet> is nil.
 func GetRenderer(rendererType string, clientSet *kubernetes.Clientset) (renderer.Renderer, error) {
+	var r renderer.Renderer
+	var err error
 	switch rendererType {
 	case "helm", "Helm", "HELM":
-		return helm.New(clientSet)
+		if clientSet == nil {
+			clientSet = getClientSet()
+		}
+		r, err = helm.New(clientSet)
 	case "gotemplate", "Gotemplate", "GoTemplate", "GOTEMPLATE":
-		return gotemplate.New()
+		r, err = gotemplate.New()
+	case "jsonnet", "Jsonnet", "JSONNET":
+		r, err = jsonnet.New()
+	case "kustomize", "Kustomize", "KUSTOMIZE":
+		r, err = kustomize.New()
 	default:
 		return nil, fmt.Errorf("unable to create renderer for type %s. not implemented", rendererType)
 	}
+	if err != nil {
+		return nil, err
+	}
+	return renderer.NewLimitedRenderer(r), nil
+}
+
+// GetCachedRenderer returns a renderer based on the type, which reuses the
+// templates, charts and remote kustomization bases loaded for the same
+// version of the plan
+func GetCachedRenderer(rendererType string, plan *osbv1alpha1.SFPlan) (renderer.Renderer, error) {
+	var r renderer.Renderer
+	var err error
+	switch rendererType {
+	case "helm", "Helm", "HELM":
+		r, err = helm.NewWithCache(getClientSet(), planCache.forPlan(plan))
+	case "gotemplate", "Gotemplate", "GoTemplate", "GOTEMPLATE":
+		r, err = gotemplate.NewWithCache(planCache.forPlan(plan))
+	case "kustomize", "Kustomize", "KUSTOMIZE":
+		r, err = kustomize.NewWithCache(planCache.forPlan(plan))
+	default:
+		return GetRenderer(rendererType, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return renderer.NewLimitedRenderer(r), nil
 }
 
 // GetRendererInput contructs the input required for the renderer
@@ -69,18 +136,22 @@ func GetRendererInput(template *osbv1alpha1.TemplateSpec, service *osbv1alpha1.S
 		input := helm.NewInput(template.URL, name.Name, name.Namespace, values)
 		return input, nil
 	case "gotemplate", "Gotemplate", "GoTemplate", "GOTEMPLATE":
-		var content string
-		if template.Content != "" {
-			content = template.Content
-		} else if template.ContentEncoded != "" {
-			decodedContent, err := base64.StdEncoding.DecodeString(template.ContentEncoded)
-			content = string(decodedContent)
-			if err != nil {
-				return nil, fmt.Errorf("unable to decode base64 content %v", err)
-			}
+		content, err := getTemplateContent(template)
+		if err != nil {
+			return nil, err
 		}
 		input := gotemplate.NewInput(template.URL, content, name.Name, values)
 		return input, nil
+	case "jsonnet", "Jsonnet", "JSONNET":
+		content, err := getTemplateContent(template)
+		if err != nil {
+			return nil, err
+		}
+		input := jsonnet.NewInput(template.URL, content, name.Name, values)
+		return input, nil
+	case "kustomize", "Kustomize", "KUSTOMIZE":
+		input := kustomize.NewInput(template.URL, name.Name, name.Namespace, values)
+		return input, nil
 	default:
 		return nil, fmt.Errorf("unable to create renderer for type %s. not implemented", rendererType)
 	}
@@ -100,19 +171,39 @@ func GetStatusRendererInput(template *osbv1alpha1.TemplateSpec, name types.Names
 		input := helm.NewInput(template.URL, name.Name, name.Namespace, values)
 		return input, nil
 	case "gotemplate", "Gotemplate", "GoTemplate", "GOTEMPLATE":
-		var content string
-		if template.Content != "" {
-			content = template.Content
-		} else if template.ContentEncoded != "" {
-			decodedContent, err := base64.StdEncoding.DecodeString(template.ContentEncoded)
-			content = string(decodedContent)
-			if err != nil {
-				return nil, fmt.Errorf("unable to decode base64 content %v", err)
-			}
+		content, err := getTemplateContent(template)
+		if err != nil {
+			return nil, err
 		}
 		input := gotemplate.NewInput(template.URL, content, name.Name, values)
 		return input, nil
+	case "jsonnet", "Jsonnet", "JSONNET":
+		content, err := getTemplateContent(template)
+		if err != nil {
+			return nil, err
+		}
+		input := jsonnet.NewInput(template.URL, content, name.Name, values)
+		return input, nil
+	case "kustomize", "Kustomize", "KUSTOMIZE":
+		input := kustomize.NewInput(template.URL, name.Name, name.Namespace, values)
+		return input, nil
 	default:
 		return nil, fmt.Errorf("unable to create renderer for type %s. not implemented", rendererType)
 	}
 }
+
+// getTemplateContent returns the content of the template, decoding
+// ContentEncoded if Content is not set
+func getTemplateContent(template *osbv1alpha1.TemplateSpec) (string, error) {
+	var content string
+	if template.Content != "" {
+		content = template.Content
+	} else if template.ContentEncoded != "" {
+		decodedContent, err := base64.StdEncoding.DecodeString(template.ContentEncoded)
+		content = string(decodedContent)
+		if err != nil {
+			return "", fmt.Errorf("unable to decode base64 content %v", err)
+		}
+	}
+	return content, nil
+}
@@ -64,6 +64,7 @@ func TestGetRenderer(t *testing.T) {
 }
 
 func TestGetRendererInput(t *testing.T) {
+	planUpdatable := true
 	gopath := os.Getenv("GOPATH")
 	if gopath == "" {
 		gopath = "/home/travis/gopath"
@@ -98,7 +99,7 @@ func TestGetRendererInput(t *testing.T) {
 			Metadata:      nil,
 			Free:          false,
 			Bindable:      true,
-			PlanUpdatable: true,
+			PlanUpdatable: &planUpdatable,
 			Schemas:       nil,
 			Templates:     templateSpec,
 			ServiceID:     "service-id",
@@ -226,6 +227,7 @@ func TestGetRendererInput(t *testing.T) {
 }
 
 func TestGetPropertiesRendererInput(t *testing.T) {
+	planUpdatable := true
 	gopath := os.Getenv("GOPATH")
 	if gopath == "" {
 		gopath = "/home/travis/gopath"
@@ -260,7 +262,7 @@ func TestGetPropertiesRendererInput(t *testing.T) {
 			Metadata:      nil,
 			Free:          false,
 			Bindable:      true,
-			PlanUpdatable: true,
+			PlanUpdatable: &planUpdatable,
 			Schemas:       nil,
 			Templates:     templateSpec,
 			ServiceID:     "service-id",
@@ -305,7 +307,7 @@ func TestGetPropertiesRendererInput(t *testing.T) {
 			Metadata:      nil,
 			Free:          false,
 			Bindable:      true,
-			PlanUpdatable: true,
+			PlanUpdatable: &planUpdatable,
 			Schemas:       nil,
 			Templates:     templateSpec2,
 			ServiceID:     "service-id",
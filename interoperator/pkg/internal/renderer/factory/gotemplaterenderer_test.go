@@ -10,6 +10,7 @@ import (
 )
 
 func TestGoTemplateRenderer(t *testing.T) {
+	planUpdatable := true
 	g := gomega.NewGomegaWithT(t)
 
 	templateSpec := []osbv1alpha1.TemplateSpec{
@@ -47,7 +48,7 @@ func TestGoTemplateRenderer(t *testing.T) {
 			Metadata:      nil,
 			Free:          false,
 			Bindable:      true,
-			PlanUpdatable: true,
+			PlanUpdatable: &planUpdatable,
 			Schemas:       nil,
 			Templates:     templateSpec,
 			ServiceID:     "service-id",
@@ -165,4 +166,34 @@ func TestGoTemplateRenderer(t *testing.T) {
 	g.Expect(len(files7)).To(gomega.Equal(1))
 	g.Expect(files7[0]).To(gomega.Equal("main"))
 	//g.Expect(content7).To(gomega.Equal("provisioncontent"))
+
+	plan.Spec.Templates[0].Content = `{{ define "file:status.yaml" }}provision:
+  state: succeeded{{ end }}
+{{ define "file:sources.yaml" }}{{ .instance.metadata.name }}{{ end }}`
+	template8, _ := plan.GetTemplate("provision")
+	renderer8, _ := GetRenderer(template8.Type, nil)
+	input8, _ := GetRendererInput(template8, &service, &plan, &instance, &binding, name)
+	output8, err11 := renderer8.Render(input8)
+	g.Expect(err11).NotTo(gomega.HaveOccurred())
+	files8, _ := output8.ListFiles()
+	g.Expect(files8).To(gomega.Equal([]string{"sources.yaml", "status.yaml"}))
+	content8, _ := output8.FileContent("sources.yaml")
+	g.Expect(content8).To(gomega.Equal("foo"))
+	content9, _ := output8.FileContent("status.yaml")
+	g.Expect(content9).To(gomega.Equal("provision:\n  state: succeeded"))
+	_, err12 := output8.FileContent("main")
+	g.Expect(err12).To(gomega.HaveOccurred())
+
+	// Partials are only rendered where they are included, whatever their name
+	plan.Spec.Templates[0].Content = `{{ define "labels.yaml" }}app: {{ .instance.metadata.name }}{{ end }}
+{{ define "file:status.yaml" }}{{ include "labels.yaml" . }}{{ end }}`
+	template9, _ := plan.GetTemplate("provision")
+	renderer9, _ := GetRenderer(template9.Type, nil)
+	input9, _ := GetRendererInput(template9, &service, &plan, &instance, &binding, name)
+	output9, err13 := renderer9.Render(input9)
+	g.Expect(err13).NotTo(gomega.HaveOccurred())
+	files9, _ := output9.ListFiles()
+	g.Expect(files9).To(gomega.Equal([]string{"status.yaml"}))
+	content10, _ := output9.FileContent("status.yaml")
+	g.Expect(content10).To(gomega.Equal("app: foo"))
 }
@@ -40,6 +40,7 @@ func TestMain(m *testing.M) {
 }
 
 func TestHelmTemplateRenderer(t *testing.T) {
+	planUpdatable := true
 	g := gomega.NewGomegaWithT(t)
 
 	gopath := os.Getenv("GOPATH")
@@ -76,7 +77,7 @@ func TestHelmTemplateRenderer(t *testing.T) {
 			Metadata:      nil,
 			Free:          false,
 			Bindable:      true,
-			PlanUpdatable: true,
+			PlanUpdatable: &planUpdatable,
 			Schemas:       nil,
 			Templates:     templateSpec,
 			ServiceID:     "service-id",
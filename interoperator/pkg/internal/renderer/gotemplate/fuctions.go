@@ -1,11 +1,19 @@
 package gotemplate
 
 import (
+	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"text/template"
+
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/renderer"
+
+	"github.com/Masterminds/sprig"
+	"k8s.io/helm/pkg/chartutil"
 )
 
 // encodeToString converts a string to base64 encoded string
@@ -63,14 +71,125 @@ func strval(v interface{}) string {
 	}
 }
 
+// required fails the rendering with the message <warn> if <val> is nil
+// or an empty string
+func required(warn string, val interface{}) (interface{}, error) {
+	if val == nil {
+		return val, errors.New(warn)
+	} else if _, ok := val.(string); ok {
+		if val == "" {
+			return val, errors.New(warn)
+		}
+	}
+	return val, nil
+}
+
+// getFuncMap returns the functions available to gotemplate templates.
+// It is the same set the Helm engine offers to charts, i.e. the sprig
+// functions (http://masterminds.github.io/sprig/) along with toYaml,
+// fromYaml, toJson, fromJson, required, include and tpl. So snippets
+// can be shared between the two template types. marshalJSON and
+// unmarshalJSON are specific to interoperator. include, tpl, until and
+// untilStep are bound to the template being rendered and to the context of
+// the render, see bind.
 func getFuncMap() template.FuncMap {
-	funcMap := template.FuncMap{
+	funcMap := sprig.TxtFuncMap()
+
+	// Remove functions which can leak information about the host
+	delete(funcMap, "env")
+	delete(funcMap, "expandenv")
+
+	extra := template.FuncMap{
 		"b64enc":        encodeToString,
 		"b64dec":        decodeString,
 		"unmarshalJSON": unmarshalJSON,
 		"marshalJSON":   marshalJSON,
 		"quote":         quote,
 		"squote":        squote,
+		"toYaml":        chartutil.ToYaml,
+		"fromYaml":      chartutil.FromYaml,
+		"toJson":        chartutil.ToJson,
+		"fromJson":      chartutil.FromJson,
+		"required":      required,
+
+		// Placeholders, replaced with the template specific
+		// implementation during rendering
+		"include": func(string, interface{}) (string, error) { return "", nil },
+		"tpl":     func(string, interface{}) (string, error) { return "", nil },
+	}
+
+	for k, v := range extra {
+		funcMap[k] = v
 	}
 	return funcMap
 }
+
+// bind returns a copy of the parsed template <t> whose include, tpl, until
+// and untilStep functions fail once <ctx> is done. The templates do not
+// write to the output while they range or include, so these functions are
+// where a render which timed out is stopped. A range over a list built
+// before the timeout still runs to its end.
+func bind(ctx context.Context, t *template.Template) (*template.Template, error) {
+	engine, err := t.Clone()
+	if err != nil {
+		return nil, err
+	}
+	funcMap := sprig.TxtFuncMap()
+	until := funcMap["until"].(func(int) []int)
+	untilStep := funcMap["untilStep"].(func(int, int, int) []int)
+	engine.Funcs(template.FuncMap{
+		"include": includeFun(ctx, engine),
+		"tpl":     tplFun(ctx, engine),
+		"until": func(count int) ([]int, error) {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			return until(count), nil
+		},
+		"untilStep": func(start, stop, step int) ([]int, error) {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			return untilStep(start, stop, step), nil
+		},
+	})
+	return engine, nil
+}
+
+// includeFun returns the include function which renders the named
+// template <name> of <t> with <data> and returns it as a string
+func includeFun(ctx context.Context, t *template.Template) func(string, interface{}) (string, error) {
+	return func(name string, data interface{}) (string, error) {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		buf := new(bytes.Buffer)
+		if err := t.ExecuteTemplate(renderer.NewLimitedWriter(ctx, buf, 0, new(int64)), name, data); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+}
+
+// tplFun returns the tpl function which renders the string <tpl> as a
+// template with <data>. The named templates of <t> are available to it.
+func tplFun(ctx context.Context, t *template.Template) func(string, interface{}) (string, error) {
+	return func(tpl string, data interface{}) (string, error) {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		engine, err := t.Clone()
+		if err != nil {
+			return "", err
+		}
+		engine, err = engine.New("tpl").Parse(tpl)
+		if err != nil {
+			return "", fmt.Errorf("can't parse tpl %s:, %s", tpl, err)
+		}
+		buf := new(bytes.Buffer)
+		if err := engine.ExecuteTemplate(renderer.NewLimitedWriter(ctx, buf, 0, new(int64)), "tpl", data); err != nil {
+			return "", fmt.Errorf("can't render tpl %s:, %s", tpl, err)
+		}
+		return buf.String(), nil
+	}
+}
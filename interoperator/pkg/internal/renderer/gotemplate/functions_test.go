@@ -1,8 +1,11 @@
 package gotemplate
 
 import (
+	"context"
 	"testing"
+	"time"
 
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/renderer"
 	"github.com/onsi/gomega"
 )
 
@@ -54,3 +57,100 @@ func TestGoTemplateFunctions(t *testing.T) {
 	g.Expect(intVal).To(gomega.Equal("10"))
 
 }
+
+func TestGoTemplateSprigFunctions(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	r, _ := New()
+	render := func(content string, values map[string]interface{}) (string, error) {
+		output, err := r.Render(NewInput("", content, "foo", values))
+		if err != nil {
+			return "", err
+		}
+		return output.FileContent("main")
+	}
+
+	values := map[string]interface{}{
+		"instance": map[string]interface{}{
+			"name":   "  foo  ",
+			"labels": map[string]interface{}{"app": "bar"},
+		},
+	}
+
+	content, err := render(`{{ .instance.missing | default "bar" }}`, values)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(content).To(gomega.Equal("bar"))
+
+	content, err = render(`{{ .instance.name | trim | upper }}`, values)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(content).To(gomega.Equal("FOO"))
+
+	content, err = render(`{{ .instance.labels | toYaml | trim | indent 2 }}`, values)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(content).To(gomega.Equal("  app: bar"))
+
+	content, err = render(`{{ .instance.labels | toJson }}`, values)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(content).To(gomega.Equal(`{"app":"bar"}`))
+
+	content, err = render(`{{ sha256sum "hello" }}`, values)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(content).To(gomega.Equal("2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"))
+
+	content, err = render(`{{ $d := dict "a" 1 }}{{ $l := list 1 2 3 }}{{ add (len $l) $d.a }}`, values)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(content).To(gomega.Equal("4"))
+
+	content, err = render(`{{ define "labels" }}app: {{ .app }}{{ end }}{{ include "labels" .instance.labels | quote }}`, values)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(content).To(gomega.Equal(`"app: bar"`))
+
+	content, err = render(`{{ tpl "{{ .app }}" .instance.labels }}`, values)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(content).To(gomega.Equal("bar"))
+
+	_, err = render(`{{ required "name is required" .instance.missing }}`, values)
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	_, err = render(`{{ env "HOME" }}`, values)
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func TestGoTemplateRenderContext(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	r := &gotemplateRenderer{funcMap: getFuncMap()}
+	// The ranges and includes writing nothing stop once the context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := r.RenderContext(ctx, NewInput("", `{{ range until 100000 }}{{ range until 100000 }}{{ end }}{{ end }}`, "foo", nil))
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(time.Since(start)).To(gomega.BeNumerically("<", 5*time.Second))
+
+	_, err = r.RenderContext(ctx, NewInput("", `{{ define "empty" }}{{ end }}{{ include "empty" . }}`, "foo", nil))
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	output, err := r.RenderContext(context.Background(), NewInput("", `{{ define "n" }}{{ . }}{{ end }}{{ range untilStep 0 3 1 }}{{ include "n" . }}{{ end }}`, "foo", nil))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	content, _ := output.FileContent("main")
+	g.Expect(content).To(gomega.Equal("012"))
+}
+
+func TestGoTemplateOutputLimit(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	defer renderer.SetLimits(renderer.GetLimits())
+	renderer.SetLimits(renderer.Limits{MaxOutputSize: 1024})
+
+	r, _ := New()
+	_, err := r.Render(NewInput("", `{{ range until 1000000 }}runaway{{ end }}`, "foo", nil))
+	g.Expect(renderer.IsLimitExceeded(err)).To(gomega.BeTrue())
+
+	_, err = r.Render(NewInput("", `{{ define "file:a.yaml" }}{{ repeat 600 "x" }}{{ end }}{{ define "file:b.yaml" }}{{ repeat 600 "x" }}{{ end }}`, "foo", nil))
+	g.Expect(renderer.IsLimitExceeded(err)).To(gomega.BeTrue())
+
+	output, err := r.Render(NewInput("", `{{ repeat 1000 "x" }}`, "foo", nil))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	content, _ := output.FileContent("main")
+	g.Expect(content).To(gomega.HaveLen(1000))
+}
@@ -1,25 +1,29 @@
 package gotemplate
 
 import (
-	"bytes"
 	"fmt"
 )
 
+// mainFileName is the name of the file holding the output of the root template
+const mainFileName = "main"
+
 type gotemplateOutput struct {
-	content bytes.Buffer
+	fileNames []string
+	files     map[string]string
 }
 
 // FileContent returns explicitly the content of the provided <filename>.
 func (c *gotemplateOutput) FileContent(filename string) (string, error) {
-	if filename == "main" {
-		return c.content.String(), nil
+	content, ok := c.files[filename]
+	if !ok {
+		return "", fmt.Errorf("file %s not found in rendered gotemplate output", filename)
 	}
-	return "", fmt.Errorf("File not found")
+	return content, nil
 }
 
 // ListFiles returns list of file names rendered
 func (c *gotemplateOutput) ListFiles() ([]string, error) {
-	fileNames := make([]string, 0, 1)
-	fileNames = append(fileNames, "main")
+	fileNames := make([]string, len(c.fileNames))
+	copy(fileNames, c.fileNames)
 	return fileNames, nil
 }
@@ -18,14 +18,28 @@ package gotemplate
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
 	"text/template"
 
 	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/renderer"
 )
 
+// filePrefix marks the named templates which are rendered as separate
+// output files. A template defined with
+// {{ define "file:status.yaml" }} ... {{ end }} is available as status.yaml
+// in the rendered output. The other named templates are partials, they are
+// only rendered where they are included.
+const filePrefix = "file:"
+
 type gotemplateRenderer struct {
 	funcMap template.FuncMap
+	cache   renderer.Cache
 }
 
 type gotemplateInput struct {
@@ -49,27 +63,123 @@ func NewInput(url, content, name string, values map[string]interface{}) renderer
 
 // New creates a new gotemplate Renderer object.
 func New() (renderer.Renderer, error) {
-	return &gotemplateRenderer{funcMap: getFuncMap()}, nil
+	return NewWithCache(nil)
+}
+
+// NewWithCache creates a new gotemplate Renderer object, which stores the
+// parsed templates in <cache> keyed by the digest of their content.
+func NewWithCache(cache renderer.Cache) (renderer.Renderer, error) {
+	return &gotemplateRenderer{
+		funcMap: getFuncMap(),
+		cache:   cache,
+	}, nil
 }
 
 // Render loads the chart from the given location <chartPath> and calls the Render() function
 // to convert it into a renderer.Output object.
 // TODO Consider using streams (io.Writer or io.Reader) in the API instead of buffers.
 func (r *gotemplateRenderer) Render(rawInput renderer.Input) (renderer.Output, error) {
+	return r.RenderContext(context.Background(), rawInput)
+}
+
+// RenderContext renders the input like Render. The render fails at the next
+// write to the output, include, tpl, until or untilStep once <ctx> is done.
+func (r *gotemplateRenderer) RenderContext(ctx context.Context, rawInput renderer.Input) (renderer.Output, error) {
 	input, ok := rawInput.(gotemplateInput)
 	if !ok {
 		return nil, fmt.Errorf("invalid input to gotemplate chart renderer")
 	}
-	engine, err := template.New(input.name).Funcs(r.funcMap).Parse(input.content)
+	parsed, err := r.parse(input.content)
+	if err != nil {
+		return nil, fmt.Errorf("can't create template from %s:, %s", input.name, err)
+	}
+	engine, err := bind(ctx, parsed)
 	if err != nil {
 		return nil, fmt.Errorf("can't create template from %s:, %s", input.name, err)
 	}
 
+	// The output is limited while executing the templates, so that a
+	// runaway template fails before exhausting the memory, and a render
+	// which timed out does not keep running in the background
+	limit := renderer.GetLimits().MaxOutputSize
+	var written int64
 	buf := new(bytes.Buffer)
-	err = engine.Execute(buf, input.values)
+	err = engine.Execute(renderer.NewLimitedWriter(ctx, buf, limit, &written), input.values)
+	if renderer.IsLimitExceeded(err) {
+		return nil, err
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
 	if err != nil {
 		return nil, fmt.Errorf("can't render from %s:, %s", input.name, err)
 	}
 
-	return &gotemplateOutput{content: *buf}, nil
+	files := make(map[string]string)
+	fileNames := make([]string, 0)
+	for _, t := range engine.Templates() {
+		fileName, ok := fileTemplateName(t.Name())
+		if !ok {
+			continue
+		}
+		fileBuf := new(bytes.Buffer)
+		err = engine.ExecuteTemplate(renderer.NewLimitedWriter(ctx, fileBuf, limit, &written), t.Name(), input.values)
+		if renderer.IsLimitExceeded(err) {
+			return nil, err
+		}
+		if err != nil {
+			return nil, fmt.Errorf("can't render %s from %s:, %s", fileName, input.name, err)
+		}
+		files[fileName] = fileBuf.String()
+		fileNames = append(fileNames, fileName)
+	}
+	sort.Strings(fileNames)
+
+	// The output of the root template is kept as main unless it only
+	// contains the whitespace left over by the define blocks
+	if len(fileNames) == 0 || strings.TrimSpace(buf.String()) != "" {
+		files[mainFileName] = buf.String()
+		fileNames = append([]string{mainFileName}, fileNames...)
+	}
+
+	return &gotemplateOutput{
+		fileNames: fileNames,
+		files:     files,
+	}, nil
+}
+
+// parse parses the content into a template, or returns the template parsed
+// earlier from the same content. The parsed templates are only executed, so
+// they are safe to share.
+func (r *gotemplateRenderer) parse(content string) (*template.Template, error) {
+	sum := sha256.Sum256([]byte(content))
+	key := "gotemplate:" + hex.EncodeToString(sum[:])
+	if r.cache != nil {
+		if cached, ok := r.cache.Get(key); ok {
+			return cached.(*template.Template), nil
+		}
+	}
+
+	// include, tpl and the range functions are bound to the render by bind
+	engine, err := template.New(mainFileName).Funcs(r.funcMap).Parse(content)
+	if err != nil {
+		return nil, err
+	}
+	if r.cache != nil {
+		r.cache.Add(key, engine)
+	}
+	return engine, nil
+}
+
+// fileTemplateName returns the name of the output file of a named template
+// marked with filePrefix
+func fileTemplateName(name string) (string, bool) {
+	if !strings.HasPrefix(name, filePrefix) {
+		return "", false
+	}
+	fileName := strings.TrimPrefix(name, filePrefix)
+	if fileName == "" || fileName == mainFileName || fileName != filepath.Base(fileName) {
+		return "", false
+	}
+	return fileName, true
 }
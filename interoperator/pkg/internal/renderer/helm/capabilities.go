@@ -0,0 +1,126 @@
+/*
+Copyright 2018 The Service Fabrik Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"flag"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/discovery"
+	"k8s.io/helm/pkg/chartutil"
+)
+
+var (
+	kubeVersionFlag string
+	apiVersionsFlag string
+
+	capabilitiesLock sync.Mutex
+	capabilities     *chartutil.Capabilities
+)
+
+// AddFlags registers the --kube-version and --api-versions flags in <fs>
+func AddFlags(fs *flag.FlagSet) {
+	fs.StringVar(&kubeVersionFlag, "kube-version", "", "Kubernetes version passed to helm charts, e.g. v1.13.1. Discovered from the api server if not set.")
+	fs.StringVar(&apiVersionsFlag, "api-versions", "", "Comma separated list of API versions passed to helm charts along with --kube-version.")
+}
+
+// NewCapabilities creates the capabilities passed to the charts for the
+// kubernetes version <kubeVersion> (e.g. v1.13.1) and the API versions
+// <apiVersions>. The default API versions of helm are used if
+// <apiVersions> is empty.
+func NewCapabilities(kubeVersion string, apiVersions []string) (*chartutil.Capabilities, error) {
+	parts := strings.SplitN(strings.TrimPrefix(kubeVersion, "v"), ".", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid kubernetes version %s", kubeVersion)
+	}
+
+	versionSet := chartutil.DefaultVersionSet
+	if len(apiVersions) != 0 {
+		versionSet = chartutil.NewVersionSet(apiVersions...)
+	}
+
+	return &chartutil.Capabilities{
+		APIVersions: versionSet,
+		KubeVersion: &version.Info{
+			Major:      parts[0],
+			Minor:      parts[1],
+			GitVersion: "v" + strings.TrimPrefix(kubeVersion, "v"),
+			GoVersion:  runtime.Version(),
+			Compiler:   runtime.Compiler,
+			Platform:   fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH),
+		},
+	}, nil
+}
+
+// SetCapabilities sets the capabilities passed to the charts. The api
+// server is not contacted for rendering once they are set.
+func SetCapabilities(c *chartutil.Capabilities) {
+	capabilitiesLock.Lock()
+	defer capabilitiesLock.Unlock()
+	capabilities = c
+}
+
+// getCapabilities returns the capabilities passed to the charts. These are
+// either set by SetCapabilities, configured with the --kube-version and
+// --api-versions flags or discovered from the api server using <client>.
+// The result is cached, so the api server is contacted only once.
+func getCapabilities(client discovery.DiscoveryInterface) (*chartutil.Capabilities, error) {
+	capabilitiesLock.Lock()
+	defer capabilitiesLock.Unlock()
+
+	if capabilities != nil {
+		return capabilities, nil
+	}
+
+	if kubeVersionFlag != "" {
+		var apiVersions []string
+		if apiVersionsFlag != "" {
+			apiVersions = strings.Split(apiVersionsFlag, ",")
+		}
+		c, err := NewCapabilities(kubeVersionFlag, apiVersions)
+		if err != nil {
+			return nil, err
+		}
+		capabilities = c
+		return capabilities, nil
+	}
+
+	if client == nil {
+		return nil, fmt.Errorf("no kubernetes client to discover capabilities")
+	}
+
+	sv, err := client.ServerVersion()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kubernetes server version %v", err)
+	}
+
+	groups, err := client.ServerGroups()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kubernetes api versions %v", err)
+	}
+
+	capabilities = &chartutil.Capabilities{
+		APIVersions: chartutil.NewVersionSet(metav1.ExtractGroupVersions(groups)...),
+		KubeVersion: sv,
+	}
+	return capabilities, nil
+}
@@ -0,0 +1,366 @@
+/*
+Copyright 2018 The Service Fabrik Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ghodss/yaml"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/helm/pkg/chartutil"
+	chartapi "k8s.io/helm/pkg/proto/hapi/chart"
+	"k8s.io/helm/pkg/repo"
+)
+
+const (
+	chartVersionQuery   = "version"
+	configMapKeyQuery   = "key"
+	defaultConfigMapKey = "chart.tgz"
+	chartArchiveSuffix  = ".tgz"
+	chartRefSuffix      = ".ref"
+	indexFileName       = "index.yaml"
+	downloadTimeout     = 60 * time.Second
+	defaultRefTTL       = 5 * time.Minute
+	// defaultMaxDownloadSize is the maximum size of a downloaded chart
+	// archive or chart repository index. Charts are usually much smaller,
+	// the limit leaves room for the index of a large repository.
+	defaultMaxDownloadSize = 32 * 1024 * 1024
+)
+
+// defaultChartCacheDir is the directory where the downloaded chart
+// archives are stored
+var defaultChartCacheDir = filepath.Join(os.TempDir(), "interoperator", "charts")
+
+// chartCacheLock serializes the writes to the chart cache
+var chartCacheLock sync.Mutex
+
+// chartLoader loads the chart referred by the url of a helm template.
+// The url can be
+//   - a path to a chart directory or a chart archive, optionally
+//     prefixed with file://
+//   - http(s)://host/path/chart-1.0.0.tgz, a chart archive
+//   - http(s)://host/repo/chart?version=1.0.0, a chart in a chart
+//     repository. version can be a semver constraint, the latest
+//     version is used if it is not set
+//   - configmap://namespace/name?key=chart.tgz, a chart archive stored
+//     in the binaryData of a ConfigMap. key defaults to chart.tgz
+//
+// Remote charts are downloaded once into the cache directory where the
+// archives are stored by their sha256 digest. The digest is verified
+// against the digest published in index.yaml for charts from a chart
+// repository.
+//
+// The digest a remote url resolved to is remembered for refTTL. After
+// that the url is resolved again, so that moving references like a
+// chart repository without a pinned version pick up new charts. The
+// archive is downloaded again only if the digest changed. The content of
+// an archive is verified against its digest when the url is resolved, it
+// is not read again while the digest is remembered.
+type chartLoader struct {
+	cacheDir   string
+	clientSet  kubernetes.Interface
+	httpClient *http.Client
+	refTTL     time.Duration
+	// maxDownloadSize bounds the size of the downloads, the larger ones
+	// are rejected
+	maxDownloadSize int64
+}
+
+func newChartLoader(cacheDir string, clientSet kubernetes.Interface) *chartLoader {
+	return &chartLoader{
+		cacheDir:  cacheDir,
+		clientSet: clientSet,
+		httpClient: &http.Client{
+			Timeout: downloadTimeout,
+		},
+		refTTL:          defaultRefTTL,
+		maxDownloadSize: defaultMaxDownloadSize,
+	}
+}
+
+// Load resolves the chart url and loads the chart
+func (l *chartLoader) Load(chartURL string) (*chartapi.Chart, error) {
+	chartPath, _, err := l.resolve(chartURL)
+	if err != nil {
+		return nil, err
+	}
+	return chartutil.Load(chartPath)
+}
+
+// resolve returns the local path for the chart url, fetching the chart
+// into the cache if required, and the digest of the archive for the
+// remote charts. The digest is empty for the local charts.
+func (l *chartLoader) resolve(chartURL string) (string, string, error) {
+	u, err := url.Parse(chartURL)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid chart url %s. %v", chartURL, err)
+	}
+
+	switch u.Scheme {
+	case "":
+		return chartURL, "", nil
+	case "file":
+		return u.Path, "", nil
+	case "http", "https":
+		return l.fetchRemote(u)
+	case "configmap":
+		return l.fetchConfigMap(u)
+	default:
+		return "", "", fmt.Errorf("unsupported scheme %s in chart url %s", u.Scheme, chartURL)
+	}
+}
+
+func (l *chartLoader) fetchRemote(u *url.URL) (string, string, error) {
+	chartURL := u.String()
+	refPath := filepath.Join(l.cacheDir, digest([]byte(chartURL))+chartRefSuffix)
+	if ref, ok := l.cachedRef(refPath); ok {
+		// The archive was verified when the ref was written
+		archivePath := l.archivePath(ref)
+		if _, err := os.Stat(archivePath); err == nil {
+			return archivePath, ref, nil
+		}
+	}
+
+	archiveURL := chartURL
+	expectedDigest := ""
+	if !strings.HasSuffix(u.Path, chartArchiveSuffix) {
+		chartVersion, err := l.findInRepository(u)
+		if err != nil {
+			return "", "", err
+		}
+		if archivePath, ok := l.cachedArchive(chartVersion.Digest); ok {
+			return archivePath, chartVersion.Digest, l.writeFile(refPath, []byte(chartVersion.Digest))
+		}
+		archiveURL = chartVersion.URLs[0]
+		expectedDigest = chartVersion.Digest
+	}
+
+	data, err := l.download(archiveURL)
+	if err != nil {
+		return "", "", err
+	}
+	archiveDigest := digest(data)
+	if expectedDigest != "" && expectedDigest != archiveDigest {
+		return "", "", fmt.Errorf("digest mismatch for chart %s. expected %s, found %s", archiveURL, expectedDigest, archiveDigest)
+	}
+
+	archivePath, err := l.storeArchive(data, archiveDigest)
+	if err != nil {
+		return "", "", err
+	}
+	return archivePath, archiveDigest, l.writeFile(refPath, []byte(archiveDigest))
+}
+
+// findInRepository looks up the chart in the index of the chart repository
+func (l *chartLoader) findInRepository(u *url.URL) (*repo.ChartVersion, error) {
+	chartName := path.Base(u.Path)
+	chartVersion := u.Query().Get(chartVersionQuery)
+
+	repoURL := *u
+	repoURL.Path = path.Dir(u.Path)
+	repoURL.RawQuery = ""
+	indexURL, err := repo.ResolveReferenceURL(repoURL.String(), indexFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := l.download(indexURL)
+	if err != nil {
+		return nil, err
+	}
+	index := &repo.IndexFile{}
+	if err := yaml.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("failed to parse chart repository index %s. %v", indexURL, err)
+	}
+	index.SortEntries()
+
+	version, err := index.Get(chartName, chartVersion)
+	if err != nil {
+		return nil, fmt.Errorf("chart %s version %s not found in %s. %v", chartName, chartVersion, indexURL, err)
+	}
+	if len(version.URLs) == 0 {
+		return nil, fmt.Errorf("chart %s version %s in %s has no url", chartName, version.Version, indexURL)
+	}
+	archiveURL, err := repo.ResolveReferenceURL(repoURL.String(), version.URLs[0])
+	if err != nil {
+		return nil, err
+	}
+	version.URLs = []string{archiveURL}
+	return version, nil
+}
+
+func (l *chartLoader) fetchConfigMap(u *url.URL) (string, string, error) {
+	if l.clientSet == nil {
+		return "", "", fmt.Errorf("no kubernetes client to fetch chart %s", u)
+	}
+	namespace := u.Host
+	name := strings.Trim(u.Path, "/")
+	key := u.Query().Get(configMapKeyQuery)
+	if key == "" {
+		key = defaultConfigMapKey
+	}
+
+	configMap, err := l.clientSet.CoreV1().ConfigMaps(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get configmap %s/%s for chart. %v", namespace, name, err)
+	}
+	data, ok := configMap.BinaryData[key]
+	if !ok {
+		return "", "", fmt.Errorf("key %s not found in binaryData of configmap %s/%s", key, namespace, name)
+	}
+
+	archiveDigest := digest(data)
+	if archivePath, ok := l.cachedArchive(archiveDigest); ok {
+		return archivePath, archiveDigest, nil
+	}
+	archivePath, err := l.storeArchive(data, archiveDigest)
+	return archivePath, archiveDigest, err
+}
+
+func (l *chartLoader) download(downloadURL string) ([]byte, error) {
+	resp, err := l.httpClient.Get(downloadURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s. %v", downloadURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s. %s", downloadURL, resp.Status)
+	}
+	// One byte more than the maximum tells an oversized download apart
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, l.maxDownloadSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s. %v", downloadURL, err)
+	}
+	if int64(len(data)) > l.maxDownloadSize {
+		return nil, fmt.Errorf("failed to download %s. larger than %d bytes", downloadURL, l.maxDownloadSize)
+	}
+	return data, nil
+}
+
+// cachedRef returns the digest stored in the ref file if it was written
+// less than refTTL ago
+func (l *chartLoader) cachedRef(refPath string) (string, bool) {
+	info, err := os.Stat(refPath)
+	if err != nil || time.Since(info.ModTime()) >= l.refTTL {
+		return "", false
+	}
+	ref, err := ioutil.ReadFile(refPath)
+	if err != nil {
+		return "", false
+	}
+	return string(ref), true
+}
+
+// cachedArchive returns the path of the archive with the digest if it
+// is present in the cache and its content matches the digest
+func (l *chartLoader) cachedArchive(archiveDigest string) (string, bool) {
+	if archiveDigest == "" {
+		return "", false
+	}
+	archivePath := l.archivePath(archiveDigest)
+	data, err := ioutil.ReadFile(archivePath)
+	if err != nil {
+		return "", false
+	}
+	if digest(data) != archiveDigest {
+		// Corrupt archive, fetch it again
+		os.Remove(archivePath)
+		return "", false
+	}
+	return archivePath, true
+}
+
+// archivePath returns the path of the archive with the digest in the cache
+func (l *chartLoader) archivePath(archiveDigest string) string {
+	return filepath.Join(l.cacheDir, archiveDigest+chartArchiveSuffix)
+}
+
+func (l *chartLoader) storeArchive(data []byte, archiveDigest string) (string, error) {
+	archivePath := l.archivePath(archiveDigest)
+	return archivePath, l.writeFile(archivePath, data)
+}
+
+// writeFile atomically writes the data to the file in the cache
+func (l *chartLoader) writeFile(filename string, data []byte) error {
+	chartCacheLock.Lock()
+	defer chartCacheLock.Unlock()
+
+	if err := os.MkdirAll(l.cacheDir, 0755); err != nil {
+		return fmt.Errorf("failed to create chart cache directory %s. %v", l.cacheDir, err)
+	}
+	tmpFile, err := ioutil.TempFile(l.cacheDir, filepath.Base(filename))
+	if err != nil {
+		return fmt.Errorf("failed to write %s to chart cache. %v", filename, err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	_, err = tmpFile.Write(data)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s to chart cache. %v", filename, err)
+	}
+	return os.Rename(tmpFile.Name(), filename)
+}
+
+// chartKey returns the key of the chart at <chartPath> in the renderer
+// cache. Archives are identified by their digest and directories by their
+// absolute path. <archiveDigest> is the digest of the remote charts, which
+// is known once they are resolved, the local archives are read to compute
+// theirs.
+func chartKey(chartPath, archiveDigest string) (string, error) {
+	if archiveDigest != "" {
+		return "helm:" + archiveDigest, nil
+	}
+	info, err := os.Stat(chartPath)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		absPath, err := filepath.Abs(chartPath)
+		if err != nil {
+			return "", err
+		}
+		return "helm:dir:" + absPath, nil
+	}
+	data, err := ioutil.ReadFile(chartPath)
+	if err != nil {
+		return "", err
+	}
+	return "helm:" + digest(data), nil
+}
+
+// digest returns the hex encoded sha256 digest of the data, which is the
+// format used in chart repository indexes
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
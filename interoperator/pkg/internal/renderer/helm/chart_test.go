@@ -0,0 +1,158 @@
+package helm
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/helm/pkg/chartutil"
+)
+
+func TestChartLoader(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	chartDir := filepath.Join("..", "..", "..", "..", "config", "samples", "templates", "helmtemplates", "postgresql")
+	tmpDir, err := ioutil.TempDir("", "chart-loader")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	defer os.RemoveAll(tmpDir)
+
+	chart, err := chartutil.Load(chartDir)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	archivePath, err := chartutil.Save(chart, tmpDir)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	archive, err := ioutil.ReadFile(archivePath)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	archiveName := filepath.Base(archivePath)
+	version := chart.GetMetadata().GetVersion()
+
+	downloads := 0
+	indexDownloads := 0
+	archiveDigest := digest(archive)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/charts/index.yaml":
+			indexDownloads++
+			fmt.Fprintf(w, `apiVersion: v1
+entries:
+  postgresql:
+  - name: postgresql
+    version: %s
+    digest: %s
+    urls:
+    - %s
+  tampered:
+  - name: tampered
+    version: %s
+    digest: 0000
+    urls:
+    - %s
+`, version, archiveDigest, archiveName, version, archiveName)
+		case "/charts/" + archiveName:
+			downloads++
+			w.Write(archive)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "postgresql-chart",
+			Namespace: "default",
+		},
+		BinaryData: map[string][]byte{
+			defaultConfigMapKey: archive,
+		},
+	}
+	loader := newChartLoader(filepath.Join(tmpDir, "cache"), fake.NewSimpleClientset(configMap))
+
+	// Local directory and archive
+	for _, chartURL := range []string{chartDir, archivePath, "file://" + archivePath} {
+		loaded, err := loader.Load(chartURL)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(loaded.GetMetadata().GetName()).To(gomega.Equal("postgresql"))
+	}
+
+	// Archive url, downloaded only once
+	for i := 0; i < 2; i++ {
+		loaded, err := loader.Load(server.URL + "/charts/" + archiveName)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(loaded.GetMetadata().GetName()).To(gomega.Equal("postgresql"))
+	}
+	g.Expect(downloads).To(gomega.Equal(1))
+
+	// Chart repository reference, served from the cache by digest
+	loaded, err := loader.Load(server.URL + "/charts/postgresql?version=" + version)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(loaded.GetMetadata().GetVersion()).To(gomega.Equal(version))
+	g.Expect(downloads).To(gomega.Equal(1))
+	g.Expect(indexDownloads).To(gomega.Equal(1))
+
+	// Resolved reference is reused until it expires
+	repoURL := server.URL + "/charts/postgresql?version=" + version
+	_, err = loader.Load(repoURL)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(indexDownloads).To(gomega.Equal(1))
+
+	refPath := filepath.Join(loader.cacheDir, digest([]byte(repoURL))+chartRefSuffix)
+	expired := time.Now().Add(-loader.refTTL)
+	g.Expect(os.Chtimes(refPath, expired, expired)).To(gomega.Succeed())
+	_, err = loader.Load(repoURL)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(indexDownloads).To(gomega.Equal(2))
+	g.Expect(downloads).To(gomega.Equal(1))
+
+	// Corrupt archive in the cache is downloaded again
+	err = ioutil.WriteFile(filepath.Join(loader.cacheDir, archiveDigest+chartArchiveSuffix), []byte("corrupt"), 0644)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	_, err = loader.Load(server.URL + "/charts/postgresql")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(downloads).To(gomega.Equal(2))
+
+	_, err = loader.Load(server.URL + "/charts/tampered")
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	_, err = loader.Load(server.URL + "/charts/postgresql?version=99.0.0")
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	_, err = loader.Load(server.URL + "/charts/missing.tgz")
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	// The digest of a resolved url is the key of the chart, the archive is
+	// not read again
+	chartPath, chartDigest, err := loader.resolve(server.URL + "/charts/" + archiveName)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(chartDigest).To(gomega.Equal(archiveDigest))
+	g.Expect(chartKey(chartPath, chartDigest)).To(gomega.Equal("helm:" + archiveDigest))
+	g.Expect(chartKey(archivePath, "")).To(gomega.Equal("helm:" + archiveDigest))
+
+	// Downloads larger than the maximum are rejected
+	loader.maxDownloadSize = int64(len(archive)) - 1
+	_, err = loader.Load(server.URL + "/charts/" + archiveName + "?nocache")
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("larger than")))
+	loader.maxDownloadSize = defaultMaxDownloadSize
+
+	// ConfigMap
+	loaded, err = loader.Load("configmap://default/postgresql-chart")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(loaded.GetMetadata().GetName()).To(gomega.Equal("postgresql"))
+
+	_, err = loader.Load("configmap://default/postgresql-chart?key=missing")
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	_, err = loader.Load("configmap://default/missing")
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	_, err = loader.Load("ftp://example.com/chart.tgz")
+	g.Expect(err).To(gomega.HaveOccurred())
+}
@@ -24,6 +24,8 @@ import (
 
 	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/renderer"
 
+	"github.com/golang/protobuf/proto"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/helm/pkg/chartutil"
 	"k8s.io/helm/pkg/engine"
@@ -38,6 +40,8 @@ type helmRenderer struct {
 	renderer     *engine.Engine
 	capabilities *chartutil.Capabilities
 	clientSet    *kubernetes.Clientset
+	charts       *chartLoader
+	cache        renderer.Cache
 }
 
 type helmInput struct {
@@ -57,46 +61,98 @@ func NewInput(chartPath, releaseName, namespace string, values map[string]interf
 	}
 }
 
-// New creates a new helm Renderer object.
+// New creates a new helm Renderer object. The api server is contacted only
+// the first time to discover the capabilities passed to the charts, see
+// getCapabilities. A kubernetes client is not required if the
+// capabilities are configured.
 func New(clientSet *kubernetes.Clientset) (renderer.Renderer, error) {
+	return NewWithCache(clientSet, nil)
+}
+
+// NewWithCache creates a new helm Renderer object, which stores the loaded
+// charts in <cache> keyed by the digest of the chart archive, or the path
+// of the chart directory.
+func NewWithCache(clientSet *kubernetes.Clientset, cache renderer.Cache) (renderer.Renderer, error) {
+	var clientErr error
 	if clientSet == nil {
 		cfg, err := config.GetConfig()
 		if err != nil {
-			return nil, fmt.Errorf("unable to set up client config for helm chart renderer %v", err)
-		}
-
-		clientSet, err = kubernetes.NewForConfig(cfg)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create kubernetes client %v", err)
+			clientErr = fmt.Errorf("unable to set up client config for helm chart renderer %v", err)
+		} else {
+			clientSet, err = kubernetes.NewForConfig(cfg)
+			if err != nil {
+				clientErr = fmt.Errorf("failed to create kubernetes client %v", err)
+			}
 		}
 	}
-	sv, err := clientSet.ServerVersion()
 
+	var discoveryClient discovery.DiscoveryInterface
+	var chartsClient kubernetes.Interface
+	if clientSet != nil {
+		discoveryClient = clientSet.Discovery()
+		chartsClient = clientSet
+	}
+	caps, err := getCapabilities(discoveryClient)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get kubernetes server version %v", err)
+		if clientErr != nil {
+			return nil, clientErr
+		}
+		return nil, err
 	}
+
 	return &helmRenderer{
 		clientSet:    clientSet,
 		renderer:     engine.New(),
-		capabilities: &chartutil.Capabilities{KubeVersion: sv},
+		capabilities: caps,
+		charts:       newChartLoader(defaultChartCacheDir, chartsClient),
+		cache:        cache,
 	}, nil
 }
 
 // Render loads the chart from the given location <chartPath> and calls the Render() function
-// to convert it into a renderer.Output object.
+// to convert it into a renderer.Output object. <chartPath> can be a local path or the url of
+// a remote chart, see chartLoader.
 // TODO Consider using streams (io.Writer or io.Reader) in the API instead of buffers.
 func (r *helmRenderer) Render(rawInput renderer.Input) (renderer.Output, error) {
 	input, ok := rawInput.(helmInput)
 	if !ok {
 		return nil, fmt.Errorf("invalid input to helm chart renderer")
 	}
-	chart, err := chartutil.Load(input.chartPath)
+	chart, err := r.loadChart(input.chartPath)
 	if err != nil {
 		return nil, fmt.Errorf("can't create load chart from path %s:, %s", input.chartPath, err)
 	}
 	return r.renderRelease(chart, input.releaseName, input.namespace, input.values)
 }
 
+// loadChart loads the chart, or copies the chart loaded earlier from the
+// same archive or directory. Rendering modifies the dependencies and values
+// of the chart, so each render gets its own copy.
+func (r *helmRenderer) loadChart(chartURL string) (*chartapi.Chart, error) {
+	if r.cache == nil {
+		return r.charts.Load(chartURL)
+	}
+
+	chartPath, archiveDigest, err := r.charts.resolve(chartURL)
+	if err != nil {
+		return nil, err
+	}
+	key, err := chartKey(chartPath, archiveDigest)
+	if err != nil {
+		return nil, err
+	}
+	if cached, ok := r.cache.Get(key); ok {
+		return proto.Clone(cached.(*chartapi.Chart)).(*chartapi.Chart), nil
+	}
+
+	chart, err := chartutil.Load(chartPath)
+	if err != nil {
+		return nil, err
+	}
+	r.cache.Add(key, chart)
+	return proto.Clone(chart).(*chartapi.Chart), nil
+}
+
 func (r *helmRenderer) renderRelease(chart *chartapi.Chart, releaseName, namespace string, values map[string]interface{}) (renderer.Output, error) {
 	chartName := chart.GetMetadata().GetName()
 
@@ -0,0 +1,132 @@
+package helm
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	chartapi "k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+func TestNewCapabilities(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	caps, err := NewCapabilities("v1.13.1", []string{"v1", "apps/v1"})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(caps.KubeVersion.Major).To(gomega.Equal("1"))
+	g.Expect(caps.KubeVersion.Minor).To(gomega.Equal("13"))
+	g.Expect(caps.KubeVersion.GitVersion).To(gomega.Equal("v1.13.1"))
+	g.Expect(caps.APIVersions.Has("apps/v1")).To(gomega.BeTrue())
+
+	caps, err = NewCapabilities("1.12", nil)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(caps.KubeVersion.GitVersion).To(gomega.Equal("v1.12"))
+	g.Expect(caps.APIVersions.Has("v1")).To(gomega.BeTrue())
+
+	_, err = NewCapabilities("latest", nil)
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func TestGetCapabilities(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	defer SetCapabilities(nil)
+
+	SetCapabilities(nil)
+	_, err := getCapabilities(nil)
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	clientSet := fake.NewSimpleClientset()
+	clientSet.Resources = []*metav1.APIResourceList{
+		{GroupVersion: "apps/v1"},
+		{GroupVersion: "v1"},
+	}
+	caps, err := getCapabilities(clientSet.Discovery())
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(caps.APIVersions.Has("apps/v1")).To(gomega.BeTrue())
+
+	// Cached after the first discovery
+	cached, err := getCapabilities(nil)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(cached).To(gomega.BeIdenticalTo(caps))
+}
+
+func TestAddFlags(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	defer SetCapabilities(nil)
+	defer func() { kubeVersionFlag, apiVersionsFlag = "", "" }()
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	AddFlags(fs)
+	g.Expect(fs.Parse([]string{"--kube-version=v1.12.3", "--api-versions=v1,apps/v1"})).To(gomega.Succeed())
+
+	SetCapabilities(nil)
+	caps, err := getCapabilities(nil)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(caps.KubeVersion.GitVersion).To(gomega.Equal("v1.12.3"))
+	g.Expect(caps.APIVersions.Has("apps/v1")).To(gomega.BeTrue())
+}
+
+func TestRenderOffline(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	defer SetCapabilities(nil)
+
+	caps, _ := NewCapabilities("v1.13.1", []string{"v1", "apps/v1"})
+	SetCapabilities(caps)
+
+	chartDir, err := ioutil.TempDir("", "capabilities")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	defer os.RemoveAll(chartDir)
+	os.MkdirAll(filepath.Join(chartDir, "templates"), 0755)
+	ioutil.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("name: capabilities\nversion: 0.1.0\n"), 0644)
+	ioutil.WriteFile(filepath.Join(chartDir, "templates", "caps.yaml"),
+		[]byte(`{{ .Capabilities.KubeVersion.GitVersion }} {{ .Capabilities.APIVersions.Has "apps/v1" }} {{ .Values.name }}`), 0644)
+
+	r, err := New(nil)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	output, err := r.Render(NewInput(chartDir, "foo", "default", map[string]interface{}{"name": "foo"}))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	content, err := output.FileContent("caps.yaml")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(content).To(gomega.Equal("v1.13.1 true foo"))
+}
+
+type mapCache map[string]interface{}
+
+func (c mapCache) Get(key string) (interface{}, bool) {
+	value, ok := c[key]
+	return value, ok
+}
+
+func (c mapCache) Add(key string, value interface{}) {
+	c[key] = value
+}
+
+func TestRenderCachedChart(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	defer SetCapabilities(nil)
+
+	caps, _ := NewCapabilities("v1.13.1", nil)
+	SetCapabilities(caps)
+
+	chartDir := filepath.Join("..", "..", "..", "..", "config", "samples", "templates", "helmtemplates", "postgresql")
+	cache := make(mapCache)
+	r, err := NewWithCache(nil, cache)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	for _, name := range []string{"foo", "bar"} {
+		output, err := r.Render(NewInput(chartDir, name, "default", map[string]interface{}{}))
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		files, _ := output.ListFiles()
+		g.Expect(files).NotTo(gomega.BeEmpty())
+	}
+	g.Expect(cache).To(gomega.HaveLen(1))
+	for _, chart := range cache {
+		g.Expect(chart.(*chartapi.Chart).GetMetadata().GetName()).To(gomega.Equal("postgresql"))
+	}
+}
@@ -0,0 +1,30 @@
+package jsonnet
+
+import (
+	"fmt"
+)
+
+// mainFileName is the name of the file holding the output of a template
+// which does not render multiple files
+const mainFileName = "main"
+
+type jsonnetOutput struct {
+	fileNames []string
+	files     map[string]string
+}
+
+// FileContent returns explicitly the content of the provided <filename>.
+func (c *jsonnetOutput) FileContent(filename string) (string, error) {
+	content, ok := c.files[filename]
+	if !ok {
+		return "", fmt.Errorf("file %s not found in rendered jsonnet output", filename)
+	}
+	return content, nil
+}
+
+// ListFiles returns list of file names rendered
+func (c *jsonnetOutput) ListFiles() ([]string, error) {
+	fileNames := make([]string, len(c.fileNames))
+	copy(fileNames, c.fileNames)
+	return fileNames, nil
+}
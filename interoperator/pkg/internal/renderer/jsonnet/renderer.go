@@ -0,0 +1,231 @@
+/*
+Copyright 2018 The Service Fabrik Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jsonnet
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/renderer"
+
+	"github.com/google/go-jsonnet"
+	"github.com/google/go-jsonnet/ast"
+)
+
+// fileSuffixes are the suffixes of the keys which make the top level
+// object of a template a set of files
+var fileSuffixes = [...]string{".yaml", ".yml", ".json"}
+
+type jsonnetRenderer struct {
+}
+
+type jsonnetInput struct {
+	url     string
+	content string
+	name    string
+	values  map[string]interface{}
+}
+
+// NewInput creates a new jsonnet Renderer input object. The template is
+// read from <url> if <content> is empty.
+func NewInput(url, content, name string, values map[string]interface{}) renderer.Input {
+	if content != "" || url != "" {
+		return jsonnetInput{
+			url:     url,
+			content: content,
+			name:    name,
+			values:  values,
+		}
+	}
+
+	return nil
+}
+
+// New creates a new jsonnet Renderer object.
+func New() (renderer.Renderer, error) {
+	return &jsonnetRenderer{}, nil
+}
+
+// Render evaluates the jsonnet template and converts the result into a
+// renderer.Output object. The values are available as external variables,
+// i.e. std.extVar("instance"). If the template is a function, the values
+// are also passed as top level arguments for the parameters it declares.
+//
+// If the template evaluates to an object whose keys are all file names
+// (ending with .yaml, .yml or .json), each field is an output file.
+// Otherwise the result is the file main. A string is used as the content
+// of the file as is, an array is rendered as multiple documents and any
+// other value as json.
+//
+// Only the templates read from <url> can import files, relative to their
+// directory.
+// TODO Consider using streams (io.Writer or io.Reader) in the API instead of buffers.
+func (r *jsonnetRenderer) Render(rawInput renderer.Input) (renderer.Output, error) {
+	input, ok := rawInput.(jsonnetInput)
+	if !ok {
+		return nil, fmt.Errorf("invalid input to jsonnet renderer")
+	}
+
+	vm := jsonnet.MakeVM()
+	// Inline templates can not import any file, the default importer would
+	// read any file of the interoperator
+	vm.Importer(&jsonnet.MemoryImporter{})
+	filename := input.name
+	content := input.content
+	if content == "" {
+		data, err := ioutil.ReadFile(input.url)
+		if err != nil {
+			return nil, fmt.Errorf("can't read template from %s:, %s", input.url, err)
+		}
+		content = string(data)
+		filename = input.url
+		vm.Importer(&jsonnet.FileImporter{
+			JPaths: []string{filepath.Dir(input.url)},
+		})
+	}
+
+	params := parameters(filename, content)
+	for key, val := range input.values {
+		code, err := json.Marshal(val)
+		if err != nil {
+			return nil, fmt.Errorf("can't pass %s to template %s:, %s", key, input.name, err)
+		}
+		vm.ExtCode(key, string(code))
+		if params[key] {
+			vm.TLACode(key, string(code))
+		}
+	}
+
+	result, err := vm.EvaluateSnippet(filename, content)
+	if err != nil {
+		return nil, fmt.Errorf("can't render from %s:, %s", input.name, err)
+	}
+	if limit := renderer.GetLimits().MaxOutputSize; limit > 0 && int64(len(result)) > limit {
+		return nil, renderer.OutputSizeError(limit)
+	}
+
+	var value interface{}
+	err = json.Unmarshal([]byte(result), &value)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse output of %s:, %s", input.name, err)
+	}
+
+	files := make(map[string]interface{})
+	if object, ok := value.(map[string]interface{}); ok && isFileObject(object) {
+		files = object
+	} else {
+		files[mainFileName] = value
+	}
+
+	output := &jsonnetOutput{
+		fileNames: make([]string, 0, len(files)),
+		files:     make(map[string]string),
+	}
+	for fileName, fileValue := range files {
+		fileContent, err := toFileContent(fileValue)
+		if err != nil {
+			return nil, fmt.Errorf("can't render %s from %s:, %s", fileName, input.name, err)
+		}
+		output.fileNames = append(output.fileNames, fileName)
+		output.files[fileName] = fileContent
+	}
+	sort.Strings(output.fileNames)
+	return output, nil
+}
+
+// parameters returns the names of the parameters of the function the
+// template evaluates to. Passing a top level argument for a parameter the
+// function does not declare is an error. Templates which fail to parse
+// have no parameters, the error is reported when they are evaluated.
+func parameters(filename, content string) map[string]bool {
+	node, err := jsonnet.SnippetToAST(filename, content)
+	if err != nil {
+		return nil
+	}
+	for {
+		local, ok := node.(*ast.Local)
+		if !ok {
+			break
+		}
+		node = local.Body
+	}
+	function, ok := node.(*ast.Function)
+	if !ok {
+		return nil
+	}
+	params := make(map[string]bool)
+	for _, param := range function.Parameters.Required {
+		params[string(param)] = true
+	}
+	for _, param := range function.Parameters.Optional {
+		params[string(param.Name)] = true
+	}
+	return params
+}
+
+func isFileObject(object map[string]interface{}) bool {
+	if len(object) == 0 {
+		return false
+	}
+	for key := range object {
+		if !isFileName(key) {
+			return false
+		}
+	}
+	return true
+}
+
+func isFileName(name string) bool {
+	if name != filepath.Base(name) {
+		return false
+	}
+	for _, suffix := range fileSuffixes {
+		if strings.HasSuffix(name, suffix) && name != suffix {
+			return true
+		}
+	}
+	return false
+}
+
+// toFileContent converts a value to the content of a file. Arrays are
+// converted to multiple documents separated by ---
+func toFileContent(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case []interface{}:
+		docs := make([]string, 0, len(v))
+		for _, item := range v {
+			doc, err := toFileContent(item)
+			if err != nil {
+				return "", err
+			}
+			docs = append(docs, doc)
+		}
+		return strings.Join(docs, "\n---\n"), nil
+	default:
+		content, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(content), nil
+	}
+}
@@ -0,0 +1,92 @@
+package jsonnet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestJsonnetRenderer(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	r, err := New()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	values := map[string]interface{}{
+		"instance": map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name": "foo",
+			},
+		},
+	}
+
+	// Top level arguments with defaults
+	content := `function(instance, binding={}) {
+		kind: "ConfigMap",
+		name: instance.metadata.name,
+	}`
+	output, err := r.Render(NewInput("", content, "provision", values))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(output.ListFiles()).To(gomega.Equal([]string{"main"}))
+	g.Expect(output.FileContent("main")).To(gomega.Equal(`{"kind":"ConfigMap","name":"foo"}`))
+	_, err = output.FileContent("missing.yaml")
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	// Values the function does not declare are not passed as arguments,
+	// all values are available as external variables
+	allValues := map[string]interface{}{
+		"service":  map[string]interface{}{"id": "service-id"},
+		"plan":     map[string]interface{}{"id": "plan-id"},
+		"instance": values["instance"],
+	}
+	output, err = r.Render(NewInput("", `function(instance) instance.metadata.name`, "provision", allValues))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(output.FileContent("main")).To(gomega.Equal("foo"))
+	output, err = r.Render(NewInput("", `local plan = std.extVar("plan"); { plan: plan.id }`, "provision", allValues))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(output.FileContent("main")).To(gomega.Equal(`{"plan":"plan-id"}`))
+
+	// Object of files
+	content = `function(instance) {
+		"sources.yaml": { name: instance.metadata.name },
+		"status.yaml": "state: succeeded",
+		"resources.yaml": [{ a: 1 }, { b: 2 }],
+	}`
+	output, err = r.Render(NewInput("", content, "provision", values))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(output.ListFiles()).To(gomega.Equal([]string{"resources.yaml", "sources.yaml", "status.yaml"}))
+	g.Expect(output.FileContent("sources.yaml")).To(gomega.Equal(`{"name":"foo"}`))
+	g.Expect(output.FileContent("status.yaml")).To(gomega.Equal("state: succeeded"))
+	g.Expect(output.FileContent("resources.yaml")).To(gomega.Equal("{\"a\":1}\n---\n{\"b\":2}"))
+
+	// Template file with imports
+	dir, err := ioutil.TempDir("", "jsonnet")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "lib.libsonnet"), []byte(`{ prefix: "sf-" }`), 0644)
+	url := filepath.Join(dir, "template.jsonnet")
+	ioutil.WriteFile(url, []byte(`local lib = import "lib.libsonnet"; function(instance) lib.prefix + instance.metadata.name`), 0644)
+	output, err = r.Render(NewInput(url, "", "provision", values))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(output.FileContent("main")).To(gomega.Equal("sf-foo"))
+
+	// Inline templates can not import files
+	_, err = r.Render(NewInput("", `import "`+filepath.Join(dir, "lib.libsonnet")+`"`, "provision", values))
+	g.Expect(err).To(gomega.HaveOccurred())
+	_, err = r.Render(NewInput("", `importstr "/etc/passwd"`, "provision", values))
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	// Errors
+	g.Expect(NewInput("", "", "provision", values)).To(gomega.BeNil())
+	_, err = r.Render(NewInput(filepath.Join(dir, "missing.jsonnet"), "", "provision", values))
+	g.Expect(err).To(gomega.HaveOccurred())
+	_, err = r.Render(NewInput("", `function(instance) error "failed"`, "provision", values))
+	g.Expect(err).To(gomega.HaveOccurred())
+	_, err = r.Render(NewInput("", `function(plan) plan`, "provision", values))
+	g.Expect(err).To(gomega.HaveOccurred())
+	_, err = r.Render(nil)
+	g.Expect(err).To(gomega.HaveOccurred())
+}
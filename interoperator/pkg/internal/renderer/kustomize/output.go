@@ -0,0 +1,26 @@
+package kustomize
+
+import (
+	"fmt"
+)
+
+type kustomizeOutput struct {
+	fileNames []string
+	files     map[string]string
+}
+
+// FileContent returns explicitly the content of the provided <filename>.
+func (c *kustomizeOutput) FileContent(filename string) (string, error) {
+	content, ok := c.files[filename]
+	if !ok {
+		return "", fmt.Errorf("file %s not found in rendered kustomize output", filename)
+	}
+	return content, nil
+}
+
+// ListFiles returns list of file names rendered
+func (c *kustomizeOutput) ListFiles() ([]string, error) {
+	fileNames := make([]string, len(c.fileNames))
+	copy(fileNames, c.fileNames)
+	return fileNames, nil
+}
@@ -0,0 +1,327 @@
+/*
+Copyright 2018 The Service Fabrik Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kustomize
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/renderer"
+
+	"github.com/ghodss/yaml"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/kustomize/k8sdeps"
+	"sigs.k8s.io/kustomize/pkg/constants"
+	"sigs.k8s.io/kustomize/pkg/fs"
+	"sigs.k8s.io/kustomize/pkg/git"
+	"sigs.k8s.io/kustomize/pkg/loader"
+	"sigs.k8s.io/kustomize/pkg/patch"
+	"sigs.k8s.io/kustomize/pkg/target"
+	"sigs.k8s.io/kustomize/pkg/types"
+)
+
+// defaultBaseCacheDir is the directory where the remote bases are cloned
+var defaultBaseCacheDir = filepath.Join(os.TempDir(), "interoperator", "kustomize")
+
+// baseCacheLock serializes the clones into the base cache
+var baseCacheLock sync.Mutex
+
+type kustomizeRenderer struct {
+	fSys     fs.FileSystem
+	cache    renderer.Cache
+	cacheDir string
+	cloner   git.Cloner
+}
+
+type kustomizeInput struct {
+	basePath  string
+	name      string
+	namespace string
+	values    map[string]interface{}
+}
+
+// overlayParameters are the instance parameters used to generate the
+// overlay on top of the kustomization base
+type overlayParameters struct {
+	NamePrefix        string                   `json:"namePrefix,omitempty"`
+	NameSuffix        string                   `json:"nameSuffix,omitempty"`
+	CommonLabels      map[string]string        `json:"commonLabels,omitempty"`
+	CommonAnnotations map[string]string        `json:"commonAnnotations,omitempty"`
+	Patches           []map[string]interface{} `json:"patches,omitempty"`
+}
+
+// NewInput creates a new kustomize Renderer input object.
+func NewInput(basePath, name, namespace string, values map[string]interface{}) renderer.Input {
+	return kustomizeInput{
+		basePath:  basePath,
+		name:      name,
+		namespace: namespace,
+		values:    values,
+	}
+}
+
+// New creates a new kustomize Renderer object.
+func New() (renderer.Renderer, error) {
+	return NewWithCache(nil)
+}
+
+// NewWithCache creates a new kustomize Renderer object, which clones each
+// remote base once and stores the directory of the clone in <cache> keyed
+// by the url of the base.
+func NewWithCache(cache renderer.Cache) (renderer.Renderer, error) {
+	return &kustomizeRenderer{
+		fSys:     fs.MakeRealFS(),
+		cache:    cache,
+		cacheDir: defaultBaseCacheDir,
+		cloner:   git.ClonerUsingGitExec,
+	}, nil
+}
+
+// Render generates an overlay for the kustomization base <basePath> and
+// converts the customized resources into a renderer.Output object, with
+// one file per resource. <basePath> can be a local directory or a remote
+// base supported by kustomize, e.g. github.com/org/repo//path?ref=v1.0.0.
+//
+// The overlay puts the resources in the namespace of the input and
+// prefixes their names with <name>-. The instance parameters namePrefix
+// (prepended to <name>-), nameSuffix, commonLabels, commonAnnotations and
+// patches (a list of strategic merge patches) customize the overlay
+// further.
+// TODO Consider using streams (io.Writer or io.Reader) in the API instead of buffers.
+func (r *kustomizeRenderer) Render(rawInput renderer.Input) (renderer.Output, error) {
+	input, ok := rawInput.(kustomizeInput)
+	if !ok {
+		return nil, fmt.Errorf("invalid input to kustomize renderer")
+	}
+
+	params, err := getOverlayParameters(input.values)
+	if err != nil {
+		return nil, fmt.Errorf("invalid overlay parameters for %s:, %s", input.name, err)
+	}
+
+	overlayDir, err := ioutil.TempDir("", "kustomize-overlay")
+	if err != nil {
+		return nil, fmt.Errorf("can't create overlay for %s:, %s", input.name, err)
+	}
+	defer os.RemoveAll(overlayDir)
+
+	basePath, err := r.resolveBase(input.basePath)
+	if err != nil {
+		return nil, fmt.Errorf("can't load kustomization %s:, %s", input.basePath, err)
+	}
+	input.basePath = basePath
+
+	err = writeOverlay(overlayDir, input, params)
+	if err != nil {
+		return nil, fmt.Errorf("can't create overlay for %s:, %s", input.name, err)
+	}
+
+	ldr, err := loader.NewLoader(overlayDir, r.fSys)
+	if err != nil {
+		return nil, fmt.Errorf("can't load kustomization %s:, %s", input.basePath, err)
+	}
+	defer ldr.Cleanup()
+
+	factory := k8sdeps.NewFactory()
+	kt, err := target.NewKustTarget(ldr, factory.ResmapF, factory.TransformerF)
+	if err != nil {
+		return nil, fmt.Errorf("can't load kustomization %s:, %s", input.basePath, err)
+	}
+	resources, err := kt.MakeCustomizedResMap()
+	if err != nil {
+		return nil, fmt.Errorf("can't render from %s:, %s", input.basePath, err)
+	}
+
+	output := &kustomizeOutput{
+		fileNames: make([]string, 0, len(resources)),
+		files:     make(map[string]string),
+	}
+	for _, res := range resources {
+		fileName := fmt.Sprintf("%s_%s.yaml", strings.ToLower(res.GetKind()), res.GetName())
+		if _, ok := output.files[fileName]; ok {
+			namespace, _ := res.GetFieldValue("metadata.namespace")
+			fileName = fmt.Sprintf("%s_%s", namespace, fileName)
+		}
+		content, err := yaml.Marshal(res.Map())
+		if err != nil {
+			return nil, fmt.Errorf("can't render %s from %s:, %s", res.Id(), input.basePath, err)
+		}
+		output.fileNames = append(output.fileNames, fileName)
+		output.files[fileName] = string(content)
+	}
+	sort.Strings(output.fileNames)
+	return output, nil
+}
+
+// resolveBase returns the local directory of a remote base, cloning it into
+// the cache directory if it is not in the cache yet. Local bases, and remote
+// bases without a cache, are loaded by kustomize directly.
+func (r *kustomizeRenderer) resolveBase(basePath string) (string, error) {
+	if r.cache == nil {
+		return basePath, nil
+	}
+	if _, err := os.Stat(basePath); err == nil {
+		return basePath, nil
+	}
+	repoSpec, err := git.NewRepoSpecFromUrl(basePath)
+	if err != nil {
+		// Not a remote base, kustomize reports the error
+		return basePath, nil
+	}
+
+	// The lookup is done under the lock, so that the concurrent renders of a
+	// base clone it once
+	baseCacheLock.Lock()
+	defer baseCacheLock.Unlock()
+
+	key := "kustomize:" + basePath
+	if cached, ok := r.cache.Get(key); ok {
+		return cached.(string), nil
+	}
+
+	// The url of the base includes its ref, each version of a base has its
+	// own directory
+	sum := sha256.Sum256([]byte(basePath))
+	baseDir := filepath.Join(r.cacheDir, hex.EncodeToString(sum[:]))
+	if err := os.MkdirAll(baseDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create kustomize base cache directory %s. %v", baseDir, err)
+	}
+	if err := r.cloner(repoSpec); err != nil {
+		return "", err
+	}
+	cloneDir := repoSpec.CloneDir().String()
+	defer os.RemoveAll(cloneDir)
+	path, err := filepath.Rel(cloneDir, repoSpec.AbsPath())
+	if err != nil {
+		return "", err
+	}
+
+	// The clone is moved to a new directory of its own, which never holds a
+	// partial clone
+	cloneParent, err := ioutil.TempDir(baseDir, "clone")
+	if err != nil {
+		return "", fmt.Errorf("failed to store %s in kustomize base cache. %v", basePath, err)
+	}
+	repoDir := filepath.Join(cloneParent, "repo")
+	if err := os.Rename(cloneDir, repoDir); err != nil {
+		os.RemoveAll(cloneParent)
+		return "", fmt.Errorf("failed to store %s in kustomize base cache. %v", basePath, err)
+	}
+	removeClones(baseDir, cloneParent)
+	localPath := filepath.Join(repoDir, path)
+	r.cache.Add(key, localPath)
+	return localPath, nil
+}
+
+// removeClones removes the clones in <baseDir> other than <cloneParent>. They
+// were cloned for previous versions of the plans, which are no longer
+// cached. A render of such a version still reading its clone fails, and is
+// retried with the current version of the plan.
+func removeClones(baseDir, cloneParent string) {
+	clones, err := ioutil.ReadDir(baseDir)
+	if err != nil {
+		return
+	}
+	for _, clone := range clones {
+		clonePath := filepath.Join(baseDir, clone.Name())
+		if clonePath != cloneParent {
+			os.RemoveAll(clonePath)
+		}
+	}
+}
+
+// getOverlayParameters reads the overlay parameters from the parameters of
+// the instance in <values>
+func getOverlayParameters(values map[string]interface{}) (*overlayParameters, error) {
+	params := &overlayParameters{}
+	instance, ok := values["instance"].(map[string]interface{})
+	if !ok {
+		return params, nil
+	}
+	rawParams, found, err := unstructured.NestedFieldNoCopy(instance, "spec", "parameters")
+	if err != nil || !found {
+		return params, err
+	}
+	data, err := json.Marshal(rawParams)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, params)
+	if err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// writeOverlay writes the kustomization file and the patches of the
+// overlay into <overlayDir>
+func writeOverlay(overlayDir string, input kustomizeInput, params *overlayParameters) error {
+	base := input.basePath
+	if _, err := os.Stat(base); err == nil {
+		// kustomize accepts only relative paths to local bases
+		absBase, err := filepath.Abs(base)
+		if err != nil {
+			return err
+		}
+		base, err = filepath.Rel(overlayDir, absBase)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The name of the input is always part of the prefix, so that the
+	// resources of different instances do not collide
+	namePrefix := params.NamePrefix
+	if input.name != "" {
+		namePrefix += input.name + "-"
+	}
+	kustomization := types.Kustomization{
+		Bases:             []string{base},
+		Namespace:         input.namespace,
+		NamePrefix:        namePrefix,
+		NameSuffix:        params.NameSuffix,
+		CommonLabels:      params.CommonLabels,
+		CommonAnnotations: params.CommonAnnotations,
+	}
+
+	for i, p := range params.Patches {
+		content, err := yaml.Marshal(p)
+		if err != nil {
+			return err
+		}
+		fileName := fmt.Sprintf("patch-%d.yaml", i)
+		err = ioutil.WriteFile(filepath.Join(overlayDir, fileName), content, 0644)
+		if err != nil {
+			return err
+		}
+		kustomization.PatchesStrategicMerge = append(kustomization.PatchesStrategicMerge, patch.StrategicMerge(fileName))
+	}
+
+	content, err := yaml.Marshal(kustomization)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(overlayDir, constants.KustomizationFileNames[0]), content, 0644)
+}
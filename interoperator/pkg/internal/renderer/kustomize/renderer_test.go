@@ -0,0 +1,215 @@
+package kustomize
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/renderer"
+	"github.com/ghodss/yaml"
+	"github.com/onsi/gomega"
+	"sigs.k8s.io/kustomize/pkg/fs"
+	"sigs.k8s.io/kustomize/pkg/git"
+)
+
+const deployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: postgres
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: postgres
+  template:
+    metadata:
+      labels:
+        app: postgres
+    spec:
+      containers:
+      - name: postgres
+        image: postgres:11
+`
+
+const service = `apiVersion: v1
+kind: Service
+metadata:
+  name: postgres
+spec:
+  selector:
+    app: postgres
+  ports:
+  - port: 5432
+`
+
+const kustomization = `resources:
+- deployment.yaml
+- service.yaml
+`
+
+func TestKustomizeRenderer(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	baseDir, err := ioutil.TempDir("", "kustomize-base")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	defer os.RemoveAll(baseDir)
+	ioutil.WriteFile(filepath.Join(baseDir, "deployment.yaml"), []byte(deployment), 0644)
+	ioutil.WriteFile(filepath.Join(baseDir, "service.yaml"), []byte(service), 0644)
+	ioutil.WriteFile(filepath.Join(baseDir, "kustomization.yaml"), []byte(kustomization), 0644)
+
+	r, err := New()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// Overlay with the defaults
+	output, err := r.Render(NewInput(baseDir, "instance-id", "default", nil))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(output.ListFiles()).To(gomega.Equal([]string{"deployment_instance-id-postgres.yaml", "service_instance-id-postgres.yaml"}))
+	obj := decode(g, output, "service_instance-id-postgres.yaml")
+	g.Expect(obj["metadata"]).To(gomega.HaveKeyWithValue("namespace", "default"))
+
+	// Overlay from the instance parameters
+	values := map[string]interface{}{
+		"instance": map[string]interface{}{
+			"spec": map[string]interface{}{
+				"parameters": map[string]interface{}{
+					"namePrefix": "pg-",
+					"commonLabels": map[string]interface{}{
+						"team": "platform",
+					},
+					"patches": []interface{}{
+						map[string]interface{}{
+							"apiVersion": "apps/v1",
+							"kind":       "Deployment",
+							"metadata": map[string]interface{}{
+								"name": "postgres",
+							},
+							"spec": map[string]interface{}{
+								"replicas": 3,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	output, err = r.Render(NewInput(baseDir, "instance-id", "default", values))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(output.ListFiles()).To(gomega.Equal([]string{"deployment_pg-instance-id-postgres.yaml", "service_pg-instance-id-postgres.yaml"}))
+	obj = decode(g, output, "deployment_pg-instance-id-postgres.yaml")
+	g.Expect(obj["spec"]).To(gomega.HaveKeyWithValue("replicas", float64(3)))
+	g.Expect(obj["metadata"]).To(gomega.HaveKeyWithValue("labels", map[string]interface{}{"team": "platform"}))
+
+	_, err = output.FileContent("missing.yaml")
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	// Errors
+	values["instance"] = map[string]interface{}{
+		"spec": map[string]interface{}{
+			"parameters": map[string]interface{}{
+				"commonLabels": "invalid",
+			},
+		},
+	}
+	_, err = r.Render(NewInput(baseDir, "instance-id", "default", values))
+	g.Expect(err).To(gomega.HaveOccurred())
+	_, err = r.Render(NewInput(filepath.Join(baseDir, "missing"), "instance-id", "default", nil))
+	g.Expect(err).To(gomega.HaveOccurred())
+	_, err = r.Render(nil)
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+type mapCache map[string]interface{}
+
+func (c mapCache) Get(key string) (interface{}, bool) {
+	value, ok := c[key]
+	return value, ok
+}
+
+func (c mapCache) Add(key string, value interface{}) {
+	c[key] = value
+}
+
+func TestKustomizeRendererRemoteBase(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	tmpDir, err := ioutil.TempDir("", "kustomize-remote")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	defer os.RemoveAll(tmpDir)
+
+	clones := 0
+	cloner := func(repoSpec *git.RepoSpec) error {
+		clones++
+		cloneDir := filepath.Join(tmpDir, fmt.Sprintf("clone-%d", clones))
+		baseDir := filepath.Join(cloneDir, "postgresql")
+		g.Expect(os.MkdirAll(baseDir, 0755)).To(gomega.Succeed())
+		ioutil.WriteFile(filepath.Join(baseDir, "service.yaml"), []byte(service), 0644)
+		ioutil.WriteFile(filepath.Join(baseDir, "kustomization.yaml"), []byte("resources:\n- service.yaml\n"), 0644)
+		return git.DoNothingCloner(fs.ConfirmedDir(cloneDir))(repoSpec)
+	}
+
+	cache := make(mapCache)
+	r, err := NewWithCache(cache)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	r.(*kustomizeRenderer).cacheDir = filepath.Join(tmpDir, "cache")
+	r.(*kustomizeRenderer).cloner = cloner
+
+	basePath := "github.com/org/repo//postgresql?ref=v1.0.0"
+	for i := 0; i < 2; i++ {
+		output, err := r.Render(NewInput(basePath, "instance-id", "default", nil))
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(output.ListFiles()).To(gomega.Equal([]string{"service_instance-id-postgres.yaml"}))
+	}
+	g.Expect(clones).To(gomega.Equal(1))
+	g.Expect(cache).To(gomega.HaveKey("kustomize:" + basePath))
+
+	// A new version of the plan clones the base again
+	r, err = NewWithCache(make(mapCache))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	r.(*kustomizeRenderer).cacheDir = filepath.Join(tmpDir, "cache")
+	r.(*kustomizeRenderer).cloner = cloner
+	_, err = r.Render(NewInput(basePath, "instance-id", "default", nil))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(clones).To(gomega.Equal(2))
+
+	// The concurrent renders of a new version of the plan clone the base once
+	r, err = NewWithCache(make(mapCache))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	r.(*kustomizeRenderer).cacheDir = filepath.Join(tmpDir, "cache")
+	r.(*kustomizeRenderer).cloner = cloner
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := r.Render(NewInput(basePath, "instance-id", "default", nil))
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+	}
+	g.Expect(clones).To(gomega.Equal(3))
+
+	// Only the clone of the current version is kept
+	baseDirs, err := ioutil.ReadDir(filepath.Join(tmpDir, "cache"))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(baseDirs).To(gomega.HaveLen(1))
+	repoDirs, err := ioutil.ReadDir(filepath.Join(tmpDir, "cache", baseDirs[0].Name()))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(repoDirs).To(gomega.HaveLen(1))
+}
+
+func decode(g *gomega.GomegaWithT, output renderer.Output, fileName string) map[string]interface{} {
+	content, err := output.FileContent(fileName)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	obj := make(map[string]interface{})
+	err = yaml.Unmarshal([]byte(content), &obj)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	return obj
+}
@@ -0,0 +1,251 @@
+/*
+Copyright 2018 The Service Fabrik Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package renderer
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRenderTimeout = 30 * time.Second
+	defaultMaxOutputSize = 4 * 1024 * 1024
+)
+
+var defaultConcurrency = runtime.NumCPU()
+
+// Limits bound the time and the output size of a single render, and the
+// number of renders running at once. A zero value disables the respective
+// limit.
+type Limits struct {
+	Timeout       time.Duration
+	MaxOutputSize int64
+	Concurrency   int
+}
+
+var (
+	limitsLock sync.Mutex
+	limits     = Limits{
+		Timeout:       defaultRenderTimeout,
+		MaxOutputSize: defaultMaxOutputSize,
+		Concurrency:   defaultConcurrency,
+	}
+	// slots holds a token for each running render, its capacity is the
+	// concurrency it was created for
+	slots chan struct{}
+)
+
+// AddFlags registers the --render-timeout, --render-max-output-size and
+// --render-concurrency flags in <fs>
+func AddFlags(fs *flag.FlagSet) {
+	fs.DurationVar(&limits.Timeout, "render-timeout", defaultRenderTimeout, "Maximum duration of rendering a template. 0 disables the limit.")
+	fs.Int64Var(&limits.MaxOutputSize, "render-max-output-size", defaultMaxOutputSize, "Maximum size in bytes of the output of rendering a template. 0 disables the limit.")
+	fs.IntVar(&limits.Concurrency, "render-concurrency", defaultConcurrency, "Maximum number of templates rendered at once, including the renders abandoned on timeout which are still running. 0 disables the limit.")
+}
+
+// GetLimits returns the limits enforced on the renders
+func GetLimits() Limits {
+	limitsLock.Lock()
+	defer limitsLock.Unlock()
+	return limits
+}
+
+// SetLimits sets the limits enforced on the renders, overriding the
+// --render-timeout and --render-max-output-size flags
+func SetLimits(l Limits) {
+	limitsLock.Lock()
+	defer limitsLock.Unlock()
+	limits = l
+}
+
+// renderSlots returns the channel bounding the number of running renders,
+// nil if the concurrency is not limited. The renders started before a change
+// of the concurrency release their token to the channel they took it from.
+func renderSlots() chan struct{} {
+	limitsLock.Lock()
+	defer limitsLock.Unlock()
+	if limits.Concurrency <= 0 {
+		return nil
+	}
+	if slots == nil || cap(slots) != limits.Concurrency {
+		slots = make(chan struct{}, limits.Concurrency)
+	}
+	return slots
+}
+
+// LimitError is returned when a render exceeds its limits. Rendering the
+// same template again fails the same way, so it is not worth retrying.
+type LimitError struct {
+	message string
+}
+
+func (e *LimitError) Error() string {
+	return e.message
+}
+
+// IsLimitExceeded returns true if <err> is a LimitError
+func IsLimitExceeded(err error) bool {
+	_, ok := err.(*LimitError)
+	return ok
+}
+
+// TimeoutError returns the error for a render taking longer than <timeout>
+func TimeoutError(timeout time.Duration) error {
+	return &LimitError{
+		message: fmt.Sprintf("rendering the template did not complete within %s", timeout),
+	}
+}
+
+// OutputSizeError returns the error for a render producing more than
+// <maxOutputSize> bytes
+func OutputSizeError(maxOutputSize int64) error {
+	return &LimitError{
+		message: fmt.Sprintf("rendered output exceeds the limit of %d bytes", maxOutputSize),
+	}
+}
+
+type limitedRenderer struct {
+	renderer Renderer
+}
+
+// limitedWriter fails the writes once more than <limit> bytes are written,
+// or once the context is done
+type limitedWriter struct {
+	ctx     context.Context
+	w       io.Writer
+	limit   int64
+	written *int64
+}
+
+// NewLimitedWriter returns a writer which fails the writes to <w> with a
+// LimitError once more than <limit> bytes are written, and with the error
+// of <ctx> once it is done. The count of written bytes is shared by the
+// writers of a render. A limit of 0 disables the limit.
+func NewLimitedWriter(ctx context.Context, w io.Writer, limit int64, written *int64) io.Writer {
+	return &limitedWriter{
+		ctx:     ctx,
+		w:       w,
+		limit:   limit,
+		written: written,
+	}
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if err := l.ctx.Err(); err != nil {
+		return 0, err
+	}
+	if l.limit > 0 && *l.written+int64(len(p)) > l.limit {
+		return 0, OutputSizeError(l.limit)
+	}
+	*l.written += int64(len(p))
+	return l.w.Write(p)
+}
+
+// NewLimitedRenderer returns a Renderer which enforces the limits returned
+// by GetLimits on the renders of <r>. A render exceeding the timeout
+// returns a LimitError right away. If <r> is a ContextRenderer, the render
+// is cancelled as well. Other renders, like the helm and jsonnet renders,
+// can not be interrupted, they are abandoned and run to completion in the
+// background. As a render keeps its slot of the concurrency limit until it
+// completes, the abandoned renders can not pile up: once every slot is
+// taken, the next renders wait for a slot and time out.
+func NewLimitedRenderer(r Renderer) Renderer {
+	return &limitedRenderer{
+		renderer: r,
+	}
+}
+
+type renderResult struct {
+	output Output
+	err    error
+}
+
+// Render renders the input with the wrapped renderer within the limits
+func (r *limitedRenderer) Render(input Input) (Output, error) {
+	l := GetLimits()
+
+	var output Output
+	var err error
+	slots := renderSlots()
+	if l.Timeout > 0 {
+		timer := time.NewTimer(l.Timeout)
+		defer timer.Stop()
+		if slots != nil {
+			select {
+			case slots <- struct{}{}:
+			case <-timer.C:
+				return nil, TimeoutError(l.Timeout)
+			}
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		done := make(chan renderResult, 1)
+		go func() {
+			if slots != nil {
+				defer func() { <-slots }()
+			}
+			output, err := r.render(ctx, input)
+			done <- renderResult{output: output, err: err}
+		}()
+		select {
+		case result := <-done:
+			output, err = result.output, result.err
+		case <-timer.C:
+			return nil, TimeoutError(l.Timeout)
+		}
+	} else {
+		if slots != nil {
+			slots <- struct{}{}
+			defer func() { <-slots }()
+		}
+		output, err = r.render(context.Background(), input)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if l.MaxOutputSize > 0 {
+		files, err := output.ListFiles()
+		if err != nil {
+			return nil, err
+		}
+		var size int64
+		for _, file := range files {
+			content, err := output.FileContent(file)
+			if err != nil {
+				return nil, err
+			}
+			size += int64(len(content))
+			if size > l.MaxOutputSize {
+				return nil, OutputSizeError(l.MaxOutputSize)
+			}
+		}
+	}
+	return output, nil
+}
+
+func (r *limitedRenderer) render(ctx context.Context, input Input) (Output, error) {
+	if contextRenderer, ok := r.renderer.(ContextRenderer); ok {
+		return contextRenderer.RenderContext(ctx, input)
+	}
+	return r.renderer.Render(input)
+}
@@ -0,0 +1,155 @@
+package renderer
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+)
+
+type testOutput map[string]string
+
+func (o testOutput) FileContent(filename string) (string, error) {
+	content, ok := o[filename]
+	if !ok {
+		return "", fmt.Errorf("file %s not found", filename)
+	}
+	return content, nil
+}
+
+func (o testOutput) ListFiles() ([]string, error) {
+	files := make([]string, 0, len(o))
+	for file := range o {
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+type testRenderer struct {
+	delay  time.Duration
+	output Output
+}
+
+func (r *testRenderer) Render(input Input) (Output, error) {
+	time.Sleep(r.delay)
+	return r.output, nil
+}
+
+// testContextRenderer blocks until the render is cancelled
+type testContextRenderer struct {
+	cancelled chan struct{}
+}
+
+func (r *testContextRenderer) Render(input Input) (Output, error) {
+	return nil, fmt.Errorf("not cancellable")
+}
+
+func (r *testContextRenderer) RenderContext(ctx context.Context, input Input) (Output, error) {
+	<-ctx.Done()
+	close(r.cancelled)
+	return nil, ctx.Err()
+}
+
+func TestLimitedRenderer(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	defer SetLimits(GetLimits())
+
+	SetLimits(Limits{
+		Timeout:       100 * time.Millisecond,
+		MaxOutputSize: 10,
+	})
+
+	output := testOutput{"a.yaml": "12345", "b.yaml": "12345"}
+	r := NewLimitedRenderer(&testRenderer{output: output})
+	rendered, err := r.Render(nil)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(rendered).To(gomega.Equal(output))
+
+	output["c.yaml"] = "1"
+	_, err = r.Render(nil)
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(IsLimitExceeded(err)).To(gomega.BeTrue())
+	g.Expect(err.Error()).To(gomega.ContainSubstring("10 bytes"))
+
+	r = NewLimitedRenderer(&testRenderer{delay: time.Second, output: testOutput{}})
+	start := time.Now()
+	_, err = r.Render(nil)
+	g.Expect(time.Since(start)).To(gomega.BeNumerically("<", time.Second))
+	g.Expect(IsLimitExceeded(err)).To(gomega.BeTrue())
+	g.Expect(err.Error()).To(gomega.ContainSubstring("100ms"))
+
+	// Renders supporting cancellation are stopped on timeout
+	contextRenderer := &testContextRenderer{cancelled: make(chan struct{})}
+	_, err = NewLimitedRenderer(contextRenderer).Render(nil)
+	g.Expect(IsLimitExceeded(err)).To(gomega.BeTrue())
+	g.Eventually(contextRenderer.cancelled).Should(gomega.BeClosed())
+
+	// Limits disabled
+	SetLimits(Limits{})
+	output["d.yaml"] = strings.Repeat("x", 100)
+	r = NewLimitedRenderer(&testRenderer{delay: 150 * time.Millisecond, output: output})
+	_, err = r.Render(nil)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	g.Expect(IsLimitExceeded(fmt.Errorf("other error"))).To(gomega.BeFalse())
+}
+
+func TestLimitedRendererConcurrency(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	defer SetLimits(GetLimits())
+
+	SetLimits(Limits{
+		Timeout:     100 * time.Millisecond,
+		Concurrency: 1,
+	})
+
+	// The abandoned render keeps its slot until it completes
+	slow := NewLimitedRenderer(&testRenderer{delay: 400 * time.Millisecond, output: testOutput{}})
+	_, err := slow.Render(nil)
+	g.Expect(IsLimitExceeded(err)).To(gomega.BeTrue())
+
+	fast := NewLimitedRenderer(&testRenderer{output: testOutput{}})
+	_, err = fast.Render(nil)
+	g.Expect(IsLimitExceeded(err)).To(gomega.BeTrue())
+
+	g.Eventually(func() error {
+		_, err := fast.Render(nil)
+		return err
+	}).Should(gomega.Succeed())
+}
+
+func TestLimitedWriter(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	var written int64
+	buf := new(bytes.Buffer)
+	ctx, cancel := context.WithCancel(context.Background())
+	w1 := NewLimitedWriter(ctx, buf, 5, &written)
+	w2 := NewLimitedWriter(ctx, buf, 5, &written)
+	_, err := w1.Write([]byte("123"))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	_, err = w2.Write([]byte("456"))
+	g.Expect(IsLimitExceeded(err)).To(gomega.BeTrue())
+	_, err = w2.Write([]byte("45"))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(buf.String()).To(gomega.Equal("12345"))
+
+	cancel()
+	_, err = NewLimitedWriter(ctx, buf, 0, &written).Write([]byte("6"))
+	g.Expect(err).To(gomega.Equal(context.Canceled))
+}
+
+func TestAddFlags(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	defer SetLimits(GetLimits())
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	AddFlags(fs)
+	g.Expect(fs.Parse([]string{"--render-timeout=1m", "--render-max-output-size=100", "--render-concurrency=2"})).To(gomega.Succeed())
+	g.Expect(GetLimits()).To(gomega.Equal(Limits{Timeout: time.Minute, MaxOutputSize: 100, Concurrency: 2}))
+}
@@ -16,12 +16,22 @@ limitations under the License.
 
 package renderer
 
+import (
+	"context"
+)
+
 // Renderer is an interface for rendering templates from path, name, namespace and values.
 type Renderer interface {
 	// TODO Consider using streams (io.Writer or io.Reader) in the API instead of buffers.
 	Render(input Input) (Output, error)
 }
 
+// ContextRenderer is implemented by renderers which stop rendering when
+// <ctx> is done, e.g. when the render exceeds its timeout
+type ContextRenderer interface {
+	RenderContext(ctx context.Context, input Input) (Output, error)
+}
+
 // Input holds input to the renderer
 type Input interface{}
 
@@ -31,3 +41,12 @@ type Output interface {
 	FileContent(filename string) (string, error)
 	ListFiles() ([]string, error)
 }
+
+// Cache stores the objects parsed by the renderers, e.g. parsed templates
+// or loaded charts, so that they are reused across reconciles. The keys are
+// derived from the content the objects are parsed from, e.g. its digest.
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	Get(key string) (interface{}, bool)
+	Add(key string, value interface{})
+}
@@ -0,0 +1,157 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	osbv1alpha1 "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis/osb/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	kubernetes "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	defaultDeletionField = "status.state"
+	defaultDeletionValue = "delete"
+)
+
+// defaultDeletionStrategies apply to the resources without a deletion
+// strategy in the plan. The service fabrik operators delete their resources
+// once the state of the resource is set to delete.
+var defaultDeletionStrategies = []osbv1alpha1.DeletionStrategy{
+	{
+		APIVersion: "deployment.servicefabrik.io/v1alpha1",
+		Type:       osbv1alpha1.FieldStrategy,
+		Field:      defaultDeletionField,
+		Value:      defaultDeletionValue,
+		From:       []string{"succeeded", "failed"},
+	},
+	{
+		APIVersion: "bind.servicefabrik.io/v1alpha1",
+		Type:       osbv1alpha1.FieldStrategy,
+		Field:      defaultDeletionField,
+		Value:      defaultDeletionValue,
+		From:       []string{"succeeded", "failed"},
+	},
+}
+
+// getDeletionStrategy returns the strategy to delete the resource. The
+// strategies for the kind of the resource take precedence over the ones for
+// its apiVersion, and the strategies of the plan over the default ones.
+// Resources without a strategy are deleted.
+func getDeletionStrategy(strategies []osbv1alpha1.DeletionStrategy, resource osbv1alpha1.Source) osbv1alpha1.DeletionStrategy {
+	for _, candidates := range [][]osbv1alpha1.DeletionStrategy{strategies, defaultDeletionStrategies} {
+		for _, strategy := range candidates {
+			if strategy.APIVersion == resource.APIVersion && strategy.Kind == resource.Kind {
+				return strategy
+			}
+		}
+		for _, strategy := range candidates {
+			if strategy.APIVersion == resource.APIVersion && strategy.Kind == "" {
+				return strategy
+			}
+		}
+	}
+	return osbv1alpha1.DeletionStrategy{
+		APIVersion: resource.APIVersion,
+		Kind:       resource.Kind,
+		Type:       osbv1alpha1.DeleteStrategy,
+	}
+}
+
+// deleteSubResource triggers the deletion of the resource with the strategy
+// and returns the progress of the deletion. A NotFound error is returned
+// once the resource is deleted.
+func (r resourceManager) deleteSubResource(client kubernetes.Client, resource *unstructured.Unstructured, strategy osbv1alpha1.DeletionStrategy) (string, error) {
+	if strategy.Type == osbv1alpha1.DeleteStrategy || strategy.Type == "" {
+		err := client.Delete(context.TODO(), resource)
+		if err != nil {
+			return "", err
+		}
+		return "delete triggered", nil
+	}
+
+	namespacedName := types.NamespacedName{
+		Name:      resource.GetName(),
+		Namespace: resource.GetNamespace(),
+	}
+	err := client.Get(context.TODO(), namespacedName, resource)
+	if err != nil {
+		return "", err
+	}
+
+	switch strategy.Type {
+	case osbv1alpha1.FieldStrategy:
+		field := strategy.Field
+		if field == "" {
+			field = defaultDeletionField
+		}
+		value := strategy.Value
+		if value == "" {
+			value = defaultDeletionValue
+		}
+		fields := strings.Split(field, ".")
+		current, found, err := unstructured.NestedFieldNoCopy(resource.Object, fields...)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s of resource %v. %v", field, resource, err)
+		}
+		currentValue := fmt.Sprint(current)
+		if found && currentValue == value {
+			return fmt.Sprintf("%s is %s, waiting for the resource to be deleted", field, value), nil
+		}
+		if found && len(strategy.From) > 0 && !containsString(strategy.From, currentValue) {
+			return fmt.Sprintf("%s is %s, waiting for it to be one of %s", field, currentValue, strings.Join(strategy.From, ", ")), nil
+		}
+		err = unstructured.SetNestedField(resource.Object, value, fields...)
+		if err != nil {
+			return "", fmt.Errorf("failed to set %s of resource %v. %v", field, resource, err)
+		}
+		err = client.Update(context.TODO(), resource)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s set to %s", field, value), nil
+	case osbv1alpha1.AnnotationStrategy:
+		if strategy.Field == "" {
+			return "", fmt.Errorf("annotation not set in the %s deletion strategy of %s", strategy.Type, strategy.APIVersion)
+		}
+		annotations := resource.GetAnnotations()
+		if current, ok := annotations[strategy.Field]; ok && current == strategy.Value {
+			return fmt.Sprintf("annotation %s is %s, waiting for the resource to be deleted", strategy.Field, strategy.Value), nil
+		}
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[strategy.Field] = strategy.Value
+		resource.SetAnnotations(annotations)
+		err = client.Update(context.TODO(), resource)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("annotation %s set to %s", strategy.Field, strategy.Value), nil
+	case osbv1alpha1.FinalizerStrategy:
+		if resource.GetDeletionTimestamp() == nil {
+			err = client.Delete(context.TODO(), resource)
+			if err != nil {
+				return "", err
+			}
+		}
+		finalizers := resource.GetFinalizers()
+		if len(finalizers) == 0 {
+			return "delete triggered", nil
+		}
+		return fmt.Sprintf("waiting for the finalizers %s", strings.Join(finalizers, ", ")), nil
+	}
+	return "", fmt.Errorf("unknown deletion strategy %s for resource %v", strategy.Type, resource)
+}
+
+func containsString(slice []string, s string) bool {
+	for _, item := range slice {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
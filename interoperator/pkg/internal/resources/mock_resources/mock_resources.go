@@ -82,6 +82,36 @@ func (mr *MockResourceManagerMockRecorder) ReconcileResources(sourceClient, targ
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileResources", reflect.TypeOf((*MockResourceManager)(nil).ReconcileResources), sourceClient, targetClient, expectedResources, lastResources)
 }
 
+// DryRunResources mocks base method
+func (m *MockResourceManager) DryRunResources(targetClient client.Client, expectedResources []*unstructured.Unstructured, lastResources []v1alpha1.Source) ([]v1alpha1.ResourceChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DryRunResources", targetClient, expectedResources, lastResources)
+	ret0, _ := ret[0].([]v1alpha1.ResourceChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DryRunResources indicates an expected call of DryRunResources
+func (mr *MockResourceManagerMockRecorder) DryRunResources(targetClient, expectedResources, lastResources interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DryRunResources", reflect.TypeOf((*MockResourceManager)(nil).DryRunResources), targetClient, expectedResources, lastResources)
+}
+
+// ComputeSources mocks base method
+func (m *MockResourceManager) ComputeSources(client client.Client, instanceID, bindingID, serviceID, planID, action, namespace string) ([]v1alpha1.Source, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ComputeSources", client, instanceID, bindingID, serviceID, planID, action, namespace)
+	ret0, _ := ret[0].([]v1alpha1.Source)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ComputeSources indicates an expected call of ComputeSources
+func (mr *MockResourceManagerMockRecorder) ComputeSources(client, instanceID, bindingID, serviceID, planID, action, namespace interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ComputeSources", reflect.TypeOf((*MockResourceManager)(nil).ComputeSources), client, instanceID, bindingID, serviceID, planID, action, namespace)
+}
+
 // ComputeStatus mocks base method
 func (m *MockResourceManager) ComputeStatus(sourceClient, targetClient client.Client, instanceID, bindingID, serviceID, planID, action, namespace string) (*properties.Status, error) {
 	m.ctrl.T.Helper()
@@ -97,17 +127,33 @@ func (mr *MockResourceManagerMockRecorder) ComputeStatus(sourceClient, targetCli
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ComputeStatus", reflect.TypeOf((*MockResourceManager)(nil).ComputeStatus), sourceClient, targetClient, instanceID, bindingID, serviceID, planID, action, namespace)
 }
 
-// DeleteSubResources mocks base method
-func (m *MockResourceManager) DeleteSubResources(client client.Client, subResources []v1alpha1.Source) ([]v1alpha1.Source, error) {
+// ComputeReadiness mocks base method
+func (m *MockResourceManager) ComputeReadiness(targetClient client.Client, resources []v1alpha1.Source) (*properties.Status, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteSubResources", client, subResources)
-	ret0, _ := ret[0].([]v1alpha1.Source)
+	ret := m.ctrl.Call(m, "ComputeReadiness", targetClient, resources)
+	ret0, _ := ret[0].(*properties.Status)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
+// ComputeReadiness indicates an expected call of ComputeReadiness
+func (mr *MockResourceManagerMockRecorder) ComputeReadiness(targetClient, resources interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ComputeReadiness", reflect.TypeOf((*MockResourceManager)(nil).ComputeReadiness), targetClient, resources)
+}
+
+// DeleteSubResources mocks base method
+func (m *MockResourceManager) DeleteSubResources(client client.Client, subResources []v1alpha1.Source, strategies []v1alpha1.DeletionStrategy) ([]v1alpha1.Source, []v1alpha1.DeletionStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSubResources", client, subResources, strategies)
+	ret0, _ := ret[0].([]v1alpha1.Source)
+	ret1, _ := ret[1].([]v1alpha1.DeletionStatus)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
 // DeleteSubResources indicates an expected call of DeleteSubResources
-func (mr *MockResourceManagerMockRecorder) DeleteSubResources(client, subResources interface{}) *gomock.Call {
+func (mr *MockResourceManagerMockRecorder) DeleteSubResources(client, subResources, strategies interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSubResources", reflect.TypeOf((*MockResourceManager)(nil).DeleteSubResources), client, subResources)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSubResources", reflect.TypeOf((*MockResourceManager)(nil).DeleteSubResources), client, subResources, strategies)
 }
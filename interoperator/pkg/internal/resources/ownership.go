@@ -0,0 +1,119 @@
+package resources
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// OwnerKindLabel is the kind of the owner of a resource which can not
+	// reference its owner, as it is cluster scoped, in another namespace or
+	// in another cluster
+	OwnerKindLabel = "interoperator.servicefabrik.io/owner-kind"
+	// OwnerNamespaceLabel is the namespace of the owner of the resource
+	OwnerNamespaceLabel = "interoperator.servicefabrik.io/owner-namespace"
+	// OwnerNameLabel is the name of the owner of the resource
+	OwnerNameLabel = "interoperator.servicefabrik.io/owner-name"
+)
+
+// setNamespace sets the namespace of a rendered resource. A namespace set
+// by the template is kept, an empty one makes the resource cluster scoped.
+// Other resources are created in <namespace>, unless the mapper reports
+// their kind as cluster scoped.
+func setNamespace(obj *unstructured.Unstructured, namespace string, mapper meta.RESTMapper) {
+	if isClusterScoped(mapper, obj.GroupVersionKind()) {
+		unstructured.RemoveNestedField(obj.Object, "metadata", "namespace")
+		return
+	}
+	if _, explicit, _ := unstructured.NestedFieldNoCopy(obj.Object, "metadata", "namespace"); explicit {
+		if obj.GetNamespace() == "" {
+			unstructured.RemoveNestedField(obj.Object, "metadata", "namespace")
+		}
+		return
+	}
+	obj.SetNamespace(namespace)
+}
+
+// isClusterScoped returns true if the mapper maps the kind to a cluster
+// scoped resource. The discovery information is refreshed once if the kind
+// is not found, as its custom resource definition may have been created
+// since. Unknown kinds are not cluster scoped.
+func isClusterScoped(mapper meta.RESTMapper, gvk schema.GroupVersionKind) bool {
+	if mapper == nil {
+		return false
+	}
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		if resettable, ok := mapper.(interface{ Reset() }); ok {
+			resettable.Reset()
+			mapping, err = mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		}
+	}
+	if err != nil {
+		return false
+	}
+	return mapping.Scope.Name() == meta.RESTScopeNameRoot
+}
+
+// setOwner makes <owner> the owner of the resource. A resource in the
+// namespace of its owner references it, so that it is garbage collected
+// along with its owner. Other resources are labeled with their owner and
+// are only deleted by the interoperator.
+func setOwner(owner metav1.Object, obj *unstructured.Unstructured, scheme *runtime.Scheme) error {
+	if obj.GetNamespace() == owner.GetNamespace() {
+		return controllerutil.SetControllerReference(owner, obj, scheme)
+	}
+	return setOwnerLabels(owner, obj, scheme)
+}
+
+// SetOwnerLabels labels all the resources with their owner. Resources
+// deployed to another cluster than their owner can not reference it, they
+// would be garbage collected in their cluster.
+func SetOwnerLabels(owner metav1.Object, resources []*unstructured.Unstructured, scheme *runtime.Scheme) error {
+	for _, obj := range resources {
+		if err := setOwnerLabels(owner, obj, scheme); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setOwnerLabels(owner metav1.Object, obj *unstructured.Unstructured, scheme *runtime.Scheme) error {
+	ownerObject, ok := owner.(runtime.Object)
+	if !ok {
+		return fmt.Errorf("%T is not a runtime.Object, cannot call setOwnerLabels", owner)
+	}
+	gvk, err := apiutil.GVKForObject(ownerObject, scheme)
+	if err != nil {
+		return err
+	}
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[OwnerKindLabel] = gvk.Kind
+	labels[OwnerNamespaceLabel] = owner.GetNamespace()
+	labels[OwnerNameLabel] = owner.GetName()
+	obj.SetLabels(labels)
+	return nil
+}
+
+// OwnerOf returns the owner of <kind> of a resource labeled with its owner
+func OwnerOf(obj metav1.Object, kind string) (types.NamespacedName, bool) {
+	labels := obj.GetLabels()
+	if labels[OwnerKindLabel] != kind || labels[OwnerNameLabel] == "" {
+		return types.NamespacedName{}, false
+	}
+	return types.NamespacedName{
+		Namespace: labels[OwnerNamespaceLabel],
+		Name:      labels[OwnerNameLabel],
+	}, true
+}
@@ -0,0 +1,199 @@
+package resources
+
+import (
+	"reflect"
+	"testing"
+
+	osbv1alpha1 "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis/osb/v1alpha1"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/dynamic"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func Test_setNamespace(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		wantNamespace string
+		wantKey       bool
+	}{
+		{
+			name: "namespaced resource",
+			content: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config`,
+			wantNamespace: "default",
+			wantKey:       true,
+		},
+		{
+			name: "explicit namespace",
+			content: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  namespace: monitoring`,
+			wantNamespace: "monitoring",
+			wantKey:       true,
+		},
+		{
+			name: "explicit empty namespace",
+			content: `apiVersion: deployment.servicefabrik.io/v1alpha1
+kind: ClusterDirector
+metadata:
+  name: director
+  namespace: ""`,
+			wantNamespace: "",
+			wantKey:       false,
+		},
+		{
+			name: "custom cluster scoped kind",
+			content: `apiVersion: deployment.servicefabrik.io/v1alpha1
+kind: ClusterDirector
+metadata:
+  name: director`,
+			wantNamespace: "",
+			wantKey:       false,
+		},
+		{
+			name: "unknown kind",
+			content: `apiVersion: deployment.servicefabrik.io/v1alpha1
+kind: Director
+metadata:
+  name: director`,
+			wantNamespace: "default",
+			wantKey:       true,
+		},
+		{
+			name: "built-in cluster scoped kind",
+			content: `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: role
+  namespace: default`,
+			wantNamespace: "",
+			wantKey:       false,
+		},
+	}
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{})
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Group: "deployment.servicefabrik.io", Version: "v1alpha1", Kind: "ClusterDirector"}, meta.RESTScopeRoot)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects, err := dynamic.StringToUnstructured(tt.content)
+			if err != nil {
+				t.Fatalf("StringToUnstructured() error = %v", err)
+			}
+			obj := objects[0]
+			setNamespace(obj, "default", mapper)
+			if got := obj.GetNamespace(); got != tt.wantNamespace {
+				t.Errorf("setNamespace() namespace = %v, want %v", got, tt.wantNamespace)
+			}
+			metadata := obj.Object["metadata"].(map[string]interface{})
+			if _, got := metadata["namespace"]; got != tt.wantKey {
+				t.Errorf("setNamespace() namespace set = %v, want %v", got, tt.wantKey)
+			}
+		})
+	}
+}
+
+func Test_setOwner(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := osbv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("AddToScheme() error = %v", err)
+	}
+	owner := &osbv1alpha1.SFServiceInstance{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "instance-id",
+			Namespace: "default",
+			UID:       "instance-uid",
+		},
+	}
+	objects, err := dynamic.StringToUnstructured(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  namespace: default
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  namespace: monitoring
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: instance-id`)
+	if err != nil {
+		t.Fatalf("StringToUnstructured() error = %v", err)
+	}
+	for _, obj := range objects {
+		if err := setOwner(owner, obj, scheme); err != nil {
+			t.Fatalf("setOwner() error = %v", err)
+		}
+	}
+
+	references := objects[0].GetOwnerReferences()
+	if len(references) != 1 || references[0].UID != owner.GetUID() {
+		t.Errorf("setOwner() owner references = %v, want reference to %s", references, owner.GetName())
+	}
+	if _, ok := OwnerOf(objects[0], "SFServiceInstance"); ok {
+		t.Errorf("setOwner() labeled resource in the namespace of its owner")
+	}
+	want := types.NamespacedName{Namespace: "default", Name: "instance-id"}
+	for _, obj := range objects[1:] {
+		if len(obj.GetOwnerReferences()) != 0 {
+			t.Errorf("setOwner() owner references = %v, want none", obj.GetOwnerReferences())
+		}
+		got, ok := OwnerOf(obj, "SFServiceInstance")
+		if !ok || !reflect.DeepEqual(got, want) {
+			t.Errorf("OwnerOf() = %v, %v, want %v", got, ok, want)
+		}
+		if _, ok := OwnerOf(obj, "SFServiceBinding"); ok {
+			t.Errorf("OwnerOf() found owner of another kind")
+		}
+	}
+}
+
+func TestSetOwnerLabels(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := osbv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("AddToScheme() error = %v", err)
+	}
+	owner := &osbv1alpha1.SFServiceBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "binding-id",
+			Namespace: "default",
+		},
+	}
+	objects, err := dynamic.StringToUnstructured(`apiVersion: v1
+kind: Secret
+metadata:
+  name: credentials
+  namespace: default
+  labels:
+    app: postgresql`)
+	if err != nil {
+		t.Fatalf("StringToUnstructured() error = %v", err)
+	}
+	if err := SetOwnerLabels(owner, objects, scheme); err != nil {
+		t.Fatalf("SetOwnerLabels() error = %v", err)
+	}
+	if len(objects[0].GetOwnerReferences()) != 0 {
+		t.Errorf("SetOwnerLabels() owner references = %v, want none", objects[0].GetOwnerReferences())
+	}
+	got, ok := OwnerOf(objects[0], "SFServiceBinding")
+	want := types.NamespacedName{Namespace: "default", Name: "binding-id"}
+	if !ok || got != want {
+		t.Errorf("OwnerOf() = %v, %v, want %v", got, ok, want)
+	}
+	if objects[0].GetLabels()["app"] != "postgresql" {
+		t.Errorf("SetOwnerLabels() labels = %v, want app label kept", objects[0].GetLabels())
+	}
+}
@@ -0,0 +1,230 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	osbv1alpha1 "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis/osb/v1alpha1"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/properties"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	kubernetes "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ReadyWithoutConditionsAnnotation marks a custom resource as ready once it
+// exists if it does not report a Ready condition. Set it to "true" in the
+// templates of the plan for the custom resources without conditions.
+const ReadyWithoutConditionsAnnotation = "interoperator.servicefabrik.io/ready-without-conditions"
+
+// states reported by computeReadiness
+const (
+	stateInProgress = "in progress"
+	stateSucceeded  = "succeeded"
+	stateFailed     = "failed"
+)
+
+// computeReadiness computes the status of the resources from their
+// readiness, for the plans without sources or status template. The
+// resources succeed once all of them are ready and fail if one of them
+// fails. They are deleted once none of them is found.
+func computeReadiness(targetClient kubernetes.Client, resources []osbv1alpha1.Source) (*properties.Status, error) {
+	var failures []string
+	pending := false
+	remaining := 0
+	for _, resource := range resources {
+		obj := &unstructured.Unstructured{}
+		obj.SetKind(resource.Kind)
+		obj.SetAPIVersion(resource.APIVersion)
+		namespacedName := types.NamespacedName{
+			Name:      resource.Name,
+			Namespace: resource.Namespace,
+		}
+		err := targetClient.Get(context.TODO(), namespacedName, obj)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				pending = true
+				continue
+			}
+			return nil, err
+		}
+		remaining++
+		if reason, failed := hasFailed(obj); failed {
+			failures = append(failures, reason)
+		} else if !isReady(obj) {
+			pending = true
+		}
+	}
+
+	state := stateSucceeded
+	if len(failures) > 0 {
+		state = stateFailed
+	} else if pending {
+		state = stateInProgress
+	}
+	deleteState := stateSucceeded
+	if remaining > 0 {
+		deleteState = stateInProgress
+	}
+	reason := strings.Join(failures, "; ")
+	return &properties.Status{
+		Provision: properties.InstanceStatus{
+			State: state,
+			Error: reason,
+		},
+		Bind: properties.GenericStatus{
+			State: state,
+			Error: reason,
+		},
+		Deprovision: properties.GenericStatus{
+			State: deleteState,
+		},
+		Unbind: properties.GenericStatus{
+			State: deleteState,
+		},
+		Sources: resources,
+	}, nil
+}
+
+// hasFailed returns the reason if the resource failed and will not become
+// ready without a change
+func hasFailed(obj *unstructured.Unstructured) (string, bool) {
+	key := resourceKey(obj.GetKind(), obj.GetName())
+	gvk := obj.GroupVersionKind()
+	switch gvk.Group {
+	case "apps", "extensions":
+		if gvk.Kind == "Deployment" {
+			status, _ := getCondition(obj, "Progressing")
+			if status == "False" && conditionField(obj, "Progressing", "reason") == "ProgressDeadlineExceeded" {
+				return fmt.Sprintf("%s failed to progress. %s", key, conditionField(obj, "Progressing", "message")), true
+			}
+		}
+	case "":
+		phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+		switch {
+		case gvk.Kind == "Pod" && phase == "Failed":
+			return fmt.Sprintf("%s failed", key), true
+		case gvk.Kind == "PersistentVolumeClaim" && phase == "Lost":
+			return fmt.Sprintf("%s lost its volume", key), true
+		}
+	}
+
+	if hasCondition(obj, "Failed") {
+		return fmt.Sprintf("%s failed. %s", key, conditionField(obj, "Failed", "message")), true
+	}
+	return "", false
+}
+
+// isReady returns true if the resource is ready to be used by the resources
+// of the later waves. Workloads are ready once all their replicas are
+// available, jobs once they complete. Resources reporting a Ready condition
+// are ready once it is True. Other built-in resources are ready once they
+// exist, custom resources only if they set the
+// ReadyWithoutConditionsAnnotation, as their readiness is unknown.
+func isReady(obj *unstructured.Unstructured) bool {
+	gvk := obj.GroupVersionKind()
+	switch gvk.Group {
+	case "apps", "extensions":
+		switch gvk.Kind {
+		case "Deployment":
+			replicas := nestedInt(obj, 1, "spec", "replicas")
+			return isObserved(obj) &&
+				nestedInt(obj, 0, "status", "updatedReplicas") >= replicas &&
+				nestedInt(obj, 0, "status", "availableReplicas") >= replicas
+		case "StatefulSet", "ReplicaSet":
+			replicas := nestedInt(obj, 1, "spec", "replicas")
+			return isObserved(obj) && nestedInt(obj, 0, "status", "readyReplicas") >= replicas
+		case "DaemonSet":
+			return isObserved(obj) &&
+				nestedInt(obj, 0, "status", "numberReady") >= nestedInt(obj, 0, "status", "desiredNumberScheduled")
+		}
+	case "batch":
+		if gvk.Kind == "Job" {
+			return hasCondition(obj, "Complete") ||
+				nestedInt(obj, 0, "status", "succeeded") >= nestedInt(obj, 1, "spec", "completions")
+		}
+	case "":
+		switch gvk.Kind {
+		case "Pod":
+			phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+			return phase == "Succeeded" || hasCondition(obj, "Ready")
+		case "PersistentVolumeClaim":
+			phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+			return phase == "Bound"
+		case "Service":
+			serviceType, _, _ := unstructured.NestedString(obj.Object, "spec", "type")
+			if serviceType != "LoadBalancer" {
+				return true
+			}
+			ingress, _, _ := unstructured.NestedSlice(obj.Object, "status", "loadBalancer", "ingress")
+			return len(ingress) > 0
+		}
+	}
+
+	status, found := getCondition(obj, "Ready")
+	if found {
+		return status == "True"
+	}
+	return isBuiltIn(gvk.Group) || obj.GetAnnotations()[ReadyWithoutConditionsAnnotation] == "true"
+}
+
+// isBuiltIn returns true if the group is served by kubernetes. The groups
+// of custom resources are fully qualified domain names.
+func isBuiltIn(group string) bool {
+	return !strings.Contains(group, ".") || strings.HasSuffix(group, ".k8s.io")
+}
+
+// isObserved returns true if the status of the resource reflects its spec
+func isObserved(obj *unstructured.Unstructured) bool {
+	return nestedInt(obj, 0, "status", "observedGeneration") >= nestedInt(obj, 0, "metadata", "generation")
+}
+
+// hasCondition returns true if the condition of the resource is True
+func hasCondition(obj *unstructured.Unstructured, conditionType string) bool {
+	status, _ := getCondition(obj, conditionType)
+	return status == "True"
+}
+
+// getCondition returns the status of the condition of the resource and
+// whether the resource reports the condition
+func getCondition(obj *unstructured.Unstructured, conditionType string) (interface{}, bool) {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, condition := range conditions {
+		if c, ok := condition.(map[string]interface{}); ok && c["type"] == conditionType {
+			return c["status"], true
+		}
+	}
+	return nil, false
+}
+
+// conditionField returns the field of the condition of the resource
+func conditionField(obj *unstructured.Unstructured, conditionType, field string) string {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, condition := range conditions {
+		if c, ok := condition.(map[string]interface{}); ok && c["type"] == conditionType {
+			value, _ := c[field].(string)
+			return value
+		}
+	}
+	return ""
+}
+
+// nestedInt returns the integer field of the resource or <defaultValue> if
+// it is not set
+func nestedInt(obj *unstructured.Unstructured, defaultValue int64, fields ...string) int64 {
+	value, found, err := unstructured.NestedFieldNoCopy(obj.Object, fields...)
+	if !found || err != nil {
+		return defaultValue
+	}
+	switch v := value.(type) {
+	case int64:
+		return v
+	case int:
+		return int64(v)
+	case float64:
+		return int64(v)
+	}
+	return defaultValue
+}
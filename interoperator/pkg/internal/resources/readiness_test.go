@@ -0,0 +1,354 @@
+package resources
+
+import (
+	"context"
+	"testing"
+
+	osbv1alpha1 "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis/osb/v1alpha1"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/dynamic"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_isReady(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{
+			name: "secret",
+			content: `apiVersion: v1
+kind: Secret
+metadata:
+  name: credentials`,
+			want: true,
+		},
+		{
+			name: "statefulset just created",
+			content: `apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: db
+  generation: 1
+spec:
+  replicas: 3`,
+			want: false,
+		},
+		{
+			name: "statefulset with ready replicas",
+			content: `apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: db
+  generation: 1
+spec:
+  replicas: 3
+status:
+  observedGeneration: 1
+  readyReplicas: 3`,
+			want: true,
+		},
+		{
+			name: "deployment with outdated status",
+			content: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  generation: 2
+status:
+  observedGeneration: 1
+  updatedReplicas: 1
+  availableReplicas: 1`,
+			want: false,
+		},
+		{
+			name: "running job",
+			content: `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: schema
+status:
+  active: 1`,
+			want: false,
+		},
+		{
+			name: "completed job",
+			content: `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: schema
+status:
+  succeeded: 1
+  conditions:
+  - type: Complete
+    status: "True"`,
+			want: true,
+		},
+		{
+			name: "pending claim",
+			content: `apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: data
+status:
+  phase: Pending`,
+			want: false,
+		},
+		{
+			name: "custom resource not ready",
+			content: `apiVersion: deployment.servicefabrik.io/v1alpha1
+kind: Director
+metadata:
+  name: instance-id
+status:
+  conditions:
+  - type: Ready
+    status: "False"`,
+			want: false,
+		},
+		{
+			name: "custom resource without conditions",
+			content: `apiVersion: deployment.servicefabrik.io/v1alpha1
+kind: Director
+metadata:
+  name: instance-id`,
+			want: false,
+		},
+		{
+			name: "custom resource without conditions ready once created",
+			content: `apiVersion: deployment.servicefabrik.io/v1alpha1
+kind: Director
+metadata:
+  name: instance-id
+  annotations:
+    interoperator.servicefabrik.io/ready-without-conditions: "true"`,
+			want: true,
+		},
+		{
+			name: "built-in resource without conditions",
+			content: `apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: instance-id`,
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects, err := dynamic.StringToUnstructured(tt.content)
+			if err != nil {
+				t.Fatalf("StringToUnstructured() error = %v", err)
+			}
+			if got := isReady(objects[0]); got != tt.want {
+				t.Errorf("isReady() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_hasFailed(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		want1   bool
+	}{
+		{
+			name: "deployment past its progress deadline",
+			content: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+status:
+  conditions:
+  - type: Progressing
+    status: "False"
+    reason: ProgressDeadlineExceeded
+    message: ReplicaSet "app-5d4f" has timed out progressing.`,
+			want:  `Deployment/app failed to progress. ReplicaSet "app-5d4f" has timed out progressing.`,
+			want1: true,
+		},
+		{
+			name: "deployment progressing",
+			content: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+status:
+  conditions:
+  - type: Progressing
+    status: "True"
+    reason: NewReplicaSetAvailable`,
+			want1: false,
+		},
+		{
+			name: "job failed",
+			content: `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: schema
+status:
+  conditions:
+  - type: Failed
+    status: "True"
+    message: Job has reached the specified backoff limit`,
+			want:  "Job/schema failed. Job has reached the specified backoff limit",
+			want1: true,
+		},
+		{
+			name: "pod failed",
+			content: `apiVersion: v1
+kind: Pod
+metadata:
+  name: pod
+status:
+  phase: Failed`,
+			want:  "Pod/pod failed",
+			want1: true,
+		},
+		{
+			name: "claim lost",
+			content: `apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: data
+status:
+  phase: Lost`,
+			want:  "PersistentVolumeClaim/data lost its volume",
+			want1: true,
+		},
+		{
+			name: "custom resource not ready",
+			content: `apiVersion: deployment.servicefabrik.io/v1alpha1
+kind: Director
+metadata:
+  name: instance-id
+status:
+  conditions:
+  - type: Ready
+    status: "False"`,
+			want1: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects, err := dynamic.StringToUnstructured(tt.content)
+			if err != nil {
+				t.Fatalf("StringToUnstructured() error = %v", err)
+			}
+			got, got1 := hasFailed(objects[0])
+			if got != tt.want {
+				t.Errorf("hasFailed() got = %v, want %v", got, tt.want)
+			}
+			if got1 != tt.want1 {
+				t.Errorf("hasFailed() got1 = %v, want %v", got1, tt.want1)
+			}
+		})
+	}
+}
+
+func Test_computeReadiness(t *testing.T) {
+	deployment := func(availableReplicas string) string {
+		return `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: default
+spec:
+  replicas: 1
+status:
+  updatedReplicas: 1
+  availableReplicas: ` + availableReplicas
+	}
+	job := `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: schema
+  namespace: default
+status:
+  conditions:
+  - type: Failed
+    status: "True"`
+	deploymentSource := osbv1alpha1.Source{APIVersion: "apps/v1", Kind: "Deployment", Name: "app", Namespace: "default"}
+	jobSource := osbv1alpha1.Source{APIVersion: "batch/v1", Kind: "Job", Name: "schema", Namespace: "default"}
+
+	tests := []struct {
+		name            string
+		live            []string
+		resources       []osbv1alpha1.Source
+		wantState       string
+		wantError       string
+		wantDeleteState string
+	}{
+		{
+			name:            "ready",
+			live:            []string{deployment("1")},
+			resources:       []osbv1alpha1.Source{deploymentSource},
+			wantState:       "succeeded",
+			wantDeleteState: "in progress",
+		},
+		{
+			name:            "not ready",
+			live:            []string{deployment("0")},
+			resources:       []osbv1alpha1.Source{deploymentSource},
+			wantState:       "in progress",
+			wantDeleteState: "in progress",
+		},
+		{
+			name:            "failed",
+			live:            []string{deployment("1"), job},
+			resources:       []osbv1alpha1.Source{deploymentSource, jobSource},
+			wantState:       "failed",
+			wantError:       "Job/schema failed. ",
+			wantDeleteState: "in progress",
+		},
+		{
+			name:            "not created yet or deleted",
+			resources:       []osbv1alpha1.Source{deploymentSource},
+			wantState:       "in progress",
+			wantDeleteState: "succeeded",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewFakeClient()
+			for _, content := range tt.live {
+				objects, err := dynamic.StringToUnstructured(content)
+				if err != nil {
+					t.Fatalf("StringToUnstructured() error = %v", err)
+				}
+				// The fake client copies the objects, which requires json
+				// numbers
+				data, err := objects[0].MarshalJSON()
+				if err != nil {
+					t.Fatalf("MarshalJSON() error = %v", err)
+				}
+				live := &unstructured.Unstructured{}
+				if err := live.UnmarshalJSON(data); err != nil {
+					t.Fatalf("UnmarshalJSON() error = %v", err)
+				}
+				if err := client.Create(context.TODO(), live); err != nil {
+					t.Fatalf("Create() error = %v", err)
+				}
+			}
+			got, err := computeReadiness(client, tt.resources)
+			if err != nil {
+				t.Fatalf("computeReadiness() error = %v", err)
+			}
+			if got.Provision.State != tt.wantState || got.Bind.State != tt.wantState {
+				t.Errorf("computeReadiness() state = %v, want %v", got.Provision.State, tt.wantState)
+			}
+			if got.Provision.Error != tt.wantError {
+				t.Errorf("computeReadiness() error = %v, want %v", got.Provision.Error, tt.wantError)
+			}
+			if got.Deprovision.State != tt.wantDeleteState || got.Unbind.State != tt.wantDeleteState {
+				t.Errorf("computeReadiness() delete state = %v, want %v", got.Deprovision.State, tt.wantDeleteState)
+			}
+			if len(got.Sources) != len(tt.resources) {
+				t.Errorf("computeReadiness() sources = %v, want %v", got.Sources, tt.resources)
+			}
+		})
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"reflect"
+	"sort"
 
 	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/properties"
 
@@ -13,16 +15,17 @@ import (
 	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/services"
 
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	kubernetes "sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
 const (
-	defaultNamespace = "default"
+	sourcesFileName = "sources.yaml"
+	statusFileName  = "status.yaml"
 )
 
 // ResourceManager defines the interface implemented by resources
@@ -31,18 +34,31 @@ type ResourceManager interface {
 	ComputeExpectedResources(client kubernetes.Client, instanceID, bindingID, serviceID, planID, action, namespace string) ([]*unstructured.Unstructured, error)
 	SetOwnerReference(owner metav1.Object, resources []*unstructured.Unstructured, scheme *runtime.Scheme) error
 	ReconcileResources(sourceClient kubernetes.Client, targetClient kubernetes.Client, expectedResources []*unstructured.Unstructured, lastResources []osbv1alpha1.Source) ([]osbv1alpha1.Source, error)
+	DryRunResources(targetClient kubernetes.Client, expectedResources []*unstructured.Unstructured, lastResources []osbv1alpha1.Source) ([]osbv1alpha1.ResourceChange, error)
+	ComputeSources(client kubernetes.Client, instanceID, bindingID, serviceID, planID, action, namespace string) ([]osbv1alpha1.Source, error)
 	ComputeStatus(sourceClient kubernetes.Client, targetClient kubernetes.Client, instanceID, bindingID, serviceID, planID, action, namespace string) (*properties.Status, error)
-	DeleteSubResources(client kubernetes.Client, subResources []osbv1alpha1.Source) ([]osbv1alpha1.Source, error)
+	ComputeReadiness(targetClient kubernetes.Client, resources []osbv1alpha1.Source) (*properties.Status, error)
+	DeleteSubResources(client kubernetes.Client, subResources []osbv1alpha1.Source, strategies []osbv1alpha1.DeletionStrategy) ([]osbv1alpha1.Source, []osbv1alpha1.DeletionStatus, error)
 }
 
 type resourceManager struct {
+	mapper meta.RESTMapper
 }
 
-// New creates a new ResourceManager object.
+// New creates a new ResourceManager object. The rendered resources are
+// created in the namespace of their owner unless the template sets their
+// namespace.
 func New() ResourceManager {
 	return resourceManager{}
 }
 
+// NewWithMapper creates a new ResourceManager object which renders the
+// resources of the kinds the mapper reports as cluster scoped without
+// namespace
+func NewWithMapper(mapper meta.RESTMapper) ResourceManager {
+	return resourceManager{mapper: mapper}
+}
+
 func (r resourceManager) fetchResources(client kubernetes.Client, instanceID, bindingID, serviceID, planID, namespace string) (*osbv1alpha1.SFServiceInstance, *osbv1alpha1.SFServiceBinding, *osbv1alpha1.SFService, *osbv1alpha1.SFPlan, error) {
 	var instance *osbv1alpha1.SFServiceInstance
 	var binding *osbv1alpha1.SFServiceBinding
@@ -63,7 +79,7 @@ func (r resourceManager) fetchResources(client kubernetes.Client, instanceID, bi
 	}
 
 	if serviceID != "" && planID != "" {
-		service, plan, err = services.FindServiceInfo(client, serviceID, planID, defaultNamespace)
+		service, plan, err = services.FindServiceInfo(client, serviceID, planID, services.Namespace)
 		if err != nil {
 			log.Printf("error finding service info with id %s. %v\n", serviceID, err)
 			return nil, nil, nil, nil, err
@@ -113,7 +129,7 @@ func (r resourceManager) ComputeExpectedResources(client kubernetes.Client, inst
 		return nil, err
 	}
 
-	renderer, err := rendererFactory.GetRenderer(template.Type, nil)
+	renderer, err := rendererFactory.GetCachedRenderer(template.Type, plan)
 	if err != nil {
 		log.Printf("error getting renderer of type %s. %v\n", template.Type, err)
 		return nil, err
@@ -139,6 +155,11 @@ func (r resourceManager) ComputeExpectedResources(client kubernetes.Client, inst
 
 	resources := make([]*unstructured.Unstructured, 0, len(files))
 	for _, file := range files {
+		// sources and status files rendered along with the resources
+		// are not sub resources
+		if file == sourcesFileName || file == statusFileName {
+			continue
+		}
 		subResourcesString, err := output.FileContent(file)
 		if err != nil {
 			log.Printf("error getting file content %s. %v\n", file, err)
@@ -152,17 +173,19 @@ func (r resourceManager) ComputeExpectedResources(client kubernetes.Client, inst
 		}
 
 		for _, obj := range subresources {
-			obj.SetNamespace(namespace)
+			setNamespace(obj, namespace, r.mapper)
 			resources = append(resources, obj)
 		}
 	}
 	return resources, nil
 }
 
-// SetOwnerReference updates the owner reference for all the resources
+// SetOwnerReference updates the owner reference for all the resources.
+// Cluster scoped resources and resources in other namespaces are labeled
+// with their owner instead.
 func (r resourceManager) SetOwnerReference(owner metav1.Object, resources []*unstructured.Unstructured, scheme *runtime.Scheme) error {
 	for _, obj := range resources {
-		if err := controllerutil.SetControllerReference(owner, obj, scheme); err != nil {
+		if err := setOwner(owner, obj, scheme); err != nil {
 			log.Printf("error setting owner reference for resource. %v\n", err)
 			return err
 		}
@@ -170,52 +193,45 @@ func (r resourceManager) SetOwnerReference(owner metav1.Object, resources []*uns
 	return nil
 }
 
-// ReconcileResources setups all resources according to expectation
+// ReconcileResources setups all resources according to expectation. The
+// resources are applied in waves, ordered by their WaveAnnotation and
+// DependsOnAnnotation. If a wave is not ready, the later waves are not
+// applied and a PendingError is returned along with the resources applied
+// so far. The outdated resources are deleted once all the waves are applied.
 func (r resourceManager) ReconcileResources(sourceClient kubernetes.Client, targetClient kubernetes.Client, expectedResources []*unstructured.Unstructured, lastResources []osbv1alpha1.Source) ([]osbv1alpha1.Source, error) {
-	foundResources := make([]*unstructured.Unstructured, 0, len(expectedResources))
-	for _, expectedResource := range expectedResources {
-		foundResource := &unstructured.Unstructured{}
+	waves, err := orderWaves(expectedResources)
+	if err != nil {
+		log.Printf("error ordering resources. %v\n", err)
+		return nil, err
+	}
 
-		kind := expectedResource.GetKind()
-		apiVersion := expectedResource.GetAPIVersion()
-		foundResource.SetKind(kind)
-		foundResource.SetAPIVersion(apiVersion)
-		namespacedName := types.NamespacedName{
-			Name:      expectedResource.GetName(),
-			Namespace: expectedResource.GetNamespace(),
+	foundResources := make([]*unstructured.Unstructured, 0, len(expectedResources))
+	var pendingErr *PendingError
+	for i, wave := range waves {
+		timeout, err := waveTimeout(wave)
+		if err != nil {
+			return nil, err
 		}
-		foundResource.SetName(namespacedName.Name)
-		foundResource.SetNamespace(namespacedName.Namespace)
-
-		err := targetClient.Get(context.TODO(), namespacedName, foundResource)
-		if err != nil && errors.IsNotFound(err) {
-			log.Printf("Creating %s %s\n", kind, namespacedName)
-			err = targetClient.Create(context.TODO(), expectedResource)
+		var notReady []string
+		for _, expectedResource := range wave {
+			foundResource, err := r.reconcileResource(targetClient, expectedResource)
 			if err != nil {
-				log.Printf("error creating %s %s. %v\n", kind, namespacedName, err)
 				return nil, err
 			}
 			foundResources = append(foundResources, foundResource)
-			break
-		} else if err != nil {
-			log.Printf("error getting %s %s. %v\n", kind, namespacedName, err)
-			return nil, err
+			if !isReady(foundResource) {
+				notReady = append(notReady, resourceKey(foundResource.GetKind(), foundResource.GetName()))
+			}
 		}
-
-		toBeUpdated := false
-		updatedResource, toBeUpdated := dynamic.DeepUpdate(foundResource.Object, expectedResource.Object)
-		if toBeUpdated {
-			log.Printf("Updating %s %s\n", kind, namespacedName)
-			foundResource.Object = updatedResource.(map[string]interface{})
-			err = targetClient.Update(context.TODO(), foundResource)
-			if err != nil {
-				log.Printf("error updating %s %s. %v\n", kind, namespacedName, err)
-				return nil, err
+		if len(notReady) > 0 && i < len(waves)-1 {
+			pendingErr = &PendingError{
+				wave:      i,
+				resources: notReady,
+				timeout:   timeout,
 			}
-		} else {
-			log.Printf("%s %s already up todate .\n", kind, namespacedName)
+			log.Printf("%v\n", pendingErr)
+			break
 		}
-		foundResources = append(foundResources, foundResource)
 	}
 
 	for _, lastResource := range lastResources {
@@ -225,6 +241,11 @@ func (r resourceManager) ReconcileResources(sourceClient kubernetes.Client, targ
 		oldResource.SetName(lastResource.Name)
 		oldResource.SetNamespace(lastResource.Namespace)
 		if ok := r.findUnstructuredObject(foundResources, oldResource); !ok {
+			if pendingErr != nil {
+				// Not all the expected resources are applied yet
+				foundResources = append(foundResources, oldResource)
+				continue
+			}
 			err := targetClient.Delete(context.TODO(), oldResource)
 			if err != nil {
 				// Not failing here. Add the outdated resource to foundResource
@@ -240,9 +261,164 @@ func (r resourceManager) ReconcileResources(sourceClient kubernetes.Client, targ
 	for _, object := range foundResources {
 		resourceRefs = append(resourceRefs, r.unstructuredToSource(object))
 	}
+	if pendingErr != nil {
+		return resourceRefs, pendingErr
+	}
 	return resourceRefs, nil
 }
 
+// reconcileResource creates or updates the resource and returns the
+// resource as found in the cluster
+func (r resourceManager) reconcileResource(targetClient kubernetes.Client, expectedResource *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	foundResource := &unstructured.Unstructured{}
+
+	kind := expectedResource.GetKind()
+	apiVersion := expectedResource.GetAPIVersion()
+	foundResource.SetKind(kind)
+	foundResource.SetAPIVersion(apiVersion)
+	namespacedName := types.NamespacedName{
+		Name:      expectedResource.GetName(),
+		Namespace: expectedResource.GetNamespace(),
+	}
+	foundResource.SetName(namespacedName.Name)
+	foundResource.SetNamespace(namespacedName.Namespace)
+
+	err := targetClient.Get(context.TODO(), namespacedName, foundResource)
+	if err != nil && errors.IsNotFound(err) {
+		log.Printf("Creating %s %s\n", kind, namespacedName)
+		_, err = dynamic.SetLastAppliedConfiguration(expectedResource)
+		if err != nil {
+			log.Printf("error creating %s %s. %v\n", kind, namespacedName, err)
+			return nil, err
+		}
+		err = targetClient.Create(context.TODO(), expectedResource)
+		if err != nil {
+			log.Printf("error creating %s %s. %v\n", kind, namespacedName, err)
+			return nil, err
+		}
+		return expectedResource, nil
+	} else if err != nil {
+		log.Printf("error getting %s %s. %v\n", kind, namespacedName, err)
+		return nil, err
+	}
+
+	// Only the fields set by the templates are updated, the fields
+	// dropped from the templates since the last update are removed
+	updatedResource, toBeUpdated, err := dynamic.ThreeWayMerge(foundResource, expectedResource)
+	if err != nil {
+		log.Printf("error merging %s %s. %v\n", kind, namespacedName, err)
+		return nil, err
+	}
+	if toBeUpdated {
+		log.Printf("Updating %s %s\n", kind, namespacedName)
+		foundResource = updatedResource
+		err = targetClient.Update(context.TODO(), foundResource)
+		if err != nil {
+			log.Printf("error updating %s %s. %v\n", kind, namespacedName, err)
+			return nil, err
+		}
+	} else {
+		log.Printf("%s %s already up todate .\n", kind, namespacedName)
+	}
+	return foundResource, nil
+}
+
+// DryRunResources computes the changes ReconcileResources would make to the
+// resources, without applying them
+func (r resourceManager) DryRunResources(targetClient kubernetes.Client, expectedResources []*unstructured.Unstructured, lastResources []osbv1alpha1.Source) ([]osbv1alpha1.ResourceChange, error) {
+	changes := []osbv1alpha1.ResourceChange{}
+	for _, expectedResource := range expectedResources {
+		foundResource := &unstructured.Unstructured{}
+
+		kind := expectedResource.GetKind()
+		foundResource.SetKind(kind)
+		foundResource.SetAPIVersion(expectedResource.GetAPIVersion())
+		namespacedName := types.NamespacedName{
+			Name:      expectedResource.GetName(),
+			Namespace: expectedResource.GetNamespace(),
+		}
+
+		// Keep the expected resources unchanged
+		modifiedResource := expectedResource.DeepCopy()
+		err := targetClient.Get(context.TODO(), namespacedName, foundResource)
+		if err != nil && errors.IsNotFound(err) {
+			changes = append(changes, osbv1alpha1.ResourceChange{
+				Source: r.unstructuredToSource(expectedResource),
+				Action: "create",
+				Diff:   dynamic.Diff(nil, redactSecret(nil, modifiedResource).Object),
+			})
+			continue
+		} else if err != nil {
+			log.Printf("error getting %s %s. %v\n", kind, namespacedName, err)
+			return nil, err
+		}
+
+		updatedResource, toBeUpdated, err := dynamic.ThreeWayMerge(foundResource, modifiedResource)
+		if err != nil {
+			log.Printf("error merging %s %s. %v\n", kind, namespacedName, err)
+			return nil, err
+		}
+		if toBeUpdated {
+			changes = append(changes, osbv1alpha1.ResourceChange{
+				Source: r.unstructuredToSource(expectedResource),
+				Action: "update",
+				Diff:   dynamic.Diff(redactSecret(nil, foundResource).Object, redactSecret(foundResource, updatedResource).Object),
+			})
+		}
+	}
+
+	for _, lastResource := range lastResources {
+		oldResource := &unstructured.Unstructured{}
+		oldResource.SetKind(lastResource.Kind)
+		oldResource.SetAPIVersion(lastResource.APIVersion)
+		oldResource.SetName(lastResource.Name)
+		oldResource.SetNamespace(lastResource.Namespace)
+		if ok := r.findUnstructuredObject(expectedResources, oldResource); !ok {
+			changes = append(changes, osbv1alpha1.ResourceChange{
+				Source: lastResource,
+				Action: "delete",
+			})
+		}
+	}
+	return changes, nil
+}
+
+// redactedValue replaces the values of Secrets in the dry run. Changed
+// values are replaced with redactedChangedValue, so that the diff still
+// lists them.
+const (
+	redactedValue        = "(redacted)"
+	redactedChangedValue = "(redacted, changed)"
+)
+
+// redactSecret returns a copy of <object> with the values in data and
+// stringData replaced, if it is a Secret, so that the dry run does not
+// expose them in the status of the instance. A value which differs from
+// the value of the same key in <current> is marked as changed.
+func redactSecret(current, object *unstructured.Unstructured) *unstructured.Unstructured {
+	if object.GetKind() != "Secret" || object.GetAPIVersion() != "v1" {
+		return object
+	}
+	redacted := object.DeepCopy()
+	for _, field := range []string{"data", "stringData"} {
+		values, ok := redacted.Object[field].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var currentValues map[string]interface{}
+		if current != nil {
+			currentValues, _ = current.Object[field].(map[string]interface{})
+		}
+		for key, value := range values {
+			values[key] = redactedValue
+			if currentValue, ok := currentValues[key]; ok && !reflect.DeepEqual(currentValue, value) {
+				values[key] = redactedChangedValue
+			}
+		}
+	}
+	return redacted
+}
+
 func (r resourceManager) unstructuredToSource(object *unstructured.Unstructured) osbv1alpha1.Source {
 	resourceRef := osbv1alpha1.Source{}
 	resourceRef.Kind = object.GetKind()
@@ -261,9 +437,9 @@ func (r resourceManager) findUnstructuredObject(list []*unstructured.Unstructure
 	return false
 }
 
-// ComputeStatus computes status template
-func (r resourceManager) ComputeStatus(sourceClient kubernetes.Client, targetClient kubernetes.Client, instanceID, bindingID, serviceID, planID, action, namespace string) (*properties.Status, error) {
-	instance, binding, service, plan, err := r.fetchResources(sourceClient, instanceID, bindingID, serviceID, planID, namespace)
+// ComputeSources computes the resources listed in the sources template
+func (r resourceManager) ComputeSources(client kubernetes.Client, instanceID, bindingID, serviceID, planID, action, namespace string) ([]osbv1alpha1.Source, error) {
+	instance, binding, service, plan, err := r.fetchResources(client, instanceID, bindingID, serviceID, planID, namespace)
 	if err != nil {
 		log.Printf("error getting resource. %v\n", err)
 		return nil, err
@@ -283,13 +459,23 @@ func (r resourceManager) ComputeStatus(sourceClient kubernetes.Client, targetCli
 		name.Name = binding.GetName()
 	}
 
+	sources, err := r.renderSources(service, plan, instance, binding, name)
+	if err != nil {
+		return nil, err
+	}
+	return sortedSources(sources), nil
+}
+
+// renderSources renders the sources template
+func (r resourceManager) renderSources(service *osbv1alpha1.SFService, plan *osbv1alpha1.SFPlan, instance *osbv1alpha1.SFServiceInstance, binding *osbv1alpha1.SFServiceBinding, name types.NamespacedName) (map[string]osbv1alpha1.Source, error) {
+	serviceID := service.Spec.ID
 	template, err := plan.GetTemplate(osbv1alpha1.SourcesAction)
 	if err != nil {
-		log.Printf("plan %s does not have sources template. %v\n", planID, err)
+		log.Printf("plan %s does not have sources template. %v\n", plan.Spec.ID, err)
 		return nil, err
 	}
 
-	renderer, err := rendererFactory.GetRenderer(template.Type, nil)
+	renderer, err := rendererFactory.GetCachedRenderer(template.Type, plan)
 	if err != nil {
 		log.Printf("error getting renderer of type %s. %v\n", template.Type, err)
 		return nil, err
@@ -318,15 +504,15 @@ func (r resourceManager) ComputeStatus(sourceClient kubernetes.Client, targetCli
 		return nil, err
 	}
 
-	sourcesFileName := files[0]
+	sourcesFile := files[0]
 	for _, file := range files {
-		if file == "sources.yaml" {
-			sourcesFileName = file
+		if file == sourcesFileName {
+			sourcesFile = file
 			break
 		}
 	}
 
-	sourcesString, err := output.FileContent(sourcesFileName)
+	sourcesString, err := output.FileContent(sourcesFile)
 	if err != nil {
 		log.Printf("error getting file content of sources.yaml. %v\n", err)
 		return nil, err
@@ -337,6 +523,74 @@ func (r resourceManager) ComputeStatus(sourceClient kubernetes.Client, targetCli
 		log.Printf("error parsing file content of sources.yaml. %v\n", err)
 		return nil, err
 	}
+	return sources, nil
+}
+
+// sortedSources returns the sources ordered by their key
+func sortedSources(sources map[string]osbv1alpha1.Source) []osbv1alpha1.Source {
+	keys := make([]string, 0, len(sources))
+	for key := range sources {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	result := make([]osbv1alpha1.Source, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, sources[key])
+	}
+	return result
+}
+
+// ComputeStatus computes status template. The status of plans without
+// sources or status template is computed from the readiness of the resources
+// of the instance or binding, their bindings fail as their response can not
+// be computed.
+func (r resourceManager) ComputeStatus(sourceClient kubernetes.Client, targetClient kubernetes.Client, instanceID, bindingID, serviceID, planID, action, namespace string) (*properties.Status, error) {
+	instance, binding, service, plan, err := r.fetchResources(sourceClient, instanceID, bindingID, serviceID, planID, namespace)
+	if err != nil {
+		log.Printf("error getting resource. %v\n", err)
+		return nil, err
+	}
+
+	if plan == nil || service == nil {
+		return nil, fmt.Errorf("failed to get service or plan details")
+	}
+
+	name := types.NamespacedName{
+		Namespace: namespace,
+		Name:      instance.GetName(),
+	}
+
+	switch action {
+	case osbv1alpha1.BindAction:
+		name.Name = binding.GetName()
+	}
+
+	// The status of plans without sources or status template is computed
+	// from the readiness of the resources
+	_, sourcesErr := plan.GetTemplate(osbv1alpha1.SourcesAction)
+	_, statusErr := plan.GetTemplate(osbv1alpha1.StatusAction)
+	if sourcesErr != nil || statusErr != nil {
+		subResources := instance.Status.Resources
+		if action == osbv1alpha1.BindAction {
+			subResources = binding.Status.Resources
+		}
+		status, err := computeReadiness(targetClient, subResources)
+		if err != nil {
+			return nil, err
+		}
+		if action == osbv1alpha1.BindAction {
+			// The response of the binding is rendered by the status
+			// template, a binding without response is of no use
+			status.Bind.State = stateFailed
+			status.Bind.Error = fmt.Sprintf("plan %s has no %s and %s template to compute the response of the binding", planID, osbv1alpha1.SourcesAction, osbv1alpha1.StatusAction)
+		}
+		return status, nil
+	}
+
+	sources, err := r.renderSources(service, plan, instance, binding, name)
+	if err != nil {
+		return nil, err
+	}
 
 	sourceObjects := make(map[string]*unstructured.Unstructured)
 	for key, val := range sources {
@@ -348,6 +602,9 @@ func (r resourceManager) ComputeStatus(sourceClient kubernetes.Client, targetCli
 				Name:      val.Name,
 				Namespace: name.Namespace,
 			}
+			if val.Namespace != "" {
+				namespacedName.Namespace = val.Namespace
+			}
 			err := targetClient.Get(context.TODO(), namespacedName, obj)
 			if err != nil {
 				// Not failing here as the resource might not exist
@@ -358,31 +615,31 @@ func (r resourceManager) ComputeStatus(sourceClient kubernetes.Client, targetCli
 		}
 	}
 
-	template, err = plan.GetTemplate(osbv1alpha1.StatusAction)
+	template, err := plan.GetTemplate(osbv1alpha1.StatusAction)
 	if err != nil {
 		log.Printf("plan %s does not have status template. %v\n", planID, err)
 		return nil, err
 	}
 
-	renderer, err = rendererFactory.GetRenderer(template.Type, nil)
+	renderer, err := rendererFactory.GetCachedRenderer(template.Type, plan)
 	if err != nil {
 		log.Printf("error getting renderer of type %s. %v\n", template.Type, err)
 		return nil, err
 	}
 
-	input, err = rendererFactory.GetStatusRendererInput(template, name, sourceObjects)
+	input, err := rendererFactory.GetStatusRendererInput(template, name, sourceObjects)
 	if err != nil {
 		log.Printf("error creating status renderer input of type %s. %v\n", template.Type, err)
 		return nil, err
 	}
 
-	output, err = renderer.Render(input)
+	output, err := renderer.Render(input)
 	if err != nil {
 		log.Printf("error renderering status for service %s. %v\n", serviceID, err)
 		return nil, err
 	}
 
-	files, err = output.ListFiles()
+	files, err := output.ListFiles()
 	if err != nil {
 		log.Printf("error listing rendered resource files for service %s. %v\n", serviceID, err)
 		return nil, err
@@ -393,15 +650,15 @@ func (r resourceManager) ComputeStatus(sourceClient kubernetes.Client, targetCli
 		return nil, err
 	}
 
-	statusFileName := files[0]
+	statusFile := files[0]
 	for _, file := range files {
-		if file == "status.yaml" {
-			statusFileName = file
+		if file == statusFileName {
+			statusFile = file
 			break
 		}
 	}
 
-	statusString, err := output.FileContent(statusFileName)
+	statusString, err := output.FileContent(statusFile)
 	if err != nil {
 		log.Printf("error getting file content of status.yaml. %v\n", err)
 		return nil, err
@@ -413,11 +670,20 @@ func (r resourceManager) ComputeStatus(sourceClient kubernetes.Client, targetCli
 		return nil, err
 	}
 
+	status.Sources = sortedSources(sources)
+
 	return status, nil
 }
 
-// DeleteSubResources setups all resources according to expectation
-func (r resourceManager) DeleteSubResources(client kubernetes.Client, subResources []osbv1alpha1.Source) ([]osbv1alpha1.Source, error) {
+// ComputeReadiness computes the status of the resources from their readiness
+func (r resourceManager) ComputeReadiness(targetClient kubernetes.Client, resources []osbv1alpha1.Source) (*properties.Status, error) {
+	return computeReadiness(targetClient, resources)
+}
+
+// DeleteSubResources deletes the resources with their deletion strategy. It
+// returns the resources which are not deleted yet along with the progress of
+// their deletion.
+func (r resourceManager) DeleteSubResources(client kubernetes.Client, subResources []osbv1alpha1.Source, strategies []osbv1alpha1.DeletionStrategy) ([]osbv1alpha1.Source, []osbv1alpha1.DeletionStatus, error) {
 	//
 	// delete the external dependency here
 	//
@@ -425,6 +691,7 @@ func (r resourceManager) DeleteSubResources(client kubernetes.Client, subResourc
 	// multiple types for same object.
 
 	var remainingResource []osbv1alpha1.Source
+	var deletions []osbv1alpha1.DeletionStatus
 	var lastError error
 
 	for _, subResource := range subResources {
@@ -433,7 +700,12 @@ func (r resourceManager) DeleteSubResources(client kubernetes.Client, subResourc
 		resource.SetAPIVersion(subResource.APIVersion)
 		resource.SetName(subResource.Name)
 		resource.SetNamespace(subResource.Namespace)
-		err := r.deleteSubResource(client, resource)
+		strategy := getDeletionStrategy(strategies, subResource)
+		deletion := osbv1alpha1.DeletionStatus{
+			Source:   subResource,
+			Strategy: strategy.Type,
+		}
+		message, err := r.deleteSubResource(client, resource, strategy)
 		if err != nil {
 			if errors.IsNotFound(err) {
 				log.Printf("deleted completed for resource %v", subResource)
@@ -441,54 +713,15 @@ func (r resourceManager) DeleteSubResources(client kubernetes.Client, subResourc
 			}
 			log.Printf("failed to delete resource %v. %v", subResource, err)
 			remainingResource = append(remainingResource, subResource)
+			deletion.Message = err.Error()
+			deletions = append(deletions, deletion)
 			lastError = err
 			continue
 		}
-		log.Printf("deleted triggered for resource %v", subResource)
+		log.Printf("deleted triggered for resource %v. %s", subResource, message)
 		remainingResource = append(remainingResource, subResource)
+		deletion.Message = message
+		deletions = append(deletions, deletion)
 	}
-	return remainingResource, lastError
-}
-
-func (r resourceManager) deleteSubResource(client kubernetes.Client, resource *unstructured.Unstructured) error {
-	// Special delete handling for sf operators for delete
-	var specialDelete = [...]string{"deployment.servicefabrik.io/v1alpha1", "bind.servicefabrik.io/v1alpha1"}
-	apiVersion := resource.GetAPIVersion()
-
-	for _, val := range specialDelete {
-		if apiVersion == val {
-			namespacedName := types.NamespacedName{
-				Name:      resource.GetName(),
-				Namespace: resource.GetNamespace(),
-			}
-			err := client.Get(context.TODO(), namespacedName, resource)
-			if err != nil {
-				return err
-			}
-			content := resource.UnstructuredContent()
-			statusInt, ok := content["status"]
-			var status map[string]interface{}
-			if ok {
-				status, ok = statusInt.(map[string]interface{})
-				if !ok {
-					return fmt.Errorf("status field not map for resource %v", resource)
-				}
-			} else {
-				status = make(map[string]interface{})
-			}
-
-			state, ok := status["state"]
-			if !ok || state == "succeeded" || state == "failed" {
-				status["state"] = "delete"
-				content["status"] = status
-				resource.SetUnstructuredContent(content)
-				err = client.Update(context.TODO(), resource)
-				if err != nil {
-					return err
-				}
-			}
-			return nil
-		}
-	}
-	return client.Delete(context.TODO(), resource)
+	return remainingResource, deletions, lastError
 }
@@ -15,6 +15,7 @@ import (
 	osbv1alpha1 "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis/osb/v1alpha1"
 	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/properties"
 	"github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -23,6 +24,7 @@ import (
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	kubernetes "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 )
 
@@ -70,6 +72,7 @@ func TestNew(t *testing.T) {
 }
 
 func Test_resourceManager_fetchResources(t *testing.T) {
+	planUpdatable := true
 	g := gomega.NewGomegaWithT(t)
 
 	templateSpec := []osbv1alpha1.TemplateSpec{
@@ -111,7 +114,7 @@ func Test_resourceManager_fetchResources(t *testing.T) {
 			Metadata:      nil,
 			Free:          false,
 			Bindable:      true,
-			PlanUpdatable: true,
+			PlanUpdatable: &planUpdatable,
 			Schemas:       nil,
 			Templates:     templateSpec,
 			ServiceID:     "service-id",
@@ -333,6 +336,7 @@ func Test_resourceManager_fetchResources(t *testing.T) {
 }
 
 func Test_resourceManager_ComputeExpectedResources(t *testing.T) {
+	planUpdatable := true
 	g := gomega.NewGomegaWithT(t)
 
 	templateSpec := []osbv1alpha1.TemplateSpec{
@@ -374,7 +378,7 @@ func Test_resourceManager_ComputeExpectedResources(t *testing.T) {
 			Metadata:      nil,
 			Free:          false,
 			Bindable:      true,
-			PlanUpdatable: true,
+			PlanUpdatable: &planUpdatable,
 			Schemas:       nil,
 			Templates:     templateSpec,
 			ServiceID:     "service-id",
@@ -806,6 +810,117 @@ status:
 	}
 }
 
+func Test_resourceManager_DryRunResources(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	liveResources, err := dynamic.StringToUnstructured(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  namespace: default
+data:
+  foo: bar
+  removed: value
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: unchanged
+  namespace: default
+data:
+  foo: bar
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: password
+  namespace: default
+data:
+  password: b2xk
+  username: YWRtaW4=`)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	for _, liveResource := range liveResources {
+		_, err = dynamic.SetLastAppliedConfiguration(liveResource)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+	}
+	targetClient := fake.NewFakeClient()
+	for _, liveResource := range liveResources {
+		g.Expect(targetClient.Create(context.TODO(), liveResource)).NotTo(gomega.HaveOccurred())
+	}
+
+	expectedResources, err := dynamic.StringToUnstructured(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  namespace: default
+data:
+  foo: baz
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: unchanged
+  namespace: default
+data:
+  foo: bar
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: credentials
+  namespace: default
+stringData:
+  password: secret
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: password
+  namespace: default
+data:
+  password: bmV3
+  username: YWRtaW4=`)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	r := resourceManager{}
+	outdated := osbv1alpha1.Source{
+		APIVersion: "v1",
+		Kind:       "Service",
+		Name:       "outdated",
+		Namespace:  "default",
+	}
+	lastResources := []osbv1alpha1.Source{
+		r.unstructuredToSource(liveResources[0]),
+		r.unstructuredToSource(liveResources[1]),
+		r.unstructuredToSource(liveResources[2]),
+		outdated,
+	}
+
+	changes, err := r.DryRunResources(targetClient, expectedResources, lastResources)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(changes).To(gomega.HaveLen(4))
+	g.Expect(changes[0].Source).To(gomega.Equal(r.unstructuredToSource(expectedResources[0])))
+	g.Expect(changes[0].Action).To(gomega.Equal("update"))
+	g.Expect(changes[0].Diff).To(gomega.Equal("~ data.foo: \"bar\" -> \"baz\"\n- data.removed"))
+	g.Expect(changes[1].Source).To(gomega.Equal(r.unstructuredToSource(expectedResources[2])))
+	g.Expect(changes[1].Action).To(gomega.Equal("create"))
+	g.Expect(changes[1].Diff).To(gomega.ContainSubstring("+ stringData: {\"password\":\"(redacted)\"}"))
+	g.Expect(changes[1].Diff).NotTo(gomega.ContainSubstring("secret\""))
+	g.Expect(changes[2].Source).To(gomega.Equal(r.unstructuredToSource(expectedResources[3])))
+	g.Expect(changes[2].Action).To(gomega.Equal("update"))
+	g.Expect(changes[2].Diff).To(gomega.Equal("~ data.password: \"(redacted)\" -> \"(redacted, changed)\""))
+	g.Expect(changes[3].Source).To(gomega.Equal(outdated))
+	g.Expect(changes[3].Action).To(gomega.Equal("delete"))
+
+	// Nothing is applied
+	found := &unstructured.Unstructured{}
+	found.SetAPIVersion("v1")
+	found.SetKind("ConfigMap")
+	g.Expect(targetClient.Get(context.TODO(), types.NamespacedName{Name: "config", Namespace: "default"}, found)).NotTo(gomega.HaveOccurred())
+	g.Expect(found.Object["data"]).To(gomega.HaveKeyWithValue("removed", "value"))
+	g.Expect(expectedResources[0].GetAnnotations()).To(gomega.BeEmpty())
+}
+
 func Test_resourceManager_findUnstructuredObject(t *testing.T) {
 	type args struct {
 		list []*unstructured.Unstructured
@@ -860,6 +975,7 @@ func Test_resourceManager_findUnstructuredObject(t *testing.T) {
 }
 
 func Test_resourceManager_ComputeStatus(t *testing.T) {
+	planUpdatable := true
 
 	g := gomega.NewGomegaWithT(t)
 
@@ -1126,7 +1242,7 @@ directorbind:
 			Metadata:      nil,
 			Free:          false,
 			Bindable:      true,
-			PlanUpdatable: true,
+			PlanUpdatable: &planUpdatable,
 			Schemas:       nil,
 			Templates:     templateSpec,
 			ServiceID:     "service-id",
@@ -1370,7 +1486,7 @@ func Test_resourceManager_DeleteSubResources(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := resourceManager{}
-			got, err := r.DeleteSubResources(tt.args.client, tt.args.subResources)
+			got, _, err := r.DeleteSubResources(tt.args.client, tt.args.subResources, nil)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("resourceManager.DeleteSubResources() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -1383,23 +1499,206 @@ func Test_resourceManager_DeleteSubResources(t *testing.T) {
 }
 
 func Test_resourceManager_deleteSubResource(t *testing.T) {
+	newResource := func(content string) *unstructured.Unstructured {
+		resources, err := dynamic.StringToUnstructured(content)
+		if err != nil {
+			t.Fatalf("StringToUnstructured() error = %v", err)
+		}
+		return resources[0]
+	}
+	configMap := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  namespace: default
+  finalizers:
+  - example.com/cleanup`
+	pod := func(phase string) string {
+		return `apiVersion: v1
+kind: Pod
+metadata:
+  name: pod
+  namespace: default
+status:
+  phase: ` + phase
+	}
+
 	type args struct {
-		client   kubernetes.Client
-		resource *unstructured.Unstructured
+		live     *unstructured.Unstructured
+		strategy osbv1alpha1.DeletionStrategy
 	}
 	tests := []struct {
-		name    string
-		r       resourceManager
-		args    args
-		wantErr bool
+		name        string
+		args        args
+		want        string
+		wantErr     bool
+		wantDeleted bool
+		wantField   []string
+		wantValue   string
 	}{
-		// TODO: Add test cases.
+		{
+			name: "delete",
+			args: args{
+				live:     newResource(configMap),
+				strategy: osbv1alpha1.DeletionStrategy{Type: osbv1alpha1.DeleteStrategy},
+			},
+			want:        "delete triggered",
+			wantDeleted: true,
+		},
+		{
+			name: "field",
+			args: args{
+				live: newResource(pod("Succeeded")),
+				strategy: osbv1alpha1.DeletionStrategy{
+					Type:  osbv1alpha1.FieldStrategy,
+					Field: "status.phase",
+					Value: "Deleting",
+					From:  []string{"Succeeded", "Failed"},
+				},
+			},
+			want:      "status.phase set to Deleting",
+			wantField: []string{"status", "phase"},
+			wantValue: "Deleting",
+		},
+		{
+			name: "field with an operation in progress",
+			args: args{
+				live: newResource(pod("Running")),
+				strategy: osbv1alpha1.DeletionStrategy{
+					Type:  osbv1alpha1.FieldStrategy,
+					Field: "status.phase",
+					Value: "Deleting",
+					From:  []string{"Succeeded", "Failed"},
+				},
+			},
+			want:      "status.phase is Running, waiting for it to be one of Succeeded, Failed",
+			wantField: []string{"status", "phase"},
+			wantValue: "Running",
+		},
+		{
+			name: "field defaults to setting the state to delete",
+			args: args{
+				live:     newResource(configMap),
+				strategy: osbv1alpha1.DeletionStrategy{Type: osbv1alpha1.FieldStrategy},
+			},
+			want:      "status.state set to delete",
+			wantField: []string{"status", "state"},
+			wantValue: "delete",
+		},
+		{
+			name: "annotation",
+			args: args{
+				live: newResource(configMap),
+				strategy: osbv1alpha1.DeletionStrategy{
+					Type:  osbv1alpha1.AnnotationStrategy,
+					Field: "example.com/delete",
+					Value: "true",
+				},
+			},
+			want:      "annotation example.com/delete set to true",
+			wantField: []string{"metadata", "annotations", "example.com/delete"},
+			wantValue: "true",
+		},
+		{
+			name: "annotation without name",
+			args: args{
+				live:     newResource(configMap),
+				strategy: osbv1alpha1.DeletionStrategy{Type: osbv1alpha1.AnnotationStrategy},
+			},
+			wantErr: true,
+		},
+		{
+			name: "finalizer",
+			args: args{
+				live:     newResource(configMap),
+				strategy: osbv1alpha1.DeletionStrategy{Type: osbv1alpha1.FinalizerStrategy},
+			},
+			want:        "waiting for the finalizers example.com/cleanup",
+			wantDeleted: true,
+		},
+		{
+			name: "unknown strategy",
+			args: args{
+				live:     newResource(configMap),
+				strategy: osbv1alpha1.DeletionStrategy{Type: "unknown"},
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewFakeClient()
+			if err := client.Create(context.TODO(), tt.args.live.DeepCopy()); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			resource := &unstructured.Unstructured{}
+			resource.SetAPIVersion(tt.args.live.GetAPIVersion())
+			resource.SetKind(tt.args.live.GetKind())
+			resource.SetName(tt.args.live.GetName())
+			resource.SetNamespace(tt.args.live.GetNamespace())
+
 			r := resourceManager{}
-			if err := r.deleteSubResource(tt.args.client, tt.args.resource); (err != nil) != tt.wantErr {
+			got, err := r.deleteSubResource(client, resource, tt.args.strategy)
+			if (err != nil) != tt.wantErr {
 				t.Errorf("resourceManager.deleteSubResource() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("resourceManager.deleteSubResource() = %v, want %v", got, tt.want)
+			}
+
+			found := &unstructured.Unstructured{}
+			found.SetAPIVersion(tt.args.live.GetAPIVersion())
+			found.SetKind(tt.args.live.GetKind())
+			err = client.Get(context.TODO(), types.NamespacedName{Name: resource.GetName(), Namespace: resource.GetNamespace()}, found)
+			if tt.wantDeleted != errors.IsNotFound(err) {
+				t.Errorf("resourceManager.deleteSubResource() deleted = %v, want %v", errors.IsNotFound(err), tt.wantDeleted)
+			}
+			if tt.wantField != nil {
+				value, _, _ := unstructured.NestedString(found.Object, tt.wantField...)
+				if value != tt.wantValue {
+					t.Errorf("resourceManager.deleteSubResource() %v = %v, want %v", tt.wantField, value, tt.wantValue)
+				}
+			}
+		})
+	}
+}
+
+func Test_getDeletionStrategy(t *testing.T) {
+	strategies := []osbv1alpha1.DeletionStrategy{
+		{APIVersion: "deployment.servicefabrik.io/v1alpha1", Kind: "Docker", Type: osbv1alpha1.AnnotationStrategy},
+		{APIVersion: "example.com/v1", Type: osbv1alpha1.FinalizerStrategy},
+	}
+	tests := []struct {
+		name     string
+		resource osbv1alpha1.Source
+		want     string
+	}{
+		{
+			name:     "kind of the plan",
+			resource: osbv1alpha1.Source{APIVersion: "deployment.servicefabrik.io/v1alpha1", Kind: "Docker"},
+			want:     osbv1alpha1.AnnotationStrategy,
+		},
+		{
+			name:     "apiVersion of the plan",
+			resource: osbv1alpha1.Source{APIVersion: "example.com/v1", Kind: "Database"},
+			want:     osbv1alpha1.FinalizerStrategy,
+		},
+		{
+			name:     "default",
+			resource: osbv1alpha1.Source{APIVersion: "deployment.servicefabrik.io/v1alpha1", Kind: "Director"},
+			want:     osbv1alpha1.FieldStrategy,
+		},
+		{
+			name:     "delete",
+			resource: osbv1alpha1.Source{APIVersion: "v1", Kind: "ConfigMap"},
+			want:     osbv1alpha1.DeleteStrategy,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getDeletionStrategy(strategies, tt.resource); got.Type != tt.want {
+				t.Errorf("getDeletionStrategy() = %v, want %v", got.Type, tt.want)
 			}
 		})
 	}
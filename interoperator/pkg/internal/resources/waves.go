@@ -0,0 +1,196 @@
+package resources
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// WaveAnnotation orders the rendered resources. Resources are applied
+	// in the increasing order of their wave, the resources of a wave are
+	// applied once the resources of the previous waves are ready. The wave
+	// of a resource defaults to 0.
+	WaveAnnotation = "interoperator.servicefabrik.io/wave"
+	// DependsOnAnnotation lists the comma separated kind/name of the rendered
+	// resources which must be ready before the resource is applied
+	DependsOnAnnotation = "interoperator.servicefabrik.io/depends-on"
+	// WaveTimeoutAnnotation is the time the resources of a wave may take to
+	// become ready, e.g. 10m. The longest timeout of the resources of a wave
+	// applies to the wave. It defaults to DefaultWaveTimeout.
+	WaveTimeoutAnnotation = "interoperator.servicefabrik.io/wave-timeout"
+)
+
+// DefaultWaveTimeout is the time the resources of a wave may take to become
+// ready if none of them sets the WaveTimeoutAnnotation
+const DefaultWaveTimeout = 30 * time.Minute
+
+// PendingError is returned by ReconcileResources when the resources of a
+// wave are not ready and the later waves are not applied yet
+type PendingError struct {
+	wave      int
+	resources []string
+	timeout   time.Duration
+}
+
+func (e *PendingError) Error() string {
+	return fmt.Sprintf("waiting for wave %d to be ready: %s", e.wave, strings.Join(e.resources, ", "))
+}
+
+// Wave returns the index of the wave which is not ready
+func (e *PendingError) Wave() int {
+	return e.wave
+}
+
+// IsPending returns true if <err> is a PendingError
+func IsPending(err error) bool {
+	_, ok := err.(*PendingError)
+	return ok
+}
+
+// WaveTimeoutError is returned when the resources of a wave are not ready
+// within the timeout of the wave. Waiting longer is not expected to help,
+// so the operation fails.
+type WaveTimeoutError struct {
+	pending *PendingError
+}
+
+func (e *WaveTimeoutError) Error() string {
+	return fmt.Sprintf("wave %d not ready within %s: %s", e.pending.wave, e.pending.timeout, strings.Join(e.pending.resources, ", "))
+}
+
+// IsWaveTimeout returns true if <err> is a WaveTimeoutError
+func IsWaveTimeout(err error) bool {
+	_, ok := err.(*WaveTimeoutError)
+	return ok
+}
+
+// CheckWaveTimeout returns a WaveTimeoutError if <err> is a PendingError
+// and the wave has been pending since <since> for longer than its timeout
+func CheckWaveTimeout(err error, since time.Time) error {
+	pending, ok := err.(*PendingError)
+	if !ok || time.Since(since) <= pending.timeout {
+		return nil
+	}
+	return &WaveTimeoutError{
+		pending: pending,
+	}
+}
+
+// waveTimeout returns the longest WaveTimeoutAnnotation of the resources
+func waveTimeout(resources []*unstructured.Unstructured) (time.Duration, error) {
+	var timeout time.Duration
+	for _, resource := range resources {
+		value, ok := resource.GetAnnotations()[WaveTimeoutAnnotation]
+		if !ok {
+			continue
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s %s of %s. %v", WaveTimeoutAnnotation, value, resourceKey(resource.GetKind(), resource.GetName()), err)
+		}
+		if d > timeout {
+			timeout = d
+		}
+	}
+	if timeout == 0 {
+		timeout = DefaultWaveTimeout
+	}
+	return timeout, nil
+}
+
+func resourceKey(kind, name string) string {
+	return kind + "/" + name
+}
+
+// orderWaves groups the resources in waves. A resource is in a later wave
+// than the resources it depends on. The order of the resources within a
+// wave is preserved.
+func orderWaves(resources []*unstructured.Unstructured) ([][]*unstructured.Unstructured, error) {
+	indices := make(map[string]int, len(resources))
+	for i, resource := range resources {
+		indices[resourceKey(resource.GetKind(), resource.GetName())] = i
+	}
+
+	explicit := make([]int, len(resources))
+	dependencies := make([][]int, len(resources))
+	for i, resource := range resources {
+		key := resourceKey(resource.GetKind(), resource.GetName())
+		annotations := resource.GetAnnotations()
+		if wave, ok := annotations[WaveAnnotation]; ok {
+			value, err := strconv.Atoi(strings.TrimSpace(wave))
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %s of %s. %v", WaveAnnotation, wave, key, err)
+			}
+			explicit[i] = value
+		}
+		if dependsOn, ok := annotations[DependsOnAnnotation]; ok {
+			for _, dependency := range strings.Split(dependsOn, ",") {
+				dependency = strings.TrimSpace(dependency)
+				if dependency == "" {
+					continue
+				}
+				j, ok := indices[dependency]
+				if !ok {
+					return nil, fmt.Errorf("%s depends on %s which is not rendered", key, dependency)
+				}
+				dependencies[i] = append(dependencies[i], j)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	waves := make([]int, len(resources))
+	states := make([]int, len(resources))
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch states[i] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("cyclic dependency on %s", resourceKey(resources[i].GetKind(), resources[i].GetName()))
+		}
+		states[i] = visiting
+		wave := explicit[i]
+		for _, j := range dependencies[i] {
+			if err := visit(j); err != nil {
+				return err
+			}
+			if waves[j] >= wave {
+				wave = waves[j] + 1
+			}
+		}
+		waves[i] = wave
+		states[i] = visited
+		return nil
+	}
+	for i := range resources {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+
+	order := make([]int, len(resources))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return waves[order[a]] < waves[order[b]]
+	})
+	var result [][]*unstructured.Unstructured
+	for k, i := range order {
+		if k == 0 || waves[i] != waves[order[k-1]] {
+			result = append(result, nil)
+		}
+		result[len(result)-1] = append(result[len(result)-1], resources[i])
+	}
+	return result, nil
+}
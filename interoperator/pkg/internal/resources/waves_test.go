@@ -0,0 +1,175 @@
+package resources
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/dynamic"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func Test_orderWaves(t *testing.T) {
+	resource := func(kind, name string, annotations map[string]string) string {
+		content := "apiVersion: v1\nkind: " + kind + "\nmetadata:\n  name: " + name + "\n"
+		if len(annotations) > 0 {
+			content += "  annotations:\n"
+			for key, value := range annotations {
+				content += "    " + key + ": \"" + value + "\"\n"
+			}
+		}
+		return content
+	}
+
+	tests := []struct {
+		name    string
+		content []string
+		want    [][]string
+		wantErr bool
+	}{
+		{
+			name: "keeps the order of the resources without annotations",
+			content: []string{
+				resource("StatefulSet", "db", nil),
+				resource("Secret", "credentials", nil),
+			},
+			want: [][]string{{"StatefulSet/db", "Secret/credentials"}},
+		},
+		{
+			name: "orders the resources by wave",
+			content: []string{
+				resource("Job", "schema", map[string]string{WaveAnnotation: "2"}),
+				resource("StatefulSet", "db", map[string]string{WaveAnnotation: "1"}),
+				resource("Secret", "credentials", nil),
+				resource("ConfigMap", "config", nil),
+			},
+			want: [][]string{{"Secret/credentials", "ConfigMap/config"}, {"StatefulSet/db"}, {"Job/schema"}},
+		},
+		{
+			name: "puts the resources after their dependencies",
+			content: []string{
+				resource("Job", "schema", map[string]string{DependsOnAnnotation: "StatefulSet/db"}),
+				resource("StatefulSet", "db", map[string]string{DependsOnAnnotation: "Secret/credentials, ConfigMap/config"}),
+				resource("Secret", "credentials", nil),
+				resource("ConfigMap", "config", map[string]string{WaveAnnotation: "-1"}),
+			},
+			want: [][]string{{"ConfigMap/config"}, {"Secret/credentials"}, {"StatefulSet/db"}, {"Job/schema"}},
+		},
+		{
+			name: "fails for an invalid wave",
+			content: []string{
+				resource("Secret", "credentials", map[string]string{WaveAnnotation: "first"}),
+			},
+			wantErr: true,
+		},
+		{
+			name: "fails for a dependency which is not rendered",
+			content: []string{
+				resource("StatefulSet", "db", map[string]string{DependsOnAnnotation: "Secret/credentials"}),
+			},
+			wantErr: true,
+		},
+		{
+			name: "fails for cyclic dependencies",
+			content: []string{
+				resource("StatefulSet", "db", map[string]string{DependsOnAnnotation: "Secret/credentials"}),
+				resource("Secret", "credentials", map[string]string{DependsOnAnnotation: "StatefulSet/db"}),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects, err := dynamic.StringToUnstructured(strings.Join(tt.content, "---\n"))
+			if err != nil {
+				t.Fatalf("StringToUnstructured() error = %v", err)
+			}
+			waves, err := orderWaves(objects)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("orderWaves() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			var got [][]string
+			for _, wave := range waves {
+				var keys []string
+				for _, object := range wave {
+					keys = append(keys, resourceKey(object.GetKind(), object.GetName()))
+				}
+				got = append(got, keys)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("orderWaves() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_waveTimeout(t *testing.T) {
+	resource := func(timeout string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetKind("Job")
+		obj.SetName("schema")
+		if timeout != "" {
+			obj.SetAnnotations(map[string]string{WaveTimeoutAnnotation: timeout})
+		}
+		return obj
+	}
+
+	tests := []struct {
+		name      string
+		resources []*unstructured.Unstructured
+		want      time.Duration
+		wantErr   bool
+	}{
+		{
+			name:      "defaults the timeout",
+			resources: []*unstructured.Unstructured{resource("")},
+			want:      DefaultWaveTimeout,
+		},
+		{
+			name:      "uses the longest timeout of the wave",
+			resources: []*unstructured.Unstructured{resource("5m"), resource(""), resource("1h")},
+			want:      time.Hour,
+		},
+		{
+			name:      "fails for an invalid timeout",
+			resources: []*unstructured.Unstructured{resource("soon")},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := waveTimeout(tt.resources)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("waveTimeout() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("waveTimeout() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckWaveTimeout(t *testing.T) {
+	pending := &PendingError{
+		wave:      1,
+		resources: []string{"StatefulSet/db"},
+		timeout:   time.Minute,
+	}
+	if err := CheckWaveTimeout(pending, time.Now()); err != nil {
+		t.Errorf("CheckWaveTimeout() = %v, want nil", err)
+	}
+	err := CheckWaveTimeout(pending, time.Now().Add(-2*time.Minute))
+	if !IsWaveTimeout(err) {
+		t.Fatalf("CheckWaveTimeout() = %v, want WaveTimeoutError", err)
+	}
+	if want := "wave 1 not ready within 1m0s: StatefulSet/db"; err.Error() != want {
+		t.Errorf("CheckWaveTimeout() = %v, want %v", err, want)
+	}
+	if err := CheckWaveTimeout(fmt.Errorf("other error"), time.Time{}); err != nil {
+		t.Errorf("CheckWaveTimeout() = %v, want nil", err)
+	}
+}
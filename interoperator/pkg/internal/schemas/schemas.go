@@ -0,0 +1,51 @@
+package schemas
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ValidationError is returned when the parameters do not match the schema
+type ValidationError struct {
+	errors []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid parameters: %s", strings.Join(e.errors, ", "))
+}
+
+// IsValidationError returns true if <err> is a ValidationError
+func IsValidationError(err error) bool {
+	_, ok := err.(*ValidationError)
+	return ok
+}
+
+// Validate validates the parameters against the JSON schema. Parameters
+// which are not set are validated as an empty object. Any parameters are
+// valid if the schema is not set. A ValidationError is returned if the
+// parameters do not match the schema, any other error if the schema is
+// invalid.
+func Validate(schema, parameters *runtime.RawExtension) error {
+	if schema == nil || len(schema.Raw) == 0 {
+		return nil
+	}
+	document := []byte("{}")
+	if parameters != nil && len(parameters.Raw) > 0 {
+		document = parameters.Raw
+	}
+	result, err := gojsonschema.Validate(gojsonschema.NewBytesLoader(schema.Raw), gojsonschema.NewBytesLoader(document))
+	if err != nil {
+		return fmt.Errorf("failed to validate parameters. %v", err)
+	}
+	if result.Valid() {
+		return nil
+	}
+	errors := make([]string, 0, len(result.Errors()))
+	for _, resultError := range result.Errors() {
+		errors = append(errors, resultError.String())
+	}
+	return &ValidationError{errors: errors}
+}
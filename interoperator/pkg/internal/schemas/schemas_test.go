@@ -0,0 +1,63 @@
+package schemas
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestValidate(t *testing.T) {
+	schema := &runtime.RawExtension{Raw: []byte(`{
+		"$schema": "http://json-schema.org/draft-04/schema#",
+		"type": "object",
+		"properties": {
+			"size": {"type": "integer", "minimum": 1}
+		},
+		"additionalProperties": false
+	}`)}
+	tests := []struct {
+		name           string
+		schema         *runtime.RawExtension
+		parameters     *runtime.RawExtension
+		wantErr        bool
+		wantValidation bool
+	}{
+		{
+			name:       "valid parameters",
+			schema:     schema,
+			parameters: &runtime.RawExtension{Raw: []byte(`{"size": 2}`)},
+		},
+		{
+			name:   "parameters not set",
+			schema: schema,
+		},
+		{
+			name:       "schema not set",
+			parameters: &runtime.RawExtension{Raw: []byte(`{"foo": "bar"}`)},
+		},
+		{
+			name:           "invalid parameters",
+			schema:         schema,
+			parameters:     &runtime.RawExtension{Raw: []byte(`{"size": 0, "foo": "bar"}`)},
+			wantErr:        true,
+			wantValidation: true,
+		},
+		{
+			name:       "invalid schema",
+			schema:     &runtime.RawExtension{Raw: []byte(`{"type": 1}`)},
+			parameters: &runtime.RawExtension{Raw: []byte(`{}`)},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.schema, tt.parameters)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if IsValidationError(err) != tt.wantValidation {
+				t.Errorf("IsValidationError() = %v, want %v", IsValidationError(err), tt.wantValidation)
+			}
+		})
+	}
+}
@@ -8,6 +8,26 @@ import (
 	kubernetes "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// Namespace is the namespace of the SFServices and SFPlans. The instances and
+// bindings may be created in other namespaces.
+const Namespace = "default"
+
+// NotFoundError is returned by FindServiceInfo when the service or the plan
+// does not exist
+type NotFoundError struct {
+	message string
+}
+
+func (e *NotFoundError) Error() string {
+	return e.message
+}
+
+// IsNotFound returns true if <err> is a NotFoundError
+func IsNotFound(err error) bool {
+	_, ok := err.(*NotFoundError)
+	return ok
+}
+
 // FindServiceInfo fetches the details of a service
 // from the services path
 func FindServiceInfo(client kubernetes.Client, serviceID string, planID string, namespace string) (*osbv1alpha1.SFService, *osbv1alpha1.SFPlan, error) {
@@ -28,7 +48,9 @@ func FindServiceInfo(client kubernetes.Client, serviceID string, planID string,
 		}
 	}
 	if service == nil {
-		return nil, nil, fmt.Errorf("unable to find service with id %s", serviceID)
+		return nil, nil, &NotFoundError{
+			message: fmt.Sprintf("unable to find service with id %s", serviceID),
+		}
 	}
 
 	plans := &osbv1alpha1.SFPlanList{}
@@ -48,5 +70,7 @@ func FindServiceInfo(client kubernetes.Client, serviceID string, planID string,
 			return service, &plan, nil
 		}
 	}
-	return nil, nil, fmt.Errorf("unable to find plan with service id %s and plan id %s", serviceID, planID)
+	return nil, nil, &NotFoundError{
+		message: fmt.Sprintf("unable to find plan with service id %s and plan id %s", serviceID, planID),
+	}
 }
@@ -46,6 +46,7 @@ func TestMain(m *testing.M) {
 }
 
 func TestFindServiceInfo(t *testing.T) {
+	planUpdatable := true
 	g := gomega.NewGomegaWithT(t)
 
 	templateSpec := []osbv1alpha1.TemplateSpec{
@@ -87,7 +88,7 @@ func TestFindServiceInfo(t *testing.T) {
 			Metadata:      nil,
 			Free:          false,
 			Bindable:      true,
-			PlanUpdatable: true,
+			PlanUpdatable: &planUpdatable,
 			Schemas:       nil,
 			Templates:     templateSpec,
 			ServiceID:     "service-id",
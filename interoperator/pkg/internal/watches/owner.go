@@ -0,0 +1,45 @@
+package watches
+
+import (
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/resources"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// EnqueueRequestForOwner enqueues the owner of the watched resources, either
+// referenced by the owner reference of the resource or, for cluster scoped
+// resources and resources in other namespaces, by its owner labels
+type EnqueueRequestForOwner struct {
+	handler.EnqueueRequestForOwner
+
+	// OwnerKind is the kind of the owner in the owner labels
+	OwnerKind string
+}
+
+// NewEnqueueRequestForOwner returns a handler enqueuing the owners of type
+// <ownerType> and kind <ownerKind> of the watched resources
+func NewEnqueueRequestForOwner(ownerType runtime.Object, ownerKind string) *EnqueueRequestForOwner {
+	return &EnqueueRequestForOwner{
+		EnqueueRequestForOwner: handler.EnqueueRequestForOwner{
+			IsController: true,
+			OwnerType:    ownerType,
+		},
+		OwnerKind: ownerKind,
+	}
+}
+
+// Generic implements handler.EventHandler. The watch manager forwards all
+// the events of the watched resources as generic events.
+func (e *EnqueueRequestForOwner) Generic(evt event.GenericEvent, q workqueue.RateLimitingInterface) {
+	if evt.Meta != nil {
+		if owner, ok := resources.OwnerOf(evt.Meta, e.OwnerKind); ok {
+			q.Add(reconcile.Request{NamespacedName: owner})
+			return
+		}
+	}
+	e.EnqueueRequestForOwner.Generic(evt, q)
+}
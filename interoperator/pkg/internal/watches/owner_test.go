@@ -0,0 +1,82 @@
+package watches
+
+import (
+	"testing"
+
+	osbv1alpha1 "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis/osb/v1alpha1"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/resources"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestEnqueueRequestForOwner_Generic(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := osbv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("AddToScheme() error = %v", err)
+	}
+	h := NewEnqueueRequestForOwner(&osbv1alpha1.SFServiceInstance{}, "SFServiceInstance")
+	if err := h.InjectScheme(scheme); err != nil {
+		t.Fatalf("InjectScheme() error = %v", err)
+	}
+
+	labeled := &unstructured.Unstructured{}
+	labeled.SetAPIVersion("v1")
+	labeled.SetKind("Namespace")
+	labeled.SetName("instance-id")
+	labeled.SetLabels(map[string]string{
+		resources.OwnerKindLabel:      "SFServiceInstance",
+		resources.OwnerNamespaceLabel: "default",
+		resources.OwnerNameLabel:      "instance-id",
+	})
+
+	referenced := &unstructured.Unstructured{}
+	referenced.SetAPIVersion("v1")
+	referenced.SetKind("ConfigMap")
+	referenced.SetName("config")
+	referenced.SetNamespace("default")
+	referenced.SetOwnerReferences([]metav1.OwnerReference{
+		{
+			APIVersion: osbv1alpha1.SchemeGroupVersion.String(),
+			Kind:       "SFServiceInstance",
+			Name:       "other-instance-id",
+			Controller: func(b bool) *bool { return &b }(true),
+		},
+	})
+
+	tests := []struct {
+		name string
+		obj  *unstructured.Unstructured
+		want types.NamespacedName
+	}{
+		{
+			name: "labeled with owner",
+			obj:  labeled,
+			want: types.NamespacedName{Namespace: "default", Name: "instance-id"},
+		},
+		{
+			name: "referencing owner",
+			obj:  referenced,
+			want: types.NamespacedName{Namespace: "default", Name: "other-instance-id"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), tt.name)
+			defer q.ShutDown()
+			h.Generic(event.GenericEvent{Meta: tt.obj, Object: tt.obj}, q)
+			if q.Len() != 1 {
+				t.Fatalf("EnqueueRequestForOwner.Generic() enqueued %d requests, want 1", q.Len())
+			}
+			item, _ := q.Get()
+			if got := item.(reconcile.Request).NamespacedName; got != tt.want {
+				t.Errorf("EnqueueRequestForOwner.Generic() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,502 @@
+package watches
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	osbv1alpha1 "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis/osb/v1alpha1"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/cluster/factory"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/resources"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+var log = logf.Log.WithName("watches")
+
+// staleWatchPeriod is the time the watches used by a plan before its update
+// are kept for the resources rendered from the previous templates
+const staleWatchPeriod = 10 * time.Minute
+
+// Manager starts the watches on the kinds of the sub resources on demand.
+// A kind is watched as long as a plan uses it. The events of the watched
+// resources are forwarded to the controllers. One Manager is shared by the
+// controllers of a manager.Manager.
+type Manager interface {
+	// Start forwards the events of the watched resources to the handler of
+	// the controller and stops the watches used by a plan once it is deleted
+	// or no longer used after its update
+	Start(c controller.Controller, h handler.EventHandler) error
+	// AddWatches starts the watches on the kinds used by the plan in the
+	// SFCluster <clusterID>, or in the local cluster if <clusterID> is empty
+	AddWatches(planID, clusterID string, gvks ...schema.GroupVersionKind) error
+	// RemoveWatches stops the watches on the kinds not used by any other plan
+	RemoveWatches(planID string)
+}
+
+// informerFunc returns a new informer of the kind
+type informerFunc func(gvk schema.GroupVersionKind) (cache.SharedIndexInformer, error)
+
+// watchKey identifies the watch of a kind in a cluster
+type watchKey struct {
+	// cluster is the name of the SFCluster, empty for the local cluster
+	cluster string
+	gvk     schema.GroupVersionKind
+}
+
+// remoteCluster holds the informers of a data plane cluster
+type remoteCluster struct {
+	informer informerFunc
+	// checksum is the checksum of the kubeconfig the informers are built
+	// from
+	checksum string
+}
+
+type watchManager struct {
+	mu sync.Mutex
+	// events are the channels of the controllers the events are forwarded to
+	events      []chan event.GenericEvent
+	planWatched bool
+	stop        <-chan struct{}
+	informer    informerFunc
+	// clusterConfig returns the client config of the cached client of a
+	// SFCluster and the checksum of its kubeconfig
+	clusterConfig func(clusterID string) (*rest.Config, string, error)
+	clusters      map[string]*remoteCluster
+	watches       map[watchKey]chan struct{}
+	// refs is the number of plans using a watch
+	refs  map[watchKey]int
+	plans map[string]map[watchKey]struct{}
+	// stale are the watches used by a plan before its update and not used
+	// since. They are released after staleTTL.
+	stale map[string]map[watchKey]struct{}
+	// updates is the sequence number of the last update of a plan
+	updates   map[string]int
+	updateSeq int
+	staleTTL  time.Duration
+}
+
+var (
+	managersLock sync.Mutex
+	managers     = make(map[manager.Manager]*watchManager)
+)
+
+// New returns the Manager watching the resources of the cluster of the
+// provided manager. The same Manager is returned for the same manager.
+func New(mgr manager.Manager) (Manager, error) {
+	if mgr == nil {
+		return nil, fmt.Errorf("invalid input to new watch manager")
+	}
+	managersLock.Lock()
+	defer managersLock.Unlock()
+	if m, ok := managers[mgr]; ok {
+		return m, nil
+	}
+	m := newWatchManager()
+	informer, err := newInformerFunc(mgr.GetConfig(), mgr.GetRESTMapper(), "")
+	if err != nil {
+		return nil, err
+	}
+	m.informer = informer
+	clusterFactory, err := factory.New(mgr)
+	if err != nil {
+		return nil, err
+	}
+	m.clusterConfig = clusterFactory.GetClusterRESTConfig
+	// Stop the watches along with the manager
+	if err := mgr.SetFields(m); err != nil {
+		return nil, err
+	}
+	managers[mgr] = m
+	return m, nil
+}
+
+func newWatchManager() *watchManager {
+	return &watchManager{
+		clusters: make(map[string]*remoteCluster),
+		watches:  make(map[watchKey]chan struct{}),
+		refs:     make(map[watchKey]int),
+		plans:    make(map[string]map[watchKey]struct{}),
+		stale:    make(map[string]map[watchKey]struct{}),
+		updates:  make(map[string]int),
+		staleTTL: staleWatchPeriod,
+	}
+}
+
+// newInformerFunc returns an informerFunc creating informers of the
+// resources of the cluster matching the label selector. The mapper is
+// refreshed from the discovery information of the cluster if it is nil or
+// does not know a kind.
+func newInformerFunc(cfg *rest.Config, mapper meta.RESTMapper, labelSelector string) (informerFunc, error) {
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return func(gvk schema.GroupVersionKind) (cache.SharedIndexInformer, error) {
+		var mapping *meta.RESTMapping
+		var err error
+		if mapper != nil {
+			mapping, err = mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		}
+		if mapper == nil || meta.IsNoMatchError(err) {
+			// The kind may be served by a custom resource definition
+			// created after the mapper was built
+			groupResources, discoveryErr := restmapper.GetAPIGroupResources(discoveryClient)
+			if discoveryErr != nil {
+				return nil, discoveryErr
+			}
+			mapper = restmapper.NewDiscoveryRESTMapper(groupResources)
+			mapping, err = mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		}
+		if err != nil {
+			return nil, err
+		}
+		resource := dynamicClient.Resource(mapping.Resource)
+		return cache.NewSharedIndexInformer(&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				options.LabelSelector = labelSelector
+				return resource.List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				options.LabelSelector = labelSelector
+				return resource.Watch(options)
+			},
+		}, &unstructured.Unstructured{}, 0, cache.Indexers{}), nil
+	}, nil
+}
+
+// InjectStopChannel is called by the manager to set the channel closed when
+// the manager stops
+func (m *watchManager) InjectStopChannel(stop <-chan struct{}) error {
+	m.stop = stop
+	return nil
+}
+
+// Start forwards the events of the watched resources to the handler of the
+// controller and stops the watches used by a plan once it is deleted or no
+// longer used after its update
+func (m *watchManager) Start(c controller.Controller, h handler.EventHandler) error {
+	err := c.Watch(&source.Channel{Source: m.subscribe()}, h)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.planWatched {
+		return nil
+	}
+	err = c.Watch(&source.Kind{Type: &osbv1alpha1.SFPlan{}}, handler.Funcs{
+		UpdateFunc: func(e event.UpdateEvent, q workqueue.RateLimitingInterface) {
+			oldPlan, ok := e.ObjectOld.(*osbv1alpha1.SFPlan)
+			if !ok {
+				return
+			}
+			plan, ok := e.ObjectNew.(*osbv1alpha1.SFPlan)
+			if ok && !reflect.DeepEqual(oldPlan.Spec, plan.Spec) {
+				m.expireWatches(plan.Spec.ID)
+			}
+		},
+		DeleteFunc: func(e event.DeleteEvent, q workqueue.RateLimitingInterface) {
+			if plan, ok := e.Object.(*osbv1alpha1.SFPlan); ok {
+				m.RemoveWatches(plan.Spec.ID)
+			}
+		},
+	})
+	if err != nil {
+		return err
+	}
+	m.planWatched = true
+	return nil
+}
+
+// subscribe returns a new channel the events are forwarded to
+func (m *watchManager) subscribe() chan event.GenericEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	events := make(chan event.GenericEvent)
+	m.events = append(m.events, events)
+	return events
+}
+
+// AddWatches starts the watches on the kinds used by the plan in the
+// SFCluster <clusterID>, or in the local cluster if <clusterID> is empty.
+// Kinds which can not be watched are reported in the error, the other kinds
+// are watched.
+func (m *watchManager) AddWatches(planID, clusterID string, gvks ...schema.GroupVersionKind) error {
+	var cfg *rest.Config
+	var checksum string
+	if clusterID != "" {
+		// The config of the cached client of the cluster is looked up
+		// outside of the lock, an unreachable cluster must not block the
+		// watches of the other clusters
+		var err error
+		cfg, checksum, err = m.clusterConfig(clusterID)
+		if err != nil {
+			return fmt.Errorf("failed to watch cluster %s. %v", clusterID, err)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cluster := clusterID
+	informer := m.informer
+	if clusterID != "" {
+		var err error
+		informer, err = m.clusterInformer(cluster, cfg, checksum)
+		if err != nil {
+			return fmt.Errorf("failed to watch cluster %s. %v", clusterID, err)
+		}
+	}
+
+	var failed []string
+	for _, gvk := range gvks {
+		if gvk.Kind == "" || gvk.Version == "" {
+			continue
+		}
+		key := watchKey{cluster: cluster, gvk: gvk}
+		if _, ok := m.watches[key]; !ok {
+			informer, err := informer(gvk)
+			if err != nil {
+				failed = append(failed, fmt.Sprintf("%s. %v", gvk.String(), err))
+				continue
+			}
+			stop := make(chan struct{})
+			remote := cluster != ""
+			informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+				AddFunc: func(obj interface{}) {
+					m.forward(obj, remote, stop)
+				},
+				UpdateFunc: func(oldObj, newObj interface{}) {
+					m.forward(newObj, remote, stop)
+				},
+				DeleteFunc: func(obj interface{}) {
+					m.forward(obj, remote, stop)
+				},
+			})
+			go informer.Run(m.mergeStop(stop))
+			m.watches[key] = stop
+			log.Info("started watch", "kind", gvk.String(), "clusterID", clusterID)
+		}
+		m.acquire(planID, key)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to watch %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// clusterInformer returns the informerFunc of the SFCluster, watching only
+// the resources labeled with their owner. The watches on the cluster are
+// stopped once its kubeconfig changes, they are started again with the new
+// kubeconfig by the next AddWatches.
+func (m *watchManager) clusterInformer(cluster string, cfg *rest.Config, checksum string) (informerFunc, error) {
+	if c, ok := m.clusters[cluster]; ok {
+		if c.checksum == checksum {
+			return c.informer, nil
+		}
+		log.Info("kubeconfig of cluster changed. restarting watches", "cluster", cluster)
+		m.stopCluster(cluster)
+	}
+	informer, err := newInformerFunc(cfg, nil, resources.OwnerKindLabel)
+	if err != nil {
+		return nil, err
+	}
+	m.clusters[cluster] = &remoteCluster{
+		informer: informer,
+		checksum: checksum,
+	}
+	return informer, nil
+}
+
+// stopCluster stops the watches on the cluster
+func (m *watchManager) stopCluster(cluster string) {
+	for key, stop := range m.watches {
+		if key.cluster == cluster {
+			close(stop)
+			delete(m.watches, key)
+			delete(m.refs, key)
+		}
+	}
+	for _, plans := range []map[string]map[watchKey]struct{}{m.plans, m.stale} {
+		for _, keys := range plans {
+			for key := range keys {
+				if key.cluster == cluster {
+					delete(keys, key)
+				}
+			}
+		}
+	}
+	delete(m.clusters, cluster)
+}
+
+// acquire records the use of the watch by the plan. A watch used by the
+// plan before its update is used again.
+func (m *watchManager) acquire(planID string, key watchKey) {
+	if _, ok := m.plans[planID][key]; ok {
+		return
+	}
+	if _, ok := m.stale[planID][key]; ok {
+		delete(m.stale[planID], key)
+	} else {
+		m.refs[key]++
+	}
+	if _, ok := m.plans[planID]; !ok {
+		m.plans[planID] = make(map[watchKey]struct{})
+	}
+	m.plans[planID][key] = struct{}{}
+}
+
+// release drops a use of the watch and stops it once it is not used by any
+// plan
+func (m *watchManager) release(key watchKey) {
+	m.refs[key]--
+	if m.refs[key] > 0 {
+		return
+	}
+	delete(m.refs, key)
+	if stop, ok := m.watches[key]; ok {
+		close(stop)
+		delete(m.watches, key)
+		log.Info("stopped watch", "kind", key.gvk.String(), "cluster", key.cluster)
+	}
+}
+
+// RemoveWatches stops the watches on the kinds not used by any other plan
+func (m *watchManager) RemoveWatches(planID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, plans := range []map[string]map[watchKey]struct{}{m.plans, m.stale} {
+		for key := range plans[planID] {
+			m.release(key)
+		}
+		delete(plans, planID)
+	}
+	delete(m.updates, planID)
+}
+
+// expireWatches marks the watches used by the plan as stale once it is
+// updated. The watches are released after staleTTL unless the plan uses
+// them again, as the templates of the plan may no longer render their kinds.
+func (m *watchManager) expireWatches(planID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.plans[planID]) == 0 {
+		return
+	}
+	if _, ok := m.stale[planID]; !ok {
+		m.stale[planID] = make(map[watchKey]struct{})
+	}
+	for key := range m.plans[planID] {
+		m.stale[planID][key] = struct{}{}
+	}
+	delete(m.plans, planID)
+	m.updateSeq++
+	update := m.updateSeq
+	m.updates[planID] = update
+	time.AfterFunc(m.staleTTL, func() {
+		m.releaseStale(planID, update)
+	})
+}
+
+// releaseStale releases the stale watches of the plan unless it was updated
+// again in the meantime
+func (m *watchManager) releaseStale(planID string, update int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.updates[planID] != update {
+		return
+	}
+	for key := range m.stale[planID] {
+		m.release(key)
+	}
+	delete(m.stale, planID)
+	delete(m.updates, planID)
+}
+
+// mergeStop returns a channel closed once the watch or the manager stops
+func (m *watchManager) mergeStop(stop <-chan struct{}) <-chan struct{} {
+	merged := make(chan struct{})
+	go func() {
+		defer close(merged)
+		select {
+		case <-stop:
+		case <-m.stop:
+		}
+	}()
+	return merged
+}
+
+// forward sends the event of a watched resource to the controller. Only the
+// resources labeled with their owner are forwarded from a data plane
+// cluster, as the owner references there do not refer to the control plane.
+func (m *watchManager) forward(obj interface{}, remote bool, stop <-chan struct{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	object, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return
+	}
+	if remote && object.GetLabels()[resources.OwnerKindLabel] == "" {
+		return
+	}
+	m.mu.Lock()
+	subscribers := m.events
+	m.mu.Unlock()
+	for _, events := range subscribers {
+		select {
+		case events <- event.GenericEvent{Meta: object, Object: object}:
+		case <-stop:
+			return
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+// SourceKinds returns the kinds of the sources
+func SourceKinds(sources []osbv1alpha1.Source) []schema.GroupVersionKind {
+	gvks := make([]schema.GroupVersionKind, 0, len(sources))
+	for _, source := range sources {
+		gvks = append(gvks, schema.FromAPIVersionAndKind(source.APIVersion, source.Kind))
+	}
+	return gvks
+}
+
+// ObjectKinds returns the kinds of the resources
+func ObjectKinds(objects []*unstructured.Unstructured) []schema.GroupVersionKind {
+	gvks := make([]schema.GroupVersionKind, 0, len(objects))
+	for _, object := range objects {
+		if object != nil {
+			gvks = append(gvks, object.GroupVersionKind())
+		}
+	}
+	return gvks
+}
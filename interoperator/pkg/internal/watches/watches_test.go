@@ -0,0 +1,321 @@
+package watches
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	osbv1alpha1 "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis/osb/v1alpha1"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/resources"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+var (
+	director = schema.GroupVersionKind{Group: "deployment.servicefabrik.io", Version: "v1alpha1", Kind: "Director"}
+	docker   = schema.GroupVersionKind{Group: "deployment.servicefabrik.io", Version: "v1alpha1", Kind: "Docker"}
+	unknown  = schema.GroupVersionKind{Group: "unknown.servicefabrik.io", Version: "v1alpha1", Kind: "Unknown"}
+)
+
+// newTestWatchManager returns a watch manager whose informers watch fake
+// watchers
+func newTestWatchManager() (*watchManager, map[schema.GroupVersionKind]*watch.FakeWatcher) {
+	m := newWatchManager()
+	watchers := make(map[schema.GroupVersionKind]*watch.FakeWatcher)
+	m.informer = func(gvk schema.GroupVersionKind) (cache.SharedIndexInformer, error) {
+		if gvk == unknown {
+			return nil, fmt.Errorf("no matches for kind %s", gvk.Kind)
+		}
+		watcher := watch.NewFake()
+		watchers[gvk] = watcher
+		return cache.NewSharedIndexInformer(&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return &unstructured.UnstructuredList{}, nil
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return watcher, nil
+			},
+		}, &unstructured.Unstructured{}, 0, cache.Indexers{}), nil
+	}
+	return m, watchers
+}
+
+func Test_watchManager_AddWatches(t *testing.T) {
+	m, watchers := newTestWatchManager()
+	stop := make(chan struct{})
+	defer close(stop)
+	m.stop = stop
+	events := []chan event.GenericEvent{m.subscribe(), m.subscribe()}
+
+	if err := m.AddWatches("plan-1", "", director, docker, schema.GroupVersionKind{}); err != nil {
+		t.Errorf("AddWatches() error = %v", err)
+	}
+	if err := m.AddWatches("plan-2", "", director); err != nil {
+		t.Errorf("AddWatches() error = %v", err)
+	}
+	if len(m.watches) != 2 || len(watchers) != 2 {
+		t.Errorf("AddWatches() started %d watches, want 2", len(watchers))
+	}
+	if err := m.AddWatches("plan-2", "", unknown); err == nil {
+		t.Errorf("AddWatches() error = nil, want error for %s", unknown.Kind)
+	}
+	if _, ok := m.watches[watchKey{gvk: unknown}]; ok {
+		t.Errorf("AddWatches() watches %s", unknown.Kind)
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(director)
+	obj.SetName("instance-id")
+	obj.SetNamespace("default")
+	watchers[director].Add(obj)
+	// The event is forwarded to every controller
+	for i := range events {
+		select {
+		case evt := <-events[i]:
+			if evt.Meta.GetName() != "instance-id" {
+				t.Errorf("forwarded event of %s, want instance-id", evt.Meta.GetName())
+			}
+		case <-time.After(5 * time.Second):
+			t.Errorf("event of %s not forwarded to controller %d", director.Kind, i)
+		}
+	}
+}
+
+func Test_watchManager_AddWatches_cluster(t *testing.T) {
+	m, watchers := newTestWatchManager()
+	stop := make(chan struct{})
+	defer close(stop)
+	m.stop = stop
+	events := m.subscribe()
+	checksum := "checksum"
+	m.clusterConfig = func(clusterID string) (*rest.Config, string, error) {
+		if clusterID == "unknown" {
+			return nil, "", fmt.Errorf("sfclusters %s not found", clusterID)
+		}
+		return &rest.Config{}, checksum, nil
+	}
+	m.clusters["cluster-id"] = &remoteCluster{
+		informer: m.informer,
+		checksum: checksum,
+	}
+	key := watchKey{cluster: "cluster-id", gvk: director}
+
+	if err := m.AddWatches("plan-1", "cluster-id", director); err != nil {
+		t.Errorf("AddWatches() error = %v", err)
+	}
+	if _, ok := m.watches[key]; !ok {
+		t.Errorf("AddWatches() did not watch %s in cluster-id", director.Kind)
+	}
+	if _, ok := m.watches[watchKey{gvk: director}]; ok {
+		t.Errorf("AddWatches() watches %s in the local cluster", director.Kind)
+	}
+	if err := m.AddWatches("plan-1", "unknown", director); err == nil {
+		t.Errorf("AddWatches() error = nil, want error for unknown cluster")
+	}
+
+	// Only the resources labeled with their owner are forwarded
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(director)
+	obj.SetName("other")
+	obj.SetNamespace("default")
+	watchers[director].Add(obj)
+	obj = obj.DeepCopy()
+	obj.SetName("instance-id")
+	obj.SetLabels(map[string]string{
+		resources.OwnerKindLabel:      "SFServiceInstance",
+		resources.OwnerNamespaceLabel: "default",
+		resources.OwnerNameLabel:      "instance-id",
+	})
+	watchers[director].Add(obj)
+	select {
+	case evt := <-events:
+		if evt.Meta.GetName() != "instance-id" {
+			t.Errorf("forwarded event of %s, want instance-id", evt.Meta.GetName())
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("event of %s not forwarded", director.Kind)
+	}
+
+	// The watches are stopped once the kubeconfig changes
+	watchStop := m.watches[key]
+	checksum = "changed"
+	if err := m.AddWatches("plan-1", "cluster-id"); err != nil {
+		t.Errorf("AddWatches() error = %v", err)
+	}
+	if _, ok := m.watches[key]; ok {
+		t.Errorf("AddWatches() did not stop the watch of %s in cluster-id", director.Kind)
+	}
+	select {
+	case <-watchStop:
+	default:
+		t.Errorf("AddWatches() did not close the stop channel of %s", director.Kind)
+	}
+	if m.clusters["cluster-id"].checksum != "changed" {
+		t.Errorf("AddWatches() did not recreate the informers of cluster-id")
+	}
+}
+
+func Test_newInformerFunc(t *testing.T) {
+	selectors := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		selectors <- r.URL.Query().Get("labelSelector")
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("watch") == "true" {
+			return
+		}
+		fmt.Fprint(w, `{"apiVersion": "deployment.servicefabrik.io/v1alpha1", "kind": "DirectorList", "metadata": {"resourceVersion": "1"}, "items": []}`)
+	}))
+	defer server.Close()
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{})
+	mapper.Add(director, meta.RESTScopeNamespace)
+
+	informerFunc, err := newInformerFunc(&rest.Config{Host: server.URL}, mapper, resources.OwnerKindLabel)
+	if err != nil {
+		t.Fatalf("newInformerFunc() error = %v", err)
+	}
+	informer, err := informerFunc(director)
+	if err != nil {
+		t.Fatalf("informerFunc() error = %v", err)
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	go informer.Run(stop)
+	select {
+	case selector := <-selectors:
+		if selector != resources.OwnerKindLabel {
+			t.Errorf("informer listed with label selector %q, want %q", selector, resources.OwnerKindLabel)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("informer did not list %s", director.Kind)
+	}
+}
+
+func Test_watchManager_RemoveWatches(t *testing.T) {
+	m, _ := newTestWatchManager()
+	if err := m.AddWatches("plan-1", "", director, docker); err != nil {
+		t.Errorf("AddWatches() error = %v", err)
+	}
+	if err := m.AddWatches("plan-2", "", director); err != nil {
+		t.Errorf("AddWatches() error = %v", err)
+	}
+	directorStop := m.watches[watchKey{gvk: director}]
+	dockerStop := m.watches[watchKey{gvk: docker}]
+
+	m.RemoveWatches("plan-1")
+	if _, ok := m.watches[watchKey{gvk: docker}]; ok {
+		t.Errorf("RemoveWatches() did not stop the watch of %s", docker.Kind)
+	}
+	if _, ok := m.watches[watchKey{gvk: director}]; !ok {
+		t.Errorf("RemoveWatches() stopped the watch of %s used by plan-2", director.Kind)
+	}
+	select {
+	case <-dockerStop:
+	default:
+		t.Errorf("RemoveWatches() did not close the stop channel of %s", docker.Kind)
+	}
+
+	m.RemoveWatches("plan-2")
+	if len(m.watches) != 0 {
+		t.Errorf("RemoveWatches() left %d watches, want 0", len(m.watches))
+	}
+	select {
+	case <-directorStop:
+	default:
+		t.Errorf("RemoveWatches() did not close the stop channel of %s", director.Kind)
+	}
+
+	// Removing an unknown plan is a no-op
+	m.RemoveWatches("plan-3")
+
+	// A plan using a kind twice releases it once
+	if err := m.AddWatches("plan-1", "", director); err != nil {
+		t.Errorf("AddWatches() error = %v", err)
+	}
+	if err := m.AddWatches("plan-1", "", director); err != nil {
+		t.Errorf("AddWatches() error = %v", err)
+	}
+	if err := m.AddWatches("plan-2", "", director); err != nil {
+		t.Errorf("AddWatches() error = %v", err)
+	}
+	m.RemoveWatches("plan-1")
+	if m.refs[watchKey{gvk: director}] != 1 {
+		t.Errorf("RemoveWatches() left %d uses of %s, want 1", m.refs[watchKey{gvk: director}], director.Kind)
+	}
+	if _, ok := m.watches[watchKey{gvk: director}]; !ok {
+		t.Errorf("RemoveWatches() stopped the watch of %s used by plan-2", director.Kind)
+	}
+}
+
+func Test_watchManager_expireWatches(t *testing.T) {
+	m, _ := newTestWatchManager()
+	m.staleTTL = 10 * time.Millisecond
+	if err := m.AddWatches("plan-1", "", director, docker); err != nil {
+		t.Errorf("AddWatches() error = %v", err)
+	}
+
+	// The updated plan uses only director
+	m.expireWatches("plan-1")
+	if err := m.AddWatches("plan-1", "", director); err != nil {
+		t.Errorf("AddWatches() error = %v", err)
+	}
+	if m.refs[watchKey{gvk: director}] != 1 {
+		t.Errorf("AddWatches() counted %d uses of %s, want 1", m.refs[watchKey{gvk: director}], director.Kind)
+	}
+	if _, ok := m.watches[watchKey{gvk: docker}]; !ok {
+		t.Errorf("expireWatches() stopped the watch of %s before the stale period", docker.Kind)
+	}
+
+	err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		_, ok := m.watches[watchKey{gvk: docker}]
+		return !ok, nil
+	})
+	if err != nil {
+		t.Errorf("expireWatches() did not stop the watch of %s", docker.Kind)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.watches[watchKey{gvk: director}]; !ok {
+		t.Errorf("expireWatches() stopped the watch of %s still used", director.Kind)
+	}
+}
+
+func TestSourceKinds(t *testing.T) {
+	sources := []osbv1alpha1.Source{
+		osbv1alpha1.Source{
+			APIVersion: "deployment.servicefabrik.io/v1alpha1",
+			Kind:       "Director",
+			Name:       "instance-id",
+		},
+		osbv1alpha1.Source{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+			Name:       "instance-id",
+		},
+	}
+	got := SourceKinds(sources)
+	want := []schema.GroupVersionKind{
+		director,
+		schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("SourceKinds() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("SourceKinds()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2018 The Service Fabrik Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package render renders the templates of a plan offline, the same way the
+// controllers render them, without a kubernetes cluster.
+package render
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis"
+	osbv1alpha1 "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis/osb/v1alpha1"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/dynamic"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/properties"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/renderer/helm"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/resources"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/services"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	kubernetes "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// Objects are the objects the templates of a plan are rendered for
+type Objects struct {
+	Service  *osbv1alpha1.SFService
+	Plan     *osbv1alpha1.SFPlan
+	Instance *osbv1alpha1.SFServiceInstance
+	Binding  *osbv1alpha1.SFServiceBinding
+
+	// Sources are the objects captured from the cluster, which are read
+	// through the sources template to compute the status
+	Sources []*unstructured.Unstructured
+}
+
+// AddFlags registers the flags configuring the renders in <fs>, the
+// --kube-version and --api-versions of the helm charts
+func AddFlags(fs *flag.FlagSet) {
+	helm.AddFlags(fs)
+}
+
+// ExpectedResources returns the resources computed by
+// ResourceManager.ComputeExpectedResources for <action>
+func ExpectedResources(objects *Objects, action string) ([]*unstructured.Unstructured, error) {
+	client, err := objects.sourceClient(action)
+	if err != nil {
+		return nil, err
+	}
+	instanceID, bindingID, serviceID, planID, namespace := objects.ids()
+	return resources.New().ComputeExpectedResources(client, instanceID, bindingID, serviceID, planID, action, namespace)
+}
+
+// Sources returns the sources computed by ResourceManager.ComputeSources
+// for <action>
+func Sources(objects *Objects, action string) ([]osbv1alpha1.Source, error) {
+	client, err := objects.sourceClient(action)
+	if err != nil {
+		return nil, err
+	}
+	instanceID, bindingID, serviceID, planID, namespace := objects.ids()
+	return resources.New().ComputeSources(client, instanceID, bindingID, serviceID, planID, action, namespace)
+}
+
+// Status returns the status computed by ResourceManager.ComputeStatus
+// for <action> from the captured source objects
+func Status(objects *Objects, action string) (*properties.Status, error) {
+	client, err := objects.sourceClient(action)
+	if err != nil {
+		return nil, err
+	}
+	instanceID, bindingID, serviceID, planID, namespace := objects.ids()
+	targetObjects := make([]runtime.Object, 0, len(objects.Sources))
+	for _, obj := range objects.Sources {
+		obj = obj.DeepCopy()
+		if obj.GetNamespace() == "" {
+			obj.SetNamespace(namespace)
+		}
+		targetObjects = append(targetObjects, obj)
+	}
+	targetClient := fake.NewFakeClientWithScheme(scheme.Scheme, targetObjects...)
+	return resources.New().ComputeStatus(client, targetClient, instanceID, bindingID, serviceID, planID, action, namespace)
+}
+
+// ParseObjects decodes the objects in a yaml document stream
+func ParseObjects(content string) ([]*unstructured.Unstructured, error) {
+	return dynamic.StringToUnstructured(content)
+}
+
+func (o *Objects) ids() (instanceID, bindingID, serviceID, planID, namespace string) {
+	namespace = services.Namespace
+	if o.Instance != nil {
+		instanceID = o.Instance.GetName()
+		if o.Instance.GetNamespace() != "" {
+			namespace = o.Instance.GetNamespace()
+		}
+	}
+	if o.Binding != nil {
+		bindingID = o.Binding.GetName()
+	}
+	if o.Service != nil {
+		serviceID = o.Service.Spec.ID
+	}
+	if o.Plan != nil {
+		planID = o.Plan.Spec.ID
+	}
+	return
+}
+
+// sourceClient returns a client serving the objects the way the resource
+// manager fetches them from the cluster
+func (o *Objects) sourceClient(action string) (kubernetes.Client, error) {
+	if o.Service == nil || o.Plan == nil || o.Instance == nil {
+		return nil, fmt.Errorf("service, plan and instance are required to render a plan")
+	}
+	if action == osbv1alpha1.BindAction && o.Binding == nil {
+		return nil, fmt.Errorf("binding is required to render the %s action", action)
+	}
+	if err := apis.AddToScheme(scheme.Scheme); err != nil {
+		return nil, err
+	}
+
+	_, _, _, _, namespace := o.ids()
+	service := o.Service.DeepCopy()
+	service.SetNamespace(services.Namespace)
+	plan := o.Plan.DeepCopy()
+	plan.SetNamespace(services.Namespace)
+	instance := o.Instance.DeepCopy()
+	instance.SetNamespace(namespace)
+	objects := []runtime.Object{service, plan, instance}
+	if o.Binding != nil {
+		binding := o.Binding.DeepCopy()
+		binding.SetNamespace(namespace)
+		objects = append(objects, binding)
+	}
+	return fake.NewFakeClientWithScheme(scheme.Scheme, objects...), nil
+}
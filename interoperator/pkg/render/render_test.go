@@ -0,0 +1,90 @@
+package render
+
+import (
+	"testing"
+
+	osbv1alpha1 "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis/osb/v1alpha1"
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRender(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	objects := &Objects{
+		Service: &osbv1alpha1.SFService{
+			ObjectMeta: metav1.ObjectMeta{Name: "service-id"},
+			Spec:       osbv1alpha1.SFServiceSpec{ID: "service-id"},
+		},
+		Plan: &osbv1alpha1.SFPlan{
+			ObjectMeta: metav1.ObjectMeta{Name: "plan-id"},
+			Spec: osbv1alpha1.SFPlanSpec{
+				ID:        "plan-id",
+				ServiceID: "service-id",
+				Templates: []osbv1alpha1.TemplateSpec{
+					{
+						Action: osbv1alpha1.ProvisionAction,
+						Type:   "gotemplate",
+						Content: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .instance.metadata.name }}
+data:
+  plan: {{ .plan.spec.id }}`,
+					},
+					{
+						Action: osbv1alpha1.SourcesAction,
+						Type:   "gotemplate",
+						Content: `config:
+  apiVersion: v1
+  kind: ConfigMap
+  name: {{ .instance.metadata.name }}`,
+					},
+					{
+						Action: osbv1alpha1.StatusAction,
+						Type:   "gotemplate",
+						Content: `provision:
+  state: {{ .config.data.state }}`,
+					},
+				},
+			},
+		},
+		Instance: &osbv1alpha1.SFServiceInstance{
+			ObjectMeta: metav1.ObjectMeta{Name: "instance-id", Namespace: "default"},
+			Spec: osbv1alpha1.SFServiceInstanceSpec{
+				ServiceID: "service-id",
+				PlanID:    "plan-id",
+			},
+		},
+	}
+
+	resources, err := ExpectedResources(objects, osbv1alpha1.ProvisionAction)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(resources).To(gomega.HaveLen(1))
+	g.Expect(resources[0].GetName()).To(gomega.Equal("instance-id"))
+	g.Expect(resources[0].GetNamespace()).To(gomega.Equal("default"))
+	g.Expect(resources[0].Object["data"]).To(gomega.Equal(map[string]interface{}{"plan": "plan-id"}))
+
+	objects.Sources, err = ParseObjects(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: instance-id
+data:
+  state: succeeded`)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	status, err := Status(objects, osbv1alpha1.ProvisionAction)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(status.Provision.State).To(gomega.Equal("succeeded"))
+
+	sources, err := Sources(objects, osbv1alpha1.ProvisionAction)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(sources).To(gomega.HaveLen(1))
+	g.Expect(sources[0].Kind).To(gomega.Equal("ConfigMap"))
+	g.Expect(sources[0].Name).To(gomega.Equal("instance-id"))
+
+	_, err = ExpectedResources(objects, osbv1alpha1.BindAction)
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	_, err = ExpectedResources(&Objects{}, osbv1alpha1.ProvisionAction)
+	g.Expect(err).To(gomega.HaveOccurred())
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2018 The Service Fabrik Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	osbv1alpha1 "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis/osb/v1alpha1"
+)
+
+const defaultStrategy = "least-instances"
+
+// Request is the placement of a new service instance
+type Request struct {
+	Instance *osbv1alpha1.SFServiceInstance
+	Plan     *osbv1alpha1.SFPlan
+	// Clusters are the clusters the instance can be deployed to
+	Clusters []osbv1alpha1.SFCluster
+	// Instances are the other instances, deployed to any cluster
+	Instances []osbv1alpha1.SFServiceInstance
+}
+
+// Scheduler picks the cluster a new service instance is deployed to
+type Scheduler interface {
+	// Schedule returns the name of the cluster, out of the clusters of the
+	// request, the instance is deployed to
+	Schedule(req *Request) (string, error)
+}
+
+// NoClusterError is returned when none of the clusters can host the instance
+type NoClusterError struct {
+	message string
+}
+
+func (e *NoClusterError) Error() string {
+	return e.message
+}
+
+// IsNoCluster returns true if <err> is a NoClusterError
+func IsNoCluster(err error) bool {
+	_, ok := err.(*NoClusterError)
+	return ok
+}
+
+var (
+	strategiesLock sync.Mutex
+	strategies     = make(map[string]func() Scheduler)
+	strategy       = defaultStrategy
+)
+
+func init() {
+	Register("least-instances", NewLeastInstances)
+	Register("capacity-aware", NewCapacityAware)
+	Register("round-robin", NewRoundRobin)
+}
+
+// AddFlags registers the --scheduler flag selecting the strategy of the
+// Default Scheduler in <fs>
+func AddFlags(fs *flag.FlagSet) {
+	fs.StringVar(&strategy, "scheduler", defaultStrategy, "Strategy placing new service instances on the clusters, e.g. least-instances, capacity-aware or round-robin.")
+}
+
+// Register makes a strategy available under <name>. The strategy is created
+// by <f> when it is selected.
+func Register(name string, f func() Scheduler) {
+	strategiesLock.Lock()
+	defer strategiesLock.Unlock()
+	strategies[name] = f
+}
+
+// New returns the Scheduler of the strategy registered under <name>. The
+// strategy chooses among the schedulable clusters matching the cluster
+// selector of the plan which have capacity left. Concurrent requests are
+// scheduled one at a time, and the placements not yet observed in the
+// instances of the requests are counted.
+func New(name string) (Scheduler, error) {
+	strategiesLock.Lock()
+	f, ok := strategies[name]
+	strategiesLock.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unable to create scheduler for strategy %s. not implemented", name)
+	}
+	return newPlacements(NewSchedulableFilter(NewLabelAffinity(NewCapacityFilter(f())))), nil
+}
+
+// placements schedules the requests one at a time and records the
+// placements, until the instances of the requests reflect them. The
+// instances are read from a cache, which may not contain the placements of
+// the previous requests yet.
+type placements struct {
+	mu        sync.Mutex
+	scheduler Scheduler
+	// pending are the clusters of the instances scheduled but not yet
+	// observed, by namespace/name of the instance
+	pending map[string]string
+}
+
+func newPlacements(scheduler Scheduler) Scheduler {
+	return &placements{
+		scheduler: scheduler,
+		pending:   make(map[string]string),
+	}
+}
+
+func (p *placements) Schedule(req *Request) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	observed := make(map[string]bool, len(req.Instances))
+	instances := make([]osbv1alpha1.SFServiceInstance, len(req.Instances))
+	for i, instance := range req.Instances {
+		key := instanceKey(&instance)
+		observed[key] = true
+		if clusterID, ok := p.pending[key]; ok {
+			if instance.Spec.ClusterID != "" {
+				delete(p.pending, key)
+			} else {
+				instance = *instance.DeepCopy()
+				instance.Spec.ClusterID = clusterID
+			}
+		}
+		instances[i] = instance
+	}
+	// Forget the placements of the deleted instances
+	for key := range p.pending {
+		if !observed[key] {
+			delete(p.pending, key)
+		}
+	}
+
+	scheduled := *req
+	scheduled.Instances = instances
+	clusterID, err := p.scheduler.Schedule(&scheduled)
+	if err != nil {
+		return "", err
+	}
+	if req.Instance != nil {
+		p.pending[instanceKey(req.Instance)] = clusterID
+	}
+	return clusterID, nil
+}
+
+func instanceKey(instance *osbv1alpha1.SFServiceInstance) string {
+	return instance.GetNamespace() + "/" + instance.GetName()
+}
+
+// Default returns the Scheduler of the strategy selected by the --scheduler
+// flag
+func Default() (Scheduler, error) {
+	return New(strategy)
+}
+
+// countInstances returns the number of instances deployed to each cluster,
+// excluding the instance being scheduled
+func countInstances(req *Request) map[string]int {
+	counts := make(map[string]int)
+	for _, instance := range req.Instances {
+		if req.Instance != nil && instance.GetName() == req.Instance.GetName() {
+			continue
+		}
+		if instance.Spec.ClusterID != "" {
+			counts[instance.Spec.ClusterID]++
+		}
+	}
+	return counts
+}
+
+// sortedClusters returns the clusters ordered by their name
+func sortedClusters(clusters []osbv1alpha1.SFCluster) []osbv1alpha1.SFCluster {
+	result := make([]osbv1alpha1.SFCluster, len(clusters))
+	copy(result, clusters)
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].GetName() < result[j].GetName()
+	})
+	return result
+}
+
+func clusterNames(clusters []osbv1alpha1.SFCluster) string {
+	names := make([]string, 0, len(clusters))
+	for _, cluster := range clusters {
+		names = append(names, cluster.GetName())
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,290 @@
+/*
+Copyright 2018 The Service Fabrik Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"flag"
+	"fmt"
+	"testing"
+
+	osbv1alpha1 "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis/osb/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func cluster(name string, capacity int, labels map[string]string) osbv1alpha1.SFCluster {
+	return osbv1alpha1.SFCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		Spec:       osbv1alpha1.SFClusterSpec{Capacity: capacity},
+	}
+}
+
+func cordoned(cluster osbv1alpha1.SFCluster) osbv1alpha1.SFCluster {
+	cluster.Spec.Unschedulable = true
+	return cluster
+}
+
+func unready(cluster osbv1alpha1.SFCluster) osbv1alpha1.SFCluster {
+	cluster.Status.Conditions = []osbv1alpha1.Condition{
+		osbv1alpha1.Condition{Type: osbv1alpha1.ClusterReady, Status: osbv1alpha1.ConditionFalse},
+	}
+	return cluster
+}
+
+func instances(counts map[string]int) []osbv1alpha1.SFServiceInstance {
+	var result []osbv1alpha1.SFServiceInstance
+	for clusterID, count := range counts {
+		for i := 0; i < count; i++ {
+			result = append(result, osbv1alpha1.SFServiceInstance{
+				ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-%d", clusterID, i)},
+				Spec:       osbv1alpha1.SFServiceInstanceSpec{ClusterID: clusterID},
+			})
+		}
+	}
+	return result
+}
+
+func plan(metadata string) *osbv1alpha1.SFPlan {
+	plan := &osbv1alpha1.SFPlan{
+		Spec: osbv1alpha1.SFPlanSpec{ID: "plan-id"},
+	}
+	if metadata != "" {
+		plan.Spec.Metadata = &runtime.RawExtension{Raw: []byte(metadata)}
+	}
+	return plan
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy string
+		req      *Request
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "least instances",
+			strategy: "least-instances",
+			req: &Request{
+				Plan:      plan(""),
+				Clusters:  []osbv1alpha1.SFCluster{cluster("a", 0, nil), cluster("b", 0, nil), cluster("c", 0, nil)},
+				Instances: instances(map[string]int{"a": 2, "b": 1, "c": 1}),
+			},
+			want: "b",
+		},
+		{
+			name:     "least instances skips full clusters",
+			strategy: "least-instances",
+			req: &Request{
+				Plan:      plan(""),
+				Clusters:  []osbv1alpha1.SFCluster{cluster("a", 3, nil), cluster("b", 1, nil)},
+				Instances: instances(map[string]int{"a": 2, "b": 1}),
+			},
+			want: "a",
+		},
+		{
+			name:     "capacity aware",
+			strategy: "capacity-aware",
+			req: &Request{
+				Plan:      plan(""),
+				Clusters:  []osbv1alpha1.SFCluster{cluster("a", 10, nil), cluster("b", 4, nil)},
+				Instances: instances(map[string]int{"a": 6, "b": 1}),
+			},
+			want: "a",
+		},
+		{
+			name:     "capacity aware prefers unlimited clusters",
+			strategy: "capacity-aware",
+			req: &Request{
+				Plan:      plan(""),
+				Clusters:  []osbv1alpha1.SFCluster{cluster("a", 10, nil), cluster("b", 0, nil)},
+				Instances: instances(map[string]int{"b": 5}),
+			},
+			want: "b",
+		},
+		{
+			name:     "round robin",
+			strategy: "round-robin",
+			req: &Request{
+				Plan:     plan(""),
+				Clusters: []osbv1alpha1.SFCluster{cluster("b", 0, nil), cluster("a", 0, nil)},
+			},
+			want: "a",
+		},
+		{
+			name:     "label affinity",
+			strategy: "least-instances",
+			req: &Request{
+				Plan: plan(`{"clusterSelector": {"matchLabels": {"region": "eu"}}}`),
+				Clusters: []osbv1alpha1.SFCluster{
+					cluster("a", 0, map[string]string{"region": "us"}),
+					cluster("b", 0, map[string]string{"region": "eu"}),
+				},
+				Instances: instances(map[string]int{"b": 3}),
+			},
+			want: "b",
+		},
+		{
+			name:     "skips cordoned clusters",
+			strategy: "least-instances",
+			req: &Request{
+				Plan:      plan(""),
+				Clusters:  []osbv1alpha1.SFCluster{cordoned(cluster("a", 0, nil)), cluster("b", 0, nil)},
+				Instances: instances(map[string]int{"b": 3}),
+			},
+			want: "b",
+		},
+		{
+			name:     "skips unready clusters",
+			strategy: "capacity-aware",
+			req: &Request{
+				Plan:     plan(""),
+				Clusters: []osbv1alpha1.SFCluster{unready(cluster("a", 10, nil)), cluster("b", 2, nil)},
+			},
+			want: "b",
+		},
+		{
+			name:     "error if all clusters are cordoned or unready",
+			strategy: "round-robin",
+			req: &Request{
+				Plan:     plan(""),
+				Clusters: []osbv1alpha1.SFCluster{cordoned(cluster("a", 0, nil)), unready(cluster("b", 0, nil))},
+			},
+			wantErr: true,
+		},
+		{
+			name:     "error if no cluster matches",
+			strategy: "least-instances",
+			req: &Request{
+				Plan:     plan(`{"clusterSelector": {"matchExpressions": [{"key": "region", "operator": "In", "values": ["ap"]}]}}`),
+				Clusters: []osbv1alpha1.SFCluster{cluster("a", 0, map[string]string{"region": "us"})},
+			},
+			wantErr: true,
+		},
+		{
+			name:     "error if all clusters are full",
+			strategy: "round-robin",
+			req: &Request{
+				Plan:      plan(""),
+				Clusters:  []osbv1alpha1.SFCluster{cluster("a", 1, nil)},
+				Instances: instances(map[string]int{"a": 1}),
+			},
+			wantErr: true,
+		},
+		{
+			name:     "error on unknown strategy",
+			strategy: "unknown",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(tt.strategy)
+			if err == nil {
+				var got string
+				got, err = s.Schedule(tt.req)
+				if got != tt.want {
+					t.Errorf("Scheduler.Schedule() = %v, want %v", got, tt.want)
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Scheduler.Schedule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_placements_Schedule(t *testing.T) {
+	s, err := New("least-instances")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	newInstance := func(name string) *osbv1alpha1.SFServiceInstance {
+		return &osbv1alpha1.SFServiceInstance{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	}
+	clusters := []osbv1alpha1.SFCluster{cluster("a", 1, nil), cluster("b", 1, nil)}
+	// The instances are listed before either of them is placed
+	listed := []osbv1alpha1.SFServiceInstance{*newInstance("x"), *newInstance("y")}
+
+	got, err := s.Schedule(&Request{Instance: newInstance("x"), Plan: plan(""), Clusters: clusters, Instances: listed})
+	if err != nil || got != "a" {
+		t.Fatalf("Scheduler.Schedule() = %v, %v, want a", got, err)
+	}
+	got, err = s.Schedule(&Request{Instance: newInstance("y"), Plan: plan(""), Clusters: clusters, Instances: listed})
+	if err != nil || got != "b" {
+		t.Errorf("Scheduler.Schedule() = %v, %v, want b as x is placed on a", got, err)
+	}
+	if _, err := s.Schedule(&Request{Instance: newInstance("z"), Plan: plan(""), Clusters: clusters, Instances: listed}); !IsNoCluster(err) {
+		t.Errorf("Scheduler.Schedule() error = %v, want NoClusterError as the clusters are full", err)
+	}
+
+	// The placements are forgotten once the instances are deleted
+	got, err = s.Schedule(&Request{Instance: newInstance("z"), Plan: plan(""), Clusters: clusters})
+	if err != nil || got != "a" {
+		t.Errorf("Scheduler.Schedule() = %v, %v, want a", got, err)
+	}
+}
+
+func TestAddFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	AddFlags(fs)
+	defer func() { strategy = defaultStrategy }()
+	if err := fs.Parse([]string{"--scheduler", "round-robin"}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if strategy != "round-robin" {
+		t.Errorf("AddFlags() strategy = %v, want round-robin", strategy)
+	}
+}
+
+func Test_roundRobin_Schedule(t *testing.T) {
+	s := NewRoundRobin()
+	req := &Request{
+		Clusters: []osbv1alpha1.SFCluster{cluster("c", 0, nil), cluster("a", 0, nil), cluster("b", 0, nil)},
+	}
+	for _, want := range []string{"a", "b", "c", "a"} {
+		got, err := s.Schedule(req)
+		if err != nil {
+			t.Fatalf("roundRobin.Schedule() error = %v", err)
+		}
+		if got != want {
+			t.Errorf("roundRobin.Schedule() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register("first", func() Scheduler { return &first{} })
+	s, err := New("first")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	got, err := s.Schedule(&Request{
+		Clusters: []osbv1alpha1.SFCluster{cluster("b", 0, nil), cluster("a", 0, nil)},
+	})
+	if err != nil || got != "b" {
+		t.Errorf("Scheduler.Schedule() = %v, %v, want b", got, err)
+	}
+}
+
+// first picks the first cluster of the request
+type first struct{}
+
+func (s *first) Schedule(req *Request) (string, error) {
+	return req.Clusters[0].GetName(), nil
+}
@@ -0,0 +1,241 @@
+/*
+Copyright 2018 The Service Fabrik Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+
+	osbv1alpha1 "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis/osb/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// clusterSelectorKey is the key of the label selector of the clusters in the
+// metadata of a plan
+const clusterSelectorKey = "clusterSelector"
+
+type labelAffinity struct {
+	next Scheduler
+}
+
+// NewLabelAffinity returns a Scheduler which passes the clusters matching
+// the cluster selector in the metadata of the plan to <next>
+func NewLabelAffinity(next Scheduler) Scheduler {
+	return &labelAffinity{next: next}
+}
+
+func (s *labelAffinity) Schedule(req *Request) (string, error) {
+	selector, err := clusterSelector(req.Plan)
+	if err != nil {
+		return "", err
+	}
+	if selector.Empty() {
+		return s.next.Schedule(req)
+	}
+	var clusters []osbv1alpha1.SFCluster
+	for _, cluster := range req.Clusters {
+		if selector.Matches(labels.Set(cluster.GetLabels())) {
+			clusters = append(clusters, cluster)
+		}
+	}
+	if len(clusters) == 0 {
+		return "", &NoClusterError{
+			message: fmt.Sprintf("no cluster matches the cluster selector %s of plan %s", selector, req.Plan.Spec.ID),
+		}
+	}
+	filtered := *req
+	filtered.Clusters = clusters
+	return s.next.Schedule(&filtered)
+}
+
+// clusterSelector returns the label selector in the metadata of the plan
+func clusterSelector(plan *osbv1alpha1.SFPlan) (labels.Selector, error) {
+	if plan == nil || plan.Spec.Metadata == nil || len(plan.Spec.Metadata.Raw) == 0 {
+		return labels.Everything(), nil
+	}
+	metadata := struct {
+		ClusterSelector *metav1.LabelSelector `json:"clusterSelector"`
+	}{}
+	if err := json.Unmarshal(plan.Spec.Metadata.Raw, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse %s of plan %s. %v", clusterSelectorKey, plan.Spec.ID, err)
+	}
+	if metadata.ClusterSelector == nil {
+		return labels.Everything(), nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(metadata.ClusterSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s of plan %s. %v", clusterSelectorKey, plan.Spec.ID, err)
+	}
+	return selector, nil
+}
+
+type schedulableFilter struct {
+	next Scheduler
+}
+
+// NewSchedulableFilter returns a Scheduler which passes the clusters which
+// are neither cordoned nor unready to <next>
+func NewSchedulableFilter(next Scheduler) Scheduler {
+	return &schedulableFilter{next: next}
+}
+
+func (s *schedulableFilter) Schedule(req *Request) (string, error) {
+	var clusters []osbv1alpha1.SFCluster
+	for _, cluster := range req.Clusters {
+		if schedulable(cluster) {
+			clusters = append(clusters, cluster)
+		}
+	}
+	if len(clusters) == 0 {
+		return "", &NoClusterError{
+			message: fmt.Sprintf("none of the clusters %s is schedulable", clusterNames(req.Clusters)),
+		}
+	}
+	filtered := *req
+	filtered.Clusters = clusters
+	return s.next.Schedule(&filtered)
+}
+
+// schedulable returns false if the cluster is cordoned or its Ready
+// condition is False. Clusters not probed yet are schedulable.
+func schedulable(cluster osbv1alpha1.SFCluster) bool {
+	if cluster.Spec.Unschedulable {
+		return false
+	}
+	ready := osbv1alpha1.GetCondition(cluster.Status.Conditions, osbv1alpha1.ClusterReady)
+	return ready == nil || ready.Status != osbv1alpha1.ConditionFalse
+}
+
+type capacityFilter struct {
+	next Scheduler
+}
+
+// NewCapacityFilter returns a Scheduler which passes the clusters with
+// capacity left to <next>
+func NewCapacityFilter(next Scheduler) Scheduler {
+	return &capacityFilter{next: next}
+}
+
+func (s *capacityFilter) Schedule(req *Request) (string, error) {
+	counts := countInstances(req)
+	var clusters []osbv1alpha1.SFCluster
+	for _, cluster := range req.Clusters {
+		if freeCapacity(cluster, counts) > 0 {
+			clusters = append(clusters, cluster)
+		}
+	}
+	if len(clusters) == 0 {
+		return "", &NoClusterError{
+			message: fmt.Sprintf("no capacity left on the clusters %s", clusterNames(req.Clusters)),
+		}
+	}
+	filtered := *req
+	filtered.Clusters = clusters
+	return s.next.Schedule(&filtered)
+}
+
+// freeCapacity returns the number of instances the cluster can still host.
+// Clusters without a capacity are unlimited.
+func freeCapacity(cluster osbv1alpha1.SFCluster, counts map[string]int) int {
+	if cluster.Spec.Capacity <= 0 {
+		return math.MaxInt32
+	}
+	return cluster.Spec.Capacity - counts[cluster.GetName()]
+}
+
+type leastInstances struct{}
+
+// NewLeastInstances returns a Scheduler picking the cluster hosting the
+// fewest instances
+func NewLeastInstances() Scheduler {
+	return &leastInstances{}
+}
+
+func (s *leastInstances) Schedule(req *Request) (string, error) {
+	if len(req.Clusters) == 0 {
+		return "", &NoClusterError{message: "no cluster available"}
+	}
+	counts := countInstances(req)
+	var result string
+	min := math.MaxInt32
+	for _, cluster := range sortedClusters(req.Clusters) {
+		if counts[cluster.GetName()] < min {
+			result = cluster.GetName()
+			min = counts[result]
+		}
+	}
+	return result, nil
+}
+
+type capacityAware struct{}
+
+// NewCapacityAware returns a Scheduler picking the cluster with the most
+// capacity left. Ties are broken by the number of instances.
+func NewCapacityAware() Scheduler {
+	return &capacityAware{}
+}
+
+func (s *capacityAware) Schedule(req *Request) (string, error) {
+	if len(req.Clusters) == 0 {
+		return "", &NoClusterError{message: "no cluster available"}
+	}
+	counts := countInstances(req)
+	var result string
+	maxFree, minCount := math.MinInt32, math.MaxInt32
+	for _, cluster := range sortedClusters(req.Clusters) {
+		name := cluster.GetName()
+		free := freeCapacity(cluster, counts)
+		if free > maxFree || (free == maxFree && counts[name] < minCount) {
+			result = name
+			maxFree, minCount = free, counts[name]
+		}
+	}
+	return result, nil
+}
+
+type roundRobin struct {
+	mu   sync.Mutex
+	last string
+}
+
+// NewRoundRobin returns a Scheduler picking the clusters in turn, in the
+// order of their names
+func NewRoundRobin() Scheduler {
+	return &roundRobin{}
+}
+
+func (s *roundRobin) Schedule(req *Request) (string, error) {
+	if len(req.Clusters) == 0 {
+		return "", &NoClusterError{message: "no cluster available"}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clusters := sortedClusters(req.Clusters)
+	result := clusters[0].GetName()
+	for _, cluster := range clusters {
+		if cluster.GetName() > s.last {
+			result = cluster.GetName()
+			break
+		}
+	}
+	s.last = result
+	return result, nil
+}
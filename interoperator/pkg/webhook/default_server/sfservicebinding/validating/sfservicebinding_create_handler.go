@@ -0,0 +1,104 @@
+/*
+Copyright 2018 The Service Fabrik Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validating
+
+import (
+	"context"
+	"net/http"
+
+	osbv1alpha1 "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis/osb/v1alpha1"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/schemas"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/services"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission/types"
+)
+
+func init() {
+	webhookName := "validating-create-sfservicebinding"
+	if HandlerMap[webhookName] == nil {
+		HandlerMap[webhookName] = []admission.Handler{}
+	}
+	HandlerMap[webhookName] = append(HandlerMap[webhookName], &SFServiceBindingCreateHandler{})
+}
+
+// SFServiceBindingCreateHandler validates the service, the plan and the
+// parameters of a new SFServiceBinding against the SFPlan
+type SFServiceBindingCreateHandler struct {
+	Client client.Client
+
+	// Decoder decodes objects
+	Decoder types.Decoder
+}
+
+func (h *SFServiceBindingCreateHandler) validatingSFServiceBindingFn(ctx context.Context, obj *osbv1alpha1.SFServiceBinding) (bool, string, error) {
+	_, plan, err := services.FindServiceInfo(h.Client, obj.Spec.ServiceID, obj.Spec.PlanID, services.Namespace)
+	if err != nil {
+		if services.IsNotFound(err) {
+			return false, err.Error(), nil
+		}
+		return false, "", err
+	}
+	if plan.Spec.Schemas == nil {
+		return true, "", nil
+	}
+
+	err = schemas.Validate(plan.Spec.Schemas.Binding.Create.Parameters, obj.Spec.RawParameters)
+	if err != nil {
+		if schemas.IsValidationError(err) {
+			return false, err.Error(), nil
+		}
+		return false, "", err
+	}
+	return true, "", nil
+}
+
+var _ admission.Handler = &SFServiceBindingCreateHandler{}
+
+// Handle handles admission requests.
+func (h *SFServiceBindingCreateHandler) Handle(ctx context.Context, req types.Request) types.Response {
+	obj := &osbv1alpha1.SFServiceBinding{}
+
+	err := h.Decoder.Decode(req, obj)
+	if err != nil {
+		return admission.ErrorResponse(http.StatusBadRequest, err)
+	}
+
+	allowed, reason, err := h.validatingSFServiceBindingFn(ctx, obj)
+	if err != nil {
+		return admission.ErrorResponse(http.StatusInternalServerError, err)
+	}
+	return admission.ValidationResponse(allowed, reason)
+}
+
+var _ inject.Client = &SFServiceBindingCreateHandler{}
+
+// InjectClient injects the client into the SFServiceBindingCreateHandler
+func (h *SFServiceBindingCreateHandler) InjectClient(c client.Client) error {
+	h.Client = c
+	return nil
+}
+
+var _ inject.Decoder = &SFServiceBindingCreateHandler{}
+
+// InjectDecoder injects the decoder into the SFServiceBindingCreateHandler
+func (h *SFServiceBindingCreateHandler) InjectDecoder(d types.Decoder) error {
+	h.Decoder = d
+	return nil
+}
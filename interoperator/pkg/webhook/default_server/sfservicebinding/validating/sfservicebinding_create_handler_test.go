@@ -0,0 +1,156 @@
+/*
+Copyright 2018 The Service Fabrik Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validating
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis"
+	osbv1alpha1 "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis/osb/v1alpha1"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission/types"
+)
+
+func newHandler(t *testing.T) *SFServiceBindingCreateHandler {
+	if err := apis.AddToScheme(scheme.Scheme); err != nil {
+		t.Fatal(err)
+	}
+	decoder, err := admission.NewDecoder(scheme.Scheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := &osbv1alpha1.SFService{
+		ObjectMeta: metav1.ObjectMeta{Name: "service-id", Namespace: "default"},
+		Spec:       osbv1alpha1.SFServiceSpec{ID: "service-id"},
+	}
+	schema := &runtime.RawExtension{Raw: []byte(`{"type": "object", "properties": {"role": {"type": "string"}}, "additionalProperties": false}`)}
+	plan := func(id string, schemas *osbv1alpha1.ServiceSchemas) *osbv1alpha1.SFPlan {
+		return &osbv1alpha1.SFPlan{
+			ObjectMeta: metav1.ObjectMeta{Name: id, Namespace: "default"},
+			Spec: osbv1alpha1.SFPlanSpec{
+				ID:        id,
+				ServiceID: "service-id",
+				Bindable:  true,
+				Schemas:   schemas,
+			},
+		}
+	}
+	return &SFServiceBindingCreateHandler{
+		Client: fake.NewFakeClient(
+			service,
+			plan("plan-id", &osbv1alpha1.ServiceSchemas{
+				Binding: osbv1alpha1.ServiceBindingSchema{
+					Create: osbv1alpha1.Schema{Parameters: schema},
+				},
+			}),
+			plan("no-schemas-plan-id", nil),
+			plan("no-binding-schema-plan-id", &osbv1alpha1.ServiceSchemas{}),
+		),
+		Decoder: decoder,
+	}
+}
+
+func newBinding(planID, parameters string) *osbv1alpha1.SFServiceBinding {
+	binding := &osbv1alpha1.SFServiceBinding{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "osb.servicefabrik.io/v1alpha1", Kind: "SFServiceBinding"},
+		ObjectMeta: metav1.ObjectMeta{Name: "binding-id", Namespace: "default"},
+		Spec: osbv1alpha1.SFServiceBindingSpec{
+			ServiceID:  "service-id",
+			PlanID:     planID,
+			InstanceID: "instance-id",
+			ID:         "binding-id",
+		},
+	}
+	if parameters != "" {
+		binding.Spec.RawParameters = &runtime.RawExtension{Raw: []byte(parameters)}
+	}
+	return binding
+}
+
+func inNamespace(binding *osbv1alpha1.SFServiceBinding, namespace string) *osbv1alpha1.SFServiceBinding {
+	binding.SetNamespace(namespace)
+	return binding
+}
+
+func TestSFServiceBindingCreateHandler_Handle(t *testing.T) {
+	h := newHandler(t)
+	tests := []struct {
+		name        string
+		obj         *osbv1alpha1.SFServiceBinding
+		wantAllowed bool
+	}{
+		{
+			name:        "create with valid parameters",
+			obj:         newBinding("plan-id", `{"role": "admin"}`),
+			wantAllowed: true,
+		},
+		{
+			name:        "create without parameters",
+			obj:         newBinding("plan-id", ""),
+			wantAllowed: true,
+		},
+		{
+			name: "create with invalid parameters",
+			obj:  newBinding("plan-id", `{"role": 1}`),
+		},
+		{
+			name: "create with unknown parameters",
+			obj:  newBinding("plan-id", `{"foo": "bar"}`),
+		},
+		{
+			name: "create with unknown plan",
+			obj:  newBinding("unknown-plan-id", `{"role": "admin"}`),
+		},
+		{
+			name:        "create in another namespace than the plan",
+			obj:         inNamespace(newBinding("plan-id", `{"role": "admin"}`), "sf-instance-id"),
+			wantAllowed: true,
+		},
+		{
+			name: "create with invalid parameters in another namespace than the plan",
+			obj:  inNamespace(newBinding("plan-id", `{"role": 1}`), "sf-instance-id"),
+		},
+		{
+			name:        "create for a plan without schemas",
+			obj:         newBinding("no-schemas-plan-id", `{"foo": "bar"}`),
+			wantAllowed: true,
+		},
+		{
+			name:        "create for a plan without binding schema",
+			obj:         newBinding("no-binding-schema-plan-id", `{"foo": "bar"}`),
+			wantAllowed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &admissionv1beta1.AdmissionRequest{Operation: admissionv1beta1.Create}
+			req.Object.Raw, _ = json.Marshal(tt.obj)
+			resp := h.Handle(context.TODO(), types.Request{AdmissionRequest: req})
+			if resp.Response.Allowed != tt.wantAllowed {
+				t.Errorf("Handle() allowed = %v, want %v, result %v", resp.Response.Allowed, tt.wantAllowed, resp.Response.Result)
+			}
+		})
+	}
+}
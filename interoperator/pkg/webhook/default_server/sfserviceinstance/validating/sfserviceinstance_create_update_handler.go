@@ -0,0 +1,162 @@
+/*
+Copyright 2018 The Service Fabrik Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validating
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	osbv1alpha1 "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis/osb/v1alpha1"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/schemas"
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/internal/services"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission/types"
+)
+
+func init() {
+	webhookName := "validating-create-update-sfserviceinstance"
+	if HandlerMap[webhookName] == nil {
+		HandlerMap[webhookName] = []admission.Handler{}
+	}
+	HandlerMap[webhookName] = append(HandlerMap[webhookName], &SFServiceInstanceCreateUpdateHandler{})
+}
+
+// SFServiceInstanceCreateUpdateHandler validates the service, the plan and
+// the parameters of a SFServiceInstance against the SFPlan
+type SFServiceInstanceCreateUpdateHandler struct {
+	Client client.Client
+
+	// Decoder decodes objects
+	Decoder types.Decoder
+}
+
+// validatingSFServiceInstanceFn validates the instance. <old> is the instance
+// before the update, nil on create. Updates which change neither the
+// service, the plan nor the parameters, e.g. the updates of the status by
+// the controller, are not validated.
+func (h *SFServiceInstanceCreateUpdateHandler) validatingSFServiceInstanceFn(ctx context.Context, obj, old *osbv1alpha1.SFServiceInstance) (bool, string, error) {
+	if !obj.GetDeletionTimestamp().IsZero() {
+		return true, "", nil
+	}
+	planChanged := old != nil && (old.Spec.ServiceID != obj.Spec.ServiceID || old.Spec.PlanID != obj.Spec.PlanID)
+	if old != nil && !planChanged && reflect.DeepEqual(old.Spec.RawParameters, obj.Spec.RawParameters) {
+		return true, "", nil
+	}
+
+	service, plan, err := services.FindServiceInfo(h.Client, obj.Spec.ServiceID, obj.Spec.PlanID, services.Namespace)
+	if err != nil {
+		if services.IsNotFound(err) {
+			return false, err.Error(), nil
+		}
+		return false, "", err
+	}
+
+	schema := instanceSchema(plan, old == nil)
+	if planChanged {
+		if old.Spec.ServiceID != obj.Spec.ServiceID {
+			return false, fmt.Sprintf("service of instance %s can not be changed", obj.GetName()), nil
+		}
+		_, oldPlan, err := services.FindServiceInfo(h.Client, old.Spec.ServiceID, old.Spec.PlanID, services.Namespace)
+		if err != nil && !services.IsNotFound(err) {
+			return false, "", err
+		}
+		if !planUpdatable(service, oldPlan) {
+			return false, fmt.Sprintf("plan %s of instance %s is not updatable", old.Spec.PlanID, obj.GetName()), nil
+		}
+	}
+
+	if err := schemas.Validate(schema, obj.Spec.RawParameters); err != nil {
+		if schemas.IsValidationError(err) {
+			return false, err.Error(), nil
+		}
+		return false, "", err
+	}
+	return true, "", nil
+}
+
+// planUpdatable returns whether the plan of an instance of the service can be
+// changed, planUpdatable of the current plan overrides the one of the service
+func planUpdatable(service *osbv1alpha1.SFService, plan *osbv1alpha1.SFPlan) bool {
+	if plan != nil && plan.Spec.PlanUpdatable != nil {
+		return *plan.Spec.PlanUpdatable
+	}
+	return service.Spec.PlanUpdatable
+}
+
+// instanceSchema returns the schema of the parameters of the instance to
+// create or to update
+func instanceSchema(plan *osbv1alpha1.SFPlan, create bool) *runtime.RawExtension {
+	if plan.Spec.Schemas == nil {
+		return nil
+	}
+	if create {
+		return plan.Spec.Schemas.Instance.Create.Parameters
+	}
+	return plan.Spec.Schemas.Instance.Update.Parameters
+}
+
+var _ admission.Handler = &SFServiceInstanceCreateUpdateHandler{}
+
+// Handle handles admission requests.
+func (h *SFServiceInstanceCreateUpdateHandler) Handle(ctx context.Context, req types.Request) types.Response {
+	obj := &osbv1alpha1.SFServiceInstance{}
+
+	err := h.Decoder.Decode(req, obj)
+	if err != nil {
+		return admission.ErrorResponse(http.StatusBadRequest, err)
+	}
+
+	var old *osbv1alpha1.SFServiceInstance
+	if req.AdmissionRequest.Operation == admissionv1beta1.Update {
+		old = &osbv1alpha1.SFServiceInstance{}
+		err = h.Decoder.Decode(types.Request{
+			AdmissionRequest: &admissionv1beta1.AdmissionRequest{Object: req.AdmissionRequest.OldObject},
+		}, old)
+		if err != nil {
+			return admission.ErrorResponse(http.StatusBadRequest, err)
+		}
+	}
+
+	allowed, reason, err := h.validatingSFServiceInstanceFn(ctx, obj, old)
+	if err != nil {
+		return admission.ErrorResponse(http.StatusInternalServerError, err)
+	}
+	return admission.ValidationResponse(allowed, reason)
+}
+
+var _ inject.Client = &SFServiceInstanceCreateUpdateHandler{}
+
+// InjectClient injects the client into the SFServiceInstanceCreateUpdateHandler
+func (h *SFServiceInstanceCreateUpdateHandler) InjectClient(c client.Client) error {
+	h.Client = c
+	return nil
+}
+
+var _ inject.Decoder = &SFServiceInstanceCreateUpdateHandler{}
+
+// InjectDecoder injects the decoder into the SFServiceInstanceCreateUpdateHandler
+func (h *SFServiceInstanceCreateUpdateHandler) InjectDecoder(d types.Decoder) error {
+	h.Decoder = d
+	return nil
+}
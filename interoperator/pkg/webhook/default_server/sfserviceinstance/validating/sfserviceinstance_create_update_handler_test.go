@@ -0,0 +1,189 @@
+/*
+Copyright 2018 The Service Fabrik Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validating
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis"
+	osbv1alpha1 "github.com/cloudfoundry-incubator/service-fabrik-broker/interoperator/pkg/apis/osb/v1alpha1"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission/types"
+)
+
+func newHandler(t *testing.T) *SFServiceInstanceCreateUpdateHandler {
+	if err := apis.AddToScheme(scheme.Scheme); err != nil {
+		t.Fatal(err)
+	}
+	decoder, err := admission.NewDecoder(scheme.Scheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := func(id string, updatable bool) *osbv1alpha1.SFService {
+		return &osbv1alpha1.SFService{
+			ObjectMeta: metav1.ObjectMeta{Name: id, Namespace: "default"},
+			Spec:       osbv1alpha1.SFServiceSpec{ID: id, PlanUpdatable: updatable},
+		}
+	}
+	updatable, fixed := true, false
+	schema := &runtime.RawExtension{Raw: []byte(`{"type": "object", "properties": {"size": {"type": "integer"}}, "additionalProperties": false}`)}
+	plan := func(id, serviceID string, updatable *bool) *osbv1alpha1.SFPlan {
+		return &osbv1alpha1.SFPlan{
+			ObjectMeta: metav1.ObjectMeta{Name: id, Namespace: "default"},
+			Spec: osbv1alpha1.SFPlanSpec{
+				ID:            id,
+				ServiceID:     serviceID,
+				PlanUpdatable: updatable,
+				Schemas: &osbv1alpha1.ServiceSchemas{
+					Instance: osbv1alpha1.ServiceInstanceSchema{
+						Create: osbv1alpha1.Schema{Parameters: schema},
+						Update: osbv1alpha1.Schema{Parameters: schema},
+					},
+				},
+			},
+		}
+	}
+	return &SFServiceInstanceCreateUpdateHandler{
+		Client: fake.NewFakeClient(
+			service("service-id", false),
+			plan("plan-id", "service-id", nil),
+			plan("updatable-plan-id", "service-id", &updatable),
+			service("updatable-service-id", true),
+			plan("inherited-plan-id", "updatable-service-id", nil),
+			plan("fixed-plan-id", "updatable-service-id", &fixed),
+		),
+		Decoder: decoder,
+	}
+}
+
+func newInstance(planID, parameters string) *osbv1alpha1.SFServiceInstance {
+	return newServiceInstance("service-id", planID, parameters)
+}
+
+func newServiceInstance(serviceID, planID, parameters string) *osbv1alpha1.SFServiceInstance {
+	instance := &osbv1alpha1.SFServiceInstance{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "osb.servicefabrik.io/v1alpha1", Kind: "SFServiceInstance"},
+		ObjectMeta: metav1.ObjectMeta{Name: "instance-id", Namespace: "default"},
+		Spec: osbv1alpha1.SFServiceInstanceSpec{
+			ServiceID: serviceID,
+			PlanID:    planID,
+		},
+	}
+	if parameters != "" {
+		instance.Spec.RawParameters = &runtime.RawExtension{Raw: []byte(parameters)}
+	}
+	return instance
+}
+
+func inNamespace(instance *osbv1alpha1.SFServiceInstance, namespace string) *osbv1alpha1.SFServiceInstance {
+	instance.SetNamespace(namespace)
+	return instance
+}
+
+func TestSFServiceInstanceCreateUpdateHandler_Handle(t *testing.T) {
+	h := newHandler(t)
+	tests := []struct {
+		name        string
+		obj         *osbv1alpha1.SFServiceInstance
+		old         *osbv1alpha1.SFServiceInstance
+		wantAllowed bool
+	}{
+		{
+			name:        "create with valid parameters",
+			obj:         newInstance("plan-id", `{"size": 1}`),
+			wantAllowed: true,
+		},
+		{
+			name: "create with invalid parameters",
+			obj:  newInstance("plan-id", `{"size": "large"}`),
+		},
+		{
+			name: "create with unknown plan",
+			obj:  newInstance("unknown-plan-id", ""),
+		},
+		{
+			name:        "create in another namespace than the plan",
+			obj:         inNamespace(newInstance("plan-id", `{"size": 1}`), "sf-instance-id"),
+			wantAllowed: true,
+		},
+		{
+			name: "create with invalid parameters in another namespace than the plan",
+			obj:  inNamespace(newInstance("plan-id", `{"size": "large"}`), "sf-instance-id"),
+		},
+		{
+			name:        "update without change of the parameters",
+			obj:         newInstance("plan-id", `{"size": "large"}`),
+			old:         newInstance("plan-id", `{"size": "large"}`),
+			wantAllowed: true,
+		},
+		{
+			name: "update with invalid parameters",
+			obj:  newInstance("plan-id", `{"foo": "bar"}`),
+			old:  newInstance("plan-id", `{"size": 1}`),
+		},
+		{
+			name: "update of a plan not updatable",
+			obj:  newInstance("updatable-plan-id", ""),
+			old:  newInstance("plan-id", ""),
+		},
+		{
+			name:        "update of an updatable plan in another namespace than the plan",
+			obj:         inNamespace(newInstance("plan-id", ""), "sf-instance-id"),
+			old:         inNamespace(newInstance("updatable-plan-id", ""), "sf-instance-id"),
+			wantAllowed: true,
+		},
+		{
+			name:        "update of an updatable plan",
+			obj:         newInstance("plan-id", ""),
+			old:         newInstance("updatable-plan-id", ""),
+			wantAllowed: true,
+		},
+		{
+			name:        "update of a plan of an updatable service",
+			obj:         newServiceInstance("updatable-service-id", "fixed-plan-id", ""),
+			old:         newServiceInstance("updatable-service-id", "inherited-plan-id", ""),
+			wantAllowed: true,
+		},
+		{
+			name: "update of a plan not updatable of an updatable service",
+			obj:  newServiceInstance("updatable-service-id", "inherited-plan-id", ""),
+			old:  newServiceInstance("updatable-service-id", "fixed-plan-id", ""),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &admissionv1beta1.AdmissionRequest{Operation: admissionv1beta1.Create}
+			req.Object.Raw, _ = json.Marshal(tt.obj)
+			if tt.old != nil {
+				req.Operation = admissionv1beta1.Update
+				req.OldObject.Raw, _ = json.Marshal(tt.old)
+			}
+			resp := h.Handle(context.TODO(), types.Request{AdmissionRequest: req})
+			if resp.Response.Allowed != tt.wantAllowed {
+				t.Errorf("Handle() allowed = %v, want %v, result %v", resp.Response.Allowed, tt.wantAllowed, resp.Response.Result)
+			}
+		})
+	}
+}
@@ -1173,14 +1173,17 @@ describe('eventmesh', () => {
             labels: {
               state: 'in_progress'
             }
-          },
+          }
+        };
+        const statusPayload = {
           status: {
             state: 'in_progress',
             description: ''
           }
         };
         nockGetResource(CONST.APISERVER.RESOURCE_GROUPS.INTEROPERATOR, CONST.APISERVER.RESOURCE_TYPES.INTEROPERATOR_SERVICEINSTANCES, 'deployment1', 'default', expectedGetResponse);
-        nockPatchResource(CONST.APISERVER.RESOURCE_GROUPS.INTEROPERATOR, CONST.APISERVER.RESOURCE_TYPES.INTEROPERATOR_SERVICEINSTANCES, 'deployment1', 'default', expectedResponse, payload);
+        nockPatchResource(CONST.APISERVER.RESOURCE_GROUPS.INTEROPERATOR, CONST.APISERVER.RESOURCE_TYPES.INTEROPERATOR_SERVICEINSTANCES, 'deployment1', 'default', {}, payload);
+        nockPatchResource(CONST.APISERVER.RESOURCE_GROUPS.INTEROPERATOR, CONST.APISERVER.RESOURCE_TYPES.INTEROPERATOR_SERVICEINSTANCES, 'deployment1/status', 'default', expectedResponse, statusPayload);
         return apiserver.patchOSBResource({
             resourceId: 'deployment1',
             resourceGroup: CONST.APISERVER.RESOURCE_GROUPS.INTEROPERATOR,
@@ -1239,14 +1242,17 @@ describe('eventmesh', () => {
             labels: {
               state: 'in_progress'
             }
-          },
+          }
+        };
+        const statusPayload = {
           status: {
             state: 'in_progress',
             description: ''
           }
         };
         nockGetResource(CONST.APISERVER.RESOURCE_GROUPS.INTEROPERATOR, CONST.APISERVER.RESOURCE_TYPES.INTEROPERATOR_SERVICEINSTANCES, 'deployment1', 'namespace-id', expectedGetResponse);
-        nockPatchResource(CONST.APISERVER.RESOURCE_GROUPS.INTEROPERATOR, CONST.APISERVER.RESOURCE_TYPES.INTEROPERATOR_SERVICEINSTANCES, 'deployment1', 'namespace-id', expectedResponse, payload);
+        nockPatchResource(CONST.APISERVER.RESOURCE_GROUPS.INTEROPERATOR, CONST.APISERVER.RESOURCE_TYPES.INTEROPERATOR_SERVICEINSTANCES, 'deployment1', 'namespace-id', {}, payload);
+        nockPatchResource(CONST.APISERVER.RESOURCE_GROUPS.INTEROPERATOR, CONST.APISERVER.RESOURCE_TYPES.INTEROPERATOR_SERVICEINSTANCES, 'deployment1/status', 'namespace-id', expectedResponse, statusPayload);
         return apiserver.patchOSBResource({
             resourceId: 'deployment1',
             resourceGroup: CONST.APISERVER.RESOURCE_GROUPS.INTEROPERATOR,
@@ -1314,6 +1320,48 @@ describe('eventmesh', () => {
       });
     });
 
+    describe('createOSBResource', () => {
+      it('Creates osb resource and sets its status through the status subresource', () => {
+        const payload = {
+          apiVersion: 'osb.servicefabrik.io/v1alpha1',
+          kind: 'SFServiceInstance',
+          metadata: {
+            name: 'deployment1',
+            labels: {
+              state: 'in_queue'
+            }
+          },
+          spec: {
+            planId: 'plan1',
+            serviceId: 'service1'
+          }
+        };
+        const statusPayload = {
+          status: {
+            state: 'in_queue'
+          }
+        };
+        nockCreateResource(CONST.APISERVER.RESOURCE_GROUPS.INTEROPERATOR, CONST.APISERVER.RESOURCE_TYPES.INTEROPERATOR_SERVICEINSTANCES, {}, payload);
+        nockPatchResource(CONST.APISERVER.RESOURCE_GROUPS.INTEROPERATOR, CONST.APISERVER.RESOURCE_TYPES.INTEROPERATOR_SERVICEINSTANCES, 'deployment1/status', 'default', {}, statusPayload);
+        return apiserver.createOSBResource({
+            resourceId: 'deployment1',
+            resourceGroup: CONST.APISERVER.RESOURCE_GROUPS.INTEROPERATOR,
+            resourceType: CONST.APISERVER.RESOURCE_TYPES.INTEROPERATOR_SERVICEINSTANCES,
+            spec: {
+              plan_id: 'plan1',
+              service_id: 'service1'
+            },
+            status: {
+              state: 'in_queue'
+            }
+          })
+          .then(res => {
+            expect(res.statusCode).to.eql(201);
+            verify();
+          });
+      });
+    });
+
     describe('createOrUpdateServicePlan', () => {
       it('Create service crd successfully for first time', () => {
         const crdJson = utils.getServiceCrdFromConfig(config.services[0]);